@@ -1,25 +1,54 @@
 package main
 
 import (
+	"context"
 	"database/sql"
+	"flag"
 	"fmt"
+	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
 
 	tea "charm.land/bubbletea/v2"
+	"github.com/alexzajac/the-dredger/internal/config"
 	"github.com/alexzajac/the-dredger/internal/db"
+	"github.com/alexzajac/the-dredger/internal/dredge"
 	"github.com/alexzajac/the-dredger/internal/ingest"
+	"github.com/alexzajac/the-dredger/internal/model"
 	"github.com/alexzajac/the-dredger/internal/ui"
 )
 
 func main() {
-	home, err := os.UserHomeDir()
+	flags := flag.NewFlagSet("dredger", flag.ExitOnError)
+	dbFlag := flags.String("db", "", "path to the SQLite database (overrides --profile and DREDGER_DB)")
+	profileFlag := flags.String("profile", "", "named profile with its own database (overrides DREDGER_DB and DREDGER_PROFILE)")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "Usage: dredger [--db <path> | --profile <name>] [import <file> | dredge [--retry-capsized | --recrunch] | check [--all] | archive [--all] | stats | clean | dedupe [--dry-run] | reset | config | prompt init | prompt test <url>]")
+		flags.PrintDefaults()
+	}
+	_ = flags.Parse(os.Args[1:])
+	args := flags.Args()
+
+	cfg, err := config.Load()
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error finding home directory: %v\n", err)
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
 		os.Exit(1)
 	}
 
-	dbPath := filepath.Join(home, ".dredger", "dredger.db")
+	dbPath, err := config.DBPath(*dbFlag, *profileFlag)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error resolving database path: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(args) >= 1 && args[0] == "config" {
+		runConfig(cfg, dbPath)
+		return
+	}
 
 	database, err := db.Open(dbPath)
 	if err != nil {
@@ -33,28 +62,43 @@ func main() {
 		os.Exit(1)
 	}
 
-	if len(os.Args) >= 2 {
-		switch os.Args[1] {
+	if len(args) >= 1 {
+		switch args[0] {
 		case "import":
-			if len(os.Args) < 3 {
+			if len(args) < 2 {
 				fmt.Fprintln(os.Stderr, "Usage: dredger import <file>")
 				os.Exit(1)
 			}
-			runImport(database, os.Args[2])
+			runImport(database, args[1])
+			return
+		case "dredge":
+			runDredge(database, cfg, args[1:])
+			return
+		case "check":
+			runCheck(database, cfg, args[1:])
+			return
+		case "archive":
+			runArchive(database, cfg, args[1:])
 			return
 		case "stats":
 			runStats(database)
 			return
 		case "clean":
-			runClean(database)
+			runClean(database, cfg)
+			return
+		case "dedupe":
+			runDedupe(database, len(args) >= 2 && args[1] == "--dry-run")
 			return
 		case "reset":
 			runReset(database)
 			return
+		case "prompt":
+			runPrompt(database, cfg, args[1:])
+			return
 		}
 	}
 
-	app := ui.NewApp(database)
+	app := ui.NewApp(database, cfg)
 	p := tea.NewProgram(app)
 	if _, err := p.Run(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error running program: %v\n", err)
@@ -62,23 +106,363 @@ func main() {
 	}
 }
 
+func runConfig(cfg config.Config, dbPath string) {
+	fmt.Printf("Config file: %s\n", cfg.Path)
+	fmt.Printf("Database:    %s\n\n", dbPath)
+
+	settings := cfg.Settings()
+	keyW, valW := 0, 0
+	for _, s := range settings {
+		keyW = max(keyW, len(s.Key))
+		valW = max(valW, len(s.Value))
+	}
+	for _, s := range settings {
+		fmt.Printf("%-*s = %-*s  (%s)\n", keyW, s.Key, valW, s.Value, s.Source)
+	}
+}
+
+func runPrompt(database *sql.DB, cfg config.Config, args []string) {
+	switch {
+	case len(args) == 1 && args[0] == "init":
+		written, err := dredge.WriteBuiltinPrompts(cfg.LLM.PromptsDir)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing prompts: %v\n", err)
+			os.Exit(1)
+		}
+		for _, path := range written {
+			fmt.Println("Wrote", path)
+		}
+		fmt.Printf("%d templates written to %s (existing files left untouched).\n", len(written), cfg.LLM.PromptsDir)
+	case len(args) == 2 && args[0] == "test":
+		svc, err := dredge.NewService(database, cfg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading prompts: %v\n", err)
+			os.Exit(1)
+		}
+		preview, err := svc.PromptTest(context.Background(), args[1])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Kind:     %s\nTemplate: %s\n\n", preview.Kind, preview.Template)
+		fmt.Println("----- prompt -----")
+		fmt.Println(preview.Prompt)
+		fmt.Println("----- response -----")
+		if preview.LLMErr != nil {
+			fmt.Printf("(no summary: %v)\n", preview.LLMErr)
+			return
+		}
+		fmt.Printf("Summary: %s\n", preview.Summary.Text)
+		var tags []string
+		for _, t := range preview.Summary.Tags {
+			tags = append(tags, fmt.Sprintf("%s (%.2f)", t.Name, t.Confidence))
+		}
+		fmt.Printf("Tags:    %s\n", strings.Join(tags, ", "))
+		fmt.Printf("Applied: %s\n", strings.Join(preview.Accepted, ", "))
+		if len(preview.Suggested) > 0 {
+			var names []string
+			for _, t := range preview.Suggested {
+				names = append(names, t.Name)
+			}
+			fmt.Printf("Review:  %s\n", strings.Join(names, ", "))
+		}
+	default:
+		fmt.Fprintln(os.Stderr, "Usage: dredger prompt init | dredger prompt test <url>")
+		os.Exit(1)
+	}
+}
+
+func runDredge(database *sql.DB, cfg config.Config, args []string) {
+	flags := flag.NewFlagSet("dredge", flag.ExitOnError)
+	retryCapsized := flags.Bool("retry-capsized", false, "also retry links that failed")
+	recrunch := flags.Bool("recrunch", false, "re-run extraction and the LLM over cached pages, offline")
+	_ = flags.Parse(args)
+
+	var queued int
+	var err error
+	if *recrunch {
+		queued, err = db.EnqueueRecrunch(database)
+	} else {
+		queued, err = db.EnqueueUndredged(database)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error queueing links: %v\n", err)
+		os.Exit(1)
+	}
+	if *retryCapsized {
+		retried, err := db.EnqueueCapsized(database)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error queueing capsized links: %v\n", err)
+			os.Exit(1)
+		}
+		queued += retried
+	}
+	due, err := db.CountDueDredgeJobs(database)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error counting jobs: %v\n", err)
+		os.Exit(1)
+	}
+	if due == 0 {
+		fmt.Println("Nothing to dredge.")
+		return
+	}
+	fmt.Printf("Dredging %d links (%d newly queued). Ctrl-C stops; unfinished links stay queued.\n", due, queued)
+
+	svc, err := dredge.NewService(database, cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go svc.Run(ctx)
+
+	var done, failed int
+	for result := range svc.Results() {
+		if result.LinkID == 0 {
+			fmt.Fprintf(os.Stderr, "        queue error, retrying in %s: %v\n",
+				time.Until(result.RetryAt).Round(time.Second), result.Err)
+			continue
+		}
+		if !result.RetryAt.IsZero() {
+			fmt.Printf("        #%d %s error, retrying in %s: %v\n", result.LinkID, result.Class,
+				time.Until(result.RetryAt).Round(time.Second), result.Err)
+			continue
+		}
+		done++
+		if result.Err != nil {
+			failed++
+			fmt.Printf("[%d/%d] #%d capsized (%s): %v\n", done, due, result.LinkID, result.Class, result.Err)
+			if result.ArchiveURL != "" {
+				fmt.Printf("        archived copy: %s\n", result.ArchiveURL)
+			}
+			continue
+		}
+		fmt.Printf("[%d/%d] #%d %s\n", done, due, result.LinkID, result.Title)
+	}
+	fmt.Printf("Dredged %d links, %d capsized.\n", done-failed, failed)
+
+	// Redirects found while dredging can reveal that two saved links are
+	// the same page.
+	if groups, err := db.FindDuplicates(database); err == nil && len(groups) > 0 {
+		fmt.Printf("%d groups of links now point at the same page; run `dredger dedupe` to merge them.\n", len(groups))
+	}
+}
+
+func runCheck(database *sql.DB, cfg config.Config, args []string) {
+	flags := flag.NewFlagSet("check", flag.ExitOnError)
+	all := flags.Bool("all", false, "check every saved link, including ones checked within check.interval")
+	_ = flags.Parse(args)
+
+	checker := dredge.NewChecker(database, cfg)
+	var links []model.Link
+	var err error
+	if *all {
+		links, err = db.GetLinksByStatus(database, model.Saved)
+	} else {
+		links, err = checker.Due()
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error finding links to check: %v\n", err)
+		os.Exit(1)
+	}
+	if len(links) == 0 {
+		fmt.Println("No links due a check.")
+		return
+	}
+	fmt.Printf("Checking %d links. Ctrl-C stops; unchecked links are picked up next time.\n", len(links))
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var done, failing, dead int
+	for link := range checker.Run(ctx, links) {
+		done++
+		switch {
+		case link.Dead:
+			dead++
+			fmt.Printf("[%d/%d] #%d dead: %s (%s)\n", done, len(links), link.ID, link.URL, checkOutcome(link))
+			if link.ArchiveURL != "" {
+				fmt.Printf("        archived copy: %s\n", link.ArchiveURL)
+			}
+		case link.CheckFailures > 0:
+			failing++
+			fmt.Printf("[%d/%d] #%d failing %d× in a row: %s (%s)\n", done, len(links), link.ID, link.CheckFailures, link.URL, checkOutcome(link))
+		}
+	}
+	fmt.Printf("Checked %d links: %d ok, %d failing, %d dead.\n", done, done-failing-dead, failing, dead)
+	if dead > 0 {
+		fmt.Printf("Press %s in the TUI to review dead links.\n", cfg.Keys.List.DeadLinks)
+	}
+}
+
+// checkOutcome describes a link's last check: its status or its error.
+func checkOutcome(link model.Link) string {
+	if link.CheckError != "" {
+		return link.CheckError
+	}
+	return fmt.Sprintf("%d %s", link.CheckStatus, http.StatusText(link.CheckStatus))
+}
+
+func runArchive(database *sql.DB, cfg config.Config, args []string) {
+	flags := flag.NewFlagSet("archive", flag.ExitOnError)
+	all := flags.Bool("all", false, "archive every saved link again, replacing existing copies")
+	_ = flags.Parse(args)
+
+	archiver, err := dredge.NewArchiver(database, cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error setting up the archive: %v\n", err)
+		os.Exit(1)
+	}
+	var links []model.Link
+	if *all {
+		links, err = db.GetLinksByStatus(database, model.Saved)
+	} else {
+		links, err = db.GetUnarchivedLinks(database)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error finding links to archive: %v\n", err)
+		os.Exit(1)
+	}
+	if len(links) == 0 {
+		fmt.Println("Every saved link has an offline copy.")
+		return
+	}
+	fmt.Printf("Archiving %d links. Ctrl-C stops; the rest are picked up next time.\n", len(links))
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	var archived, failed int
+	for i, link := range links {
+		if ctx.Err() != nil {
+			break
+		}
+		if _, err := archiver.Archive(ctx, link); err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			failed++
+			fmt.Printf("[%d/%d] #%d failed: %s (%v)\n", i+1, len(links), link.ID, link.URL, err)
+			continue
+		}
+		archived++
+	}
+	fmt.Printf("Archived %d links, %d failed.\n", archived, failed)
+}
+
 func runStats(database *sql.DB) {
 	stats, err := db.CountLinksByStatus(database)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error getting stats: %v\n", err)
 		os.Exit(1)
 	}
-	fmt.Printf("Pending: %d\nSaved:   %d\nPruned:  %d\nTotal:   %d\n",
-		stats.Unprocessed, stats.Saved, stats.Pruned, stats.Total)
+	fmt.Printf("Pending: %d\nSaved:   %d (%d read, %d unread)\nPruned:  %d\nTotal:   %d\n",
+		stats.Unprocessed, stats.Saved, stats.SavedRead, stats.Saved-stats.SavedRead, stats.Pruned, stats.Total)
+
+	backlog, err := db.GetReadingBacklog(database)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error getting stats: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("\nLeft to read: %s\n", hoursMinutes(backlog.Pending.ReadingTime+backlog.Unread.ReadingTime))
+	printBacklogTotal("Pending", backlog.Pending)
+	printBacklogTotal("Unread", backlog.Unread)
+	for i, n := range backlog.Lengths {
+		var label string
+		switch {
+		case i == 0:
+			label = "Under " + hoursMinutes(db.BacklogLengths[0])
+		case i == len(db.BacklogLengths):
+			label = "Over " + hoursMinutes(db.BacklogLengths[i-1])
+		default:
+			label = hoursMinutes(db.BacklogLengths[i-1]) + " to " + hoursMinutes(db.BacklogLengths[i])
+		}
+		fmt.Printf("  %-15s %d links\n", label+":", n)
+	}
+}
+
+func printBacklogTotal(label string, total db.BacklogTotal) {
+	fmt.Printf("  %-15s %s in %d links", label+":", hoursMinutes(total.ReadingTime), total.Links)
+	if total.Unmeasured > 0 {
+		fmt.Printf(" (%d not yet fetched)", total.Unmeasured)
+	}
+	fmt.Println()
+}
+
+// hoursMinutes formats d like 12h 30m, 2h or 45m.
+func hoursMinutes(d time.Duration) string {
+	mins := int(d.Round(time.Minute) / time.Minute)
+	switch {
+	case mins < 60:
+		return fmt.Sprintf("%dm", mins)
+	case mins%60 == 0:
+		return fmt.Sprintf("%dh", mins/60)
+	}
+	return fmt.Sprintf("%dh %02dm", mins/60, mins%60)
 }
 
-func runClean(database *sql.DB) {
+func runClean(database *sql.DB, cfg config.Config) {
+	// Offline copies of pruned links go with them.
+	pruned, err := db.GetLinksByStatus(database, model.Pruned)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error cleaning pruned links: %v\n", err)
+		os.Exit(1)
+	}
+	for _, link := range pruned {
+		if link.ArchivePath != "" {
+			_ = os.RemoveAll(filepath.Dir(link.ArchivePath))
+		}
+	}
 	removed, err := db.DeletePrunedLinks(database)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error cleaning pruned links: %v\n", err)
 		os.Exit(1)
 	}
 	fmt.Printf("Removed %d pruned links.\n", removed)
+
+	evicted, err := db.PruneCachedPages(database, cfg.Dredge.CacheMaxAge, cfg.Dredge.CacheMaxBytes)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error trimming page cache: %v\n", err)
+		os.Exit(1)
+	}
+	if evicted > 0 {
+		fmt.Printf("Evicted %d cached pages.\n", evicted)
+	}
+}
+
+func runDedupe(database *sql.DB, dryRun bool) {
+	groups, err := db.FindDuplicates(database)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error finding duplicates: %v\n", err)
+		os.Exit(1)
+	}
+	if len(groups) == 0 {
+		fmt.Println("No duplicate links found.")
+		return
+	}
+
+	for _, g := range groups {
+		fmt.Printf("keep   %s\n", g.Keep.URL)
+		for _, d := range g.Duplicates {
+			if d.ResolvedURL != "" {
+				fmt.Printf("  drop %s → %s\n", d.URL, d.ResolvedURL)
+			} else {
+				fmt.Printf("  drop %s\n", d.URL)
+			}
+		}
+	}
+	if dryRun {
+		fmt.Printf("\n%d duplicate groups found (dry run, nothing removed).\n", len(groups))
+		return
+	}
+
+	removed, err := db.MergeDuplicates(database, groups)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error merging duplicates: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("\nRemoved %d duplicate links; their tags were merged into the kept link.\n", removed)
 }
 
 func runReset(database *sql.DB) {
@@ -89,11 +473,22 @@ func runReset(database *sql.DB) {
 		fmt.Println("Aborted.")
 		return
 	}
+	// Offline copies go with the links, as in clean.
+	links, err := db.GetLinks(database)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error resetting database: %v\n", err)
+		os.Exit(1)
+	}
 	removed, err := db.DeleteAllLinks(database)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error resetting database: %v\n", err)
 		os.Exit(1)
 	}
+	for _, link := range links {
+		if link.ArchivePath != "" {
+			_ = os.RemoveAll(filepath.Dir(link.ArchivePath))
+		}
+	}
 	fmt.Printf("Deleted %d links. Database is now empty.\n", removed)
 }
 
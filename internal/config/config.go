@@ -0,0 +1,702 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+const (
+	configFileName = "config.toml"
+	envPrefix      = "DREDGER_"
+
+	// SourceDefault marks a setting that was not overridden anywhere.
+	SourceDefault = "default"
+)
+
+// Config holds every user-tunable setting. Values come from built-in
+// defaults, then the config file, then DREDGER_* environment variables.
+type Config struct {
+	LLM     LLMConfig     `toml:"llm"`
+	Dredge  DredgeConfig  `toml:"dredge"`
+	Tags    TagsConfig    `toml:"tags"`
+	GitHub  GitHubConfig  `toml:"github"`
+	Check   CheckConfig   `toml:"check"`
+	Wayback WaybackConfig `toml:"wayback"`
+	Archive ArchiveConfig `toml:"archive"`
+	Open    OpenConfig    `toml:"open"`
+	Keys    KeyMap        `toml:"keys"`
+
+	// Path is the config file that was consulted (it may not exist).
+	Path string `toml:"-"`
+	// Sources maps each dotted setting name to where its value came from:
+	// "default", the config file path, or an environment variable name.
+	Sources map[string]string `toml:"-"`
+}
+
+// LLM providers accepted in llm.provider.
+const (
+	ProviderOllama = "ollama"
+	ProviderOpenAI = "openai"
+	ProviderNone   = "none"
+)
+
+// LLMConfig configures the summarisation backend. Endpoint and Model
+// default per provider when left empty.
+type LLMConfig struct {
+	Provider string        `toml:"provider"`
+	Endpoint string        `toml:"endpoint"`
+	Model    string        `toml:"model"`
+	APIKey   string        `toml:"api_key"`
+	Timeout  time.Duration `toml:"timeout"`
+	// PromptsDir holds user prompt templates; it defaults to a prompts
+	// directory next to the config file.
+	PromptsDir string `toml:"prompts_dir"`
+	// MaxContentTokens caps the page text excerpt sent with each prompt.
+	// Zero sends no page text.
+	MaxContentTokens int `toml:"max_content_tokens"`
+}
+
+// DredgeConfig configures the crawler.
+type DredgeConfig struct {
+	Workers  int           `toml:"workers"`
+	Timeout  time.Duration `toml:"timeout"`
+	DelayMin time.Duration `toml:"delay_min"`
+	DelayMax time.Duration `toml:"delay_max"`
+	// MaxAttempts is how many times a link with transient failures is
+	// tried before it is left capsized.
+	MaxAttempts int `toml:"max_attempts"`
+	// RetryBase is the first retry delay; it doubles with each attempt.
+	RetryBase time.Duration `toml:"retry_base"`
+	// UserAgent is sent with every crawl request and matched against
+	// robots.txt groups.
+	UserAgent string `toml:"user_agent"`
+	// HostRate is the sustained requests per second allowed to any one
+	// host, with bursts of up to HostConcurrency requests.
+	HostRate float64 `toml:"host_rate"`
+	// HostConcurrency caps in-flight requests to any one host.
+	HostConcurrency int `toml:"host_concurrency"`
+	// Robots makes the crawler fetch robots.txt, skip disallowed pages and
+	// slow down to the Crawl-delay it asks for.
+	Robots bool `toml:"robots"`
+	// CacheMaxAge and CacheMaxBytes bound the page cache: pages not
+	// fetched for longer than CacheMaxAge are evicted, then the oldest
+	// pages until the rest fit in CacheMaxBytes. Zero means no limit.
+	CacheMaxAge   time.Duration `toml:"cache_max_age"`
+	CacheMaxBytes int64         `toml:"cache_max_bytes"`
+}
+
+// GitHubConfig configures the GitHub API used for repository links.
+type GitHubConfig struct {
+	// Token is optional; it raises the API rate limit from 60 to 5000
+	// requests an hour.
+	Token   string `toml:"token"`
+	APIBase string `toml:"api_base"`
+}
+
+// CheckConfig configures the dead-link checker.
+type CheckConfig struct {
+	// Interval is how long a link's last check stays good; `dredger check`
+	// skips links checked more recently.
+	Interval time.Duration `toml:"interval"`
+	// MaxFailures is how many failed checks in a row mark a link dead.
+	// A 404 or 410 marks it dead straight away.
+	MaxFailures int `toml:"max_failures"`
+}
+
+// WaybackConfig configures the Internet Archive's Wayback Machine, used
+// to find archived copies of links that have disappeared.
+type WaybackConfig struct {
+	// Enabled looks up the closest snapshot when a dredge capsizes with a
+	// missing page or unknown host, or the checker finds a link dead.
+	Enabled bool `toml:"enabled"`
+	// Endpoint is the availability API; SaveEndpoint is the Save Page Now
+	// prefix the page URL is appended to.
+	Endpoint     string `toml:"endpoint"`
+	SaveEndpoint string `toml:"save_endpoint"`
+	// SaveOnKeep requests a fresh snapshot of every link kept in focus
+	// mode.
+	SaveOnKeep bool `toml:"save_on_keep"`
+}
+
+// ArchiveConfig configures offline copies of saved pages.
+type ArchiveConfig struct {
+	// Enabled archives each saved link when it is kept or dredged.
+	// `dredger archive` works either way.
+	Enabled bool `toml:"enabled"`
+	// Dir holds one directory of copies per link; it defaults to an
+	// archive directory next to the database.
+	Dir string `toml:"dir"`
+	// MaxBytes caps a whole copy, inlined stylesheets and images
+	// included; MaxAssetBytes caps any one of them. Assets over the limits
+	// are left as links to the live site.
+	MaxBytes      int64 `toml:"max_bytes"`
+	MaxAssetBytes int64 `toml:"max_asset_bytes"`
+}
+
+// OpenConfig sets how links are opened in a browser.
+type OpenConfig struct {
+	// Command runs instead of the platform's opener (open, xdg-open).
+	// "{url}" in it is replaced with the link; without one the link is
+	// appended. Arguments may be quoted, e.g. `firefox --new-tab {url}`.
+	Command string `toml:"command"`
+	// Terminal hands the terminal to Command until it exits, for browsers
+	// such as lynx or w3m that run in it.
+	Terminal bool `toml:"terminal"`
+}
+
+// TagsConfig controls how LLM tags are reconciled with the existing
+// vocabulary.
+type TagsConfig struct {
+	// VocabularySize is how many of the most used tags the prompt offers
+	// the model to reuse.
+	VocabularySize int `toml:"vocabulary_size"`
+	// NewTagThreshold is the confidence a tag outside the vocabulary needs
+	// to be applied straight away.
+	NewTagThreshold float64 `toml:"new_tag_threshold"`
+	// Review queues below-threshold tags for the tag-review screen instead
+	// of discarding them.
+	Review bool `toml:"review"`
+}
+
+// KeyMap holds the TUI keybindings, grouped by mode. Arrow keys always
+// work alongside the configured letters.
+type KeyMap struct {
+	List      ListKeys      `toml:"list"`
+	Focus     FocusKeys     `toml:"focus"`
+	Grid      GridKeys      `toml:"grid"`
+	TagReview TagReviewKeys `toml:"tag_review"`
+	DeadLinks DeadLinksKeys `toml:"dead_links"`
+	Reader    ReaderKeys    `toml:"reader"`
+}
+
+type ListKeys struct {
+	Quit       string `toml:"quit"`
+	Focus      string `toml:"focus"`
+	SwitchView string `toml:"switch_view"`
+	Grid       string `toml:"grid"`
+	Dredge     string `toml:"dredge"`
+	Filter     string `toml:"filter"`
+	TagReview  string `toml:"tag_review"`
+	DeadLinks  string `toml:"dead_links"`
+	Unread     string `toml:"unread"`
+}
+
+type FocusKeys struct {
+	Prune      string `toml:"prune"`
+	Keep       string `toml:"keep"`
+	Tag        string `toml:"tag"`
+	Read       string `toml:"read"`
+	Dredge     string `toml:"dredge"`
+	Undo       string `toml:"undo"`
+	Next       string `toml:"next"`
+	Prev       string `toml:"prev"`
+	ScrollDown string `toml:"scroll_down"`
+	ScrollUp   string `toml:"scroll_up"`
+	Reader     string `toml:"reader"`
+	ToggleRead string `toml:"toggle_read"`
+}
+
+type GridKeys struct {
+	Left        string `toml:"left"`
+	Right       string `toml:"right"`
+	Up          string `toml:"up"`
+	Down        string `toml:"down"`
+	Open        string `toml:"open"`
+	Copy        string `toml:"copy"`
+	Search      string `toml:"search"`
+	Serendipity string `toml:"serendipity"`
+	Reader      string `toml:"reader"`
+	ToggleRead  string `toml:"toggle_read"`
+}
+
+type TagReviewKeys struct {
+	Approve string `toml:"approve"`
+	Reject  string `toml:"reject"`
+}
+
+type DeadLinksKeys struct {
+	Prune   string `toml:"prune"`
+	Replace string `toml:"replace"`
+	Archive string `toml:"archive"`
+	Recheck string `toml:"recheck"`
+}
+
+type ReaderKeys struct {
+	ScrollDown string `toml:"scroll_down"`
+	ScrollUp   string `toml:"scroll_up"`
+	PageDown   string `toml:"page_down"`
+	PageUp     string `toml:"page_up"`
+	Top        string `toml:"top"`
+	Bottom     string `toml:"bottom"`
+}
+
+// Default returns the built-in configuration.
+func Default() Config {
+	return Config{
+		LLM: LLMConfig{
+			Provider:         ProviderOllama,
+			Timeout:          60 * time.Second,
+			MaxContentTokens: 1500,
+		},
+		Dredge: DredgeConfig{
+			Workers:         4,
+			Timeout:         10 * time.Second,
+			DelayMin:        200 * time.Millisecond,
+			DelayMax:        800 * time.Millisecond,
+			MaxAttempts:     5,
+			RetryBase:       30 * time.Second,
+			UserAgent:       "TheDredger/1.0 (+https://github.com/alexzajac/the-dredger)",
+			HostRate:        1,
+			HostConcurrency: 2,
+			CacheMaxAge:     90 * 24 * time.Hour,
+			CacheMaxBytes:   512 << 20,
+		},
+		Tags: TagsConfig{
+			VocabularySize:  50,
+			NewTagThreshold: 0.8,
+			Review:          true,
+		},
+		GitHub: GitHubConfig{
+			APIBase: "https://api.github.com",
+		},
+		Check: CheckConfig{
+			Interval:    7 * 24 * time.Hour,
+			MaxFailures: 3,
+		},
+		Wayback: WaybackConfig{
+			Enabled:      true,
+			Endpoint:     "https://archive.org/wayback/available",
+			SaveEndpoint: "https://web.archive.org/save/",
+		},
+		Archive: ArchiveConfig{
+			MaxBytes:      20 << 20,
+			MaxAssetBytes: 2 << 20,
+		},
+		Keys: KeyMap{
+			List: ListKeys{
+				Quit:       "q",
+				Focus:      "f",
+				SwitchView: "b",
+				Grid:       "g",
+				Dredge:     "r",
+				Filter:     "/",
+				TagReview:  "T",
+				DeadLinks:  "D",
+				Unread:     "u",
+			},
+			Focus: FocusKeys{
+				Prune:      "h",
+				Keep:       "l",
+				Tag:        "t",
+				Read:       "r",
+				Dredge:     "d",
+				Undo:       "z",
+				Next:       "n",
+				Prev:       "p",
+				ScrollDown: "j",
+				ScrollUp:   "k",
+				Reader:     "v",
+				ToggleRead: "m",
+			},
+			Grid: GridKeys{
+				Left:        "h",
+				Right:       "l",
+				Up:          "k",
+				Down:        "j",
+				Open:        "enter",
+				Copy:        "y",
+				Search:      "/",
+				Serendipity: "r",
+				Reader:      "v",
+				ToggleRead:  "m",
+			},
+			TagReview: TagReviewKeys{
+				Approve: "a",
+				Reject:  "x",
+			},
+			DeadLinks: DeadLinksKeys{
+				Prune:   "x",
+				Replace: "e",
+				Archive: "a",
+				Recheck: "c",
+			},
+			Reader: ReaderKeys{
+				ScrollDown: "j",
+				ScrollUp:   "k",
+				PageDown:   "space",
+				PageUp:     "b",
+				Top:        "g",
+				Bottom:     "G",
+			},
+		},
+	}
+}
+
+// ConfigDir returns the directory holding config.toml. Like DataDir, an
+// existing ~/.dredger wins; otherwise $XDG_CONFIG_HOME/dredger is used.
+func ConfigDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("find home directory: %w", err)
+	}
+	legacy := filepath.Join(home, "."+appName)
+	if info, err := os.Stat(legacy); err == nil && info.IsDir() {
+		return legacy, nil
+	}
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); filepath.IsAbs(xdg) {
+		return filepath.Join(xdg, appName), nil
+	}
+	return legacy, nil
+}
+
+// FilePath returns the config file location, honouring DREDGER_CONFIG.
+func FilePath() (string, error) {
+	if env := os.Getenv("DREDGER_CONFIG"); env != "" {
+		return env, nil
+	}
+	dir, err := ConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, configFileName), nil
+}
+
+// Load reads the config file from FilePath and applies env overrides.
+func Load() (Config, error) {
+	path, err := FilePath()
+	if err != nil {
+		return Config{}, err
+	}
+	return LoadFile(path)
+}
+
+// LoadFile reads the config file at path (a missing file is not an error)
+// and applies DREDGER_* environment overrides on top.
+func LoadFile(path string) (Config, error) {
+	cfg := Default()
+	cfg.Path = path
+	cfg.Sources = make(map[string]string)
+
+	var meta toml.MetaData
+	data, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return Config{}, fmt.Errorf("read config: %w", err)
+	default:
+		meta, err = toml.Decode(string(data), &cfg)
+		if err != nil {
+			return Config{}, fmt.Errorf("parse config %s: %w", path, err)
+		}
+		if undecoded := meta.Undecoded(); len(undecoded) > 0 {
+			return Config{}, fmt.Errorf("parse config %s: unknown setting %q", path, undecoded[0].String())
+		}
+	}
+
+	var envErr error
+	walkSettings(reflect.ValueOf(&cfg).Elem(), nil, func(key []string, field reflect.Value) {
+		name := strings.Join(key, ".")
+		cfg.Sources[name] = SourceDefault
+		if meta.IsDefined(key...) {
+			cfg.Sources[name] = path
+		}
+		envName := EnvName(name)
+		if raw, ok := os.LookupEnv(envName); ok && raw != "" {
+			if err := setFromString(field, raw); err != nil && envErr == nil {
+				envErr = fmt.Errorf("%s: %w", envName, err)
+				return
+			}
+			cfg.Sources[name] = envName
+		}
+	})
+	if envErr != nil {
+		return Config{}, envErr
+	}
+	cfg.applyProviderDefaults()
+	if cfg.LLM.PromptsDir == "" {
+		cfg.LLM.PromptsDir = filepath.Join(filepath.Dir(path), "prompts")
+	}
+
+	if err := cfg.validate(); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+// applyProviderDefaults fills in the endpoint and model for the chosen
+// LLM provider when neither the file nor the environment set them.
+func (c *Config) applyProviderDefaults() {
+	switch c.LLM.Provider {
+	case ProviderOllama:
+		if c.LLM.Endpoint == "" {
+			c.LLM.Endpoint = "http://localhost:11434"
+		}
+		if c.LLM.Model == "" {
+			c.LLM.Model = "gemma3:4b"
+		}
+	case ProviderOpenAI:
+		if c.LLM.Endpoint == "" {
+			c.LLM.Endpoint = "http://localhost:8080"
+		}
+	}
+}
+
+func (c Config) validate() error {
+	switch c.LLM.Provider {
+	case ProviderOllama, ProviderOpenAI, ProviderNone:
+	default:
+		return fmt.Errorf("llm.provider must be %q, %q or %q, got %q", ProviderOllama, ProviderOpenAI, ProviderNone, c.LLM.Provider)
+	}
+	if c.LLM.MaxContentTokens < 0 {
+		return fmt.Errorf("llm.max_content_tokens must not be negative, got %d", c.LLM.MaxContentTokens)
+	}
+	if c.Dredge.Workers < 1 {
+		return fmt.Errorf("dredge.workers must be at least 1, got %d", c.Dredge.Workers)
+	}
+	if c.Dredge.DelayMin < 0 || c.Dredge.DelayMax < c.Dredge.DelayMin {
+		return fmt.Errorf("dredge.delay_min (%s) must be between 0 and dredge.delay_max (%s)", c.Dredge.DelayMin, c.Dredge.DelayMax)
+	}
+	if c.Dredge.MaxAttempts < 1 {
+		return fmt.Errorf("dredge.max_attempts must be at least 1, got %d", c.Dredge.MaxAttempts)
+	}
+	if c.Dredge.RetryBase <= 0 {
+		return fmt.Errorf("dredge.retry_base must be positive, got %s", c.Dredge.RetryBase)
+	}
+	if c.Dredge.HostRate <= 0 {
+		return fmt.Errorf("dredge.host_rate must be positive, got %g", c.Dredge.HostRate)
+	}
+	if c.Dredge.HostConcurrency < 1 {
+		return fmt.Errorf("dredge.host_concurrency must be at least 1, got %d", c.Dredge.HostConcurrency)
+	}
+	if c.Dredge.CacheMaxAge < 0 || c.Dredge.CacheMaxBytes < 0 {
+		return fmt.Errorf("dredge.cache_max_age and dredge.cache_max_bytes must not be negative, got %s and %d",
+			c.Dredge.CacheMaxAge, c.Dredge.CacheMaxBytes)
+	}
+	if strings.TrimSpace(c.Dredge.UserAgent) == "" {
+		return errors.New("dredge.user_agent must not be empty")
+	}
+	if c.Tags.NewTagThreshold < 0 || c.Tags.NewTagThreshold > 1 {
+		return fmt.Errorf("tags.new_tag_threshold must be between 0 and 1, got %g", c.Tags.NewTagThreshold)
+	}
+	if c.Check.Interval < 0 {
+		return fmt.Errorf("check.interval must not be negative, got %s", c.Check.Interval)
+	}
+	if c.Check.MaxFailures < 1 {
+		return fmt.Errorf("check.max_failures must be at least 1, got %d", c.Check.MaxFailures)
+	}
+	if c.Open.Terminal && strings.TrimSpace(c.Open.Command) == "" {
+		return errors.New("open.terminal needs open.command")
+	}
+	if c.Wayback.Enabled && (c.Wayback.Endpoint == "" || c.Wayback.SaveEndpoint == "") {
+		return errors.New("wayback.endpoint and wayback.save_endpoint must be set while wayback is enabled")
+	}
+	if c.Archive.MaxBytes <= 0 || c.Archive.MaxAssetBytes < 0 {
+		return fmt.Errorf("archive.max_bytes must be positive and archive.max_asset_bytes not negative, got %d and %d",
+			c.Archive.MaxBytes, c.Archive.MaxAssetBytes)
+	}
+	if c.Dredge.Timeout <= 0 || c.LLM.Timeout <= 0 {
+		return errors.New("timeouts must be positive")
+	}
+	return c.Keys.validate()
+}
+
+// builtinKeys are the keys each mode handles itself whatever the key map
+// says, with what they do there. A key that already does the same as a
+// setting, such as "down" for keys.reader.scroll_down, may be bound to it.
+var builtinKeys = map[string]map[string]string{
+	"focus": {
+		"down": "keys.focus.next",
+		"up":   "keys.focus.prev",
+		"esc":  "the built-in back key",
+	},
+	"grid": {
+		"left":  "keys.grid.left",
+		"right": "keys.grid.right",
+		"up":    "keys.grid.up",
+		"down":  "keys.grid.down",
+		"esc":   "the built-in back key",
+	},
+	"tag_review": {
+		"j": "the built-in down key", "down": "the built-in down key",
+		"k": "the built-in up key", "up": "the built-in up key",
+		"esc": "the built-in back key",
+	},
+	"dead_links": {
+		"j": "the built-in down key", "down": "the built-in down key",
+		"k": "the built-in up key", "up": "the built-in up key",
+		"esc": "the built-in back key",
+	},
+	"reader": {
+		"down":   "keys.reader.scroll_down",
+		"up":     "keys.reader.scroll_up",
+		"pgdown": "keys.reader.page_down",
+		"pgup":   "keys.reader.page_up",
+		"home":   "keys.reader.top",
+		"end":    "keys.reader.bottom",
+		"esc":    "the built-in back key",
+	},
+}
+
+// validate rejects a key bound to two actions of the same mode, or to an
+// action and a key the mode handles itself, where one would silently
+// shadow the other. The quit key, and ctrl+c, quit from every mode.
+func (k KeyMap) validate() error {
+	v := reflect.ValueOf(k)
+	t := v.Type()
+	for i := range t.NumField() {
+		section := t.Field(i).Tag.Get("toml")
+		bound := map[string]string{"ctrl+c": "keys.list.quit"}
+		maps.Copy(bound, builtinKeys[section])
+		if section != "list" {
+			if other, ok := bound[k.List.Quit]; ok && other != "keys.list.quit" {
+				return fmt.Errorf("keys.list.quit and %s are both bound to %q in keys.%s", other, k.List.Quit, section)
+			}
+			bound[k.List.Quit] = "keys.list.quit"
+		}
+		var err error
+		walkSettings(v.Field(i), []string{"keys", section}, func(key []string, field reflect.Value) {
+			name, value := strings.Join(key, "."), field.String()
+			if value == "" || err != nil {
+				return
+			}
+			if other, ok := bound[value]; ok && other != name {
+				err = fmt.Errorf("%s and %s are both bound to %q", other, name, value)
+				return
+			}
+			bound[value] = name
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EnvName returns the environment variable that overrides a dotted
+// setting, e.g. "llm.model" becomes DREDGER_LLM_MODEL.
+func EnvName(key string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// Setting is one resolved configuration value, as shown by `dredger config`.
+type Setting struct {
+	Key    string
+	Value  string
+	Source string
+}
+
+// Settings lists every setting in declaration order with its source.
+func (c Config) Settings() []Setting {
+	var out []Setting
+	walkSettings(reflect.ValueOf(&c).Elem(), nil, func(key []string, field reflect.Value) {
+		name := strings.Join(key, ".")
+		source := c.Sources[name]
+		if source == "" {
+			source = SourceDefault
+		}
+		value := formatValue(field)
+		if isSecret(name) && field.String() != "" {
+			value = `"********"`
+		}
+		out = append(out, Setting{Key: name, Value: value, Source: source})
+	})
+	return out
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// isSecret reports whether a setting holds a credential that should be
+// masked when printed.
+func isSecret(key string) bool {
+	return strings.HasSuffix(key, "api_key") || strings.HasSuffix(key, "token")
+}
+
+// walkSettings calls fn for every leaf field with a toml tag, passing the
+// dotted key path. Nested structs become sections.
+func walkSettings(v reflect.Value, prefix []string, fn func(key []string, field reflect.Value)) {
+	t := v.Type()
+	for i := range t.NumField() {
+		tag := t.Field(i).Tag.Get("toml")
+		if tag == "" || tag == "-" {
+			continue
+		}
+		key := append(append([]string(nil), prefix...), tag)
+		field := v.Field(i)
+		if field.Kind() == reflect.Struct && field.Type() != durationType {
+			walkSettings(field, key, fn)
+			continue
+		}
+		fn(key, field)
+	}
+}
+
+func setFromString(field reflect.Value, raw string) error {
+	if field.Type() == durationType {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		field.SetInt(int64(d))
+		return nil
+	}
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(raw)
+	case reflect.Int, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Slice:
+		var items []string
+		for _, s := range strings.Split(raw, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				items = append(items, s)
+			}
+		}
+		field.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported setting type %s", field.Type())
+	}
+	return nil
+}
+
+func formatValue(field reflect.Value) string {
+	if field.Type() == durationType {
+		return time.Duration(field.Int()).String()
+	}
+	if field.Kind() == reflect.Slice {
+		parts := make([]string, field.Len())
+		for i := range parts {
+			parts[i] = fmt.Sprint(field.Index(i).Interface())
+		}
+		return strings.Join(parts, ",")
+	}
+	if field.Kind() == reflect.String {
+		return strconv.Quote(field.String())
+	}
+	return fmt.Sprint(field.Interface())
+}
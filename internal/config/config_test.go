@@ -0,0 +1,152 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	setupHome(t)
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadFileMissing(t *testing.T) {
+	setupHome(t)
+	cfg, err := LoadFile(filepath.Join(t.TempDir(), "nope.toml"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.Dredge.Workers != 4 {
+		t.Errorf("Workers = %d, want 4", cfg.Dredge.Workers)
+	}
+	if cfg.Sources["llm.model"] != SourceDefault {
+		t.Errorf("Sources[llm.model] = %q, want %q", cfg.Sources["llm.model"], SourceDefault)
+	}
+}
+
+func TestLoadFileAndEnvOverride(t *testing.T) {
+	path := writeConfig(t, `
+[llm]
+model = "llama3.2"
+timeout = "90s"
+
+[dredge]
+workers = 8
+
+[keys.focus]
+prune = "x"
+`)
+	t.Setenv("DREDGER_DREDGE_WORKERS", "2")
+
+	cfg, err := LoadFile(path)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.LLM.Model != "llama3.2" {
+		t.Errorf("Model = %q, want llama3.2", cfg.LLM.Model)
+	}
+	if cfg.LLM.Timeout != 90*time.Second {
+		t.Errorf("Timeout = %v, want 90s", cfg.LLM.Timeout)
+	}
+	if cfg.Keys.Focus.Prune != "x" {
+		t.Errorf("Prune key = %q, want x", cfg.Keys.Focus.Prune)
+	}
+	if cfg.Dredge.Workers != 2 {
+		t.Errorf("Workers = %d, want 2 (env override)", cfg.Dredge.Workers)
+	}
+
+	want := map[string]string{
+		"llm.model":        path,
+		"llm.endpoint":     SourceDefault,
+		"dredge.workers":   "DREDGER_DREDGE_WORKERS",
+		"keys.focus.prune": path,
+	}
+	for key, source := range want {
+		if cfg.Sources[key] != source {
+			t.Errorf("Sources[%s] = %q, want %q", key, cfg.Sources[key], source)
+		}
+	}
+}
+
+func TestLoadFileErrors(t *testing.T) {
+	cases := map[string]string{
+		"unknown key":  "[llm]\nmodle = \"x\"\n",
+		"zero workers": "[dredge]\nworkers = 0\n",
+		"bad delays":   "[dredge]\ndelay_min = \"2s\"\ndelay_max = \"1s\"\n",
+		"no attempts":  "[dredge]\nmax_attempts = 0\n",
+		"no failures":  "[check]\nmax_failures = 0\n",
+		"no command":   "[open]\nterminal = true\n",
+		"shared key":   "[keys.dead_links]\narchive = \"x\"\n",
+		"quit key":     "[keys.grid]\ncopy = \"q\"\n",
+		"ctrl+c":       "[keys.focus]\nkeep = \"ctrl+c\"\n",
+		"built-in key": "[keys.reader]\ntop = \"esc\"\n",
+		"moving key":   "[keys.tag_review]\napprove = \"j\"\n",
+		"arrow key":    "[keys.reader]\nscroll_down = \"up\"\n",
+		"quit on esc":  "[keys.list]\nquit = \"esc\"\n",
+		"invalid toml": "[llm\n",
+	}
+	for name, content := range cases {
+		if _, err := LoadFile(writeConfig(t, content)); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+
+	// The same key may mean different things in different modes.
+	if _, err := LoadFile(writeConfig(t, "[keys.grid]\ncopy = \"x\"\n")); err != nil {
+		t.Errorf("key shared across modes: %v", err)
+	}
+	// A key may be bound to what the mode already does with it.
+	if _, err := LoadFile(writeConfig(t, "[keys.reader]\ntop = \"home\"\n[keys.grid]\nleft = \"left\"\n")); err != nil {
+		t.Errorf("built-in key bound to its own action: %v", err)
+	}
+
+	t.Setenv("DREDGER_DREDGE_TIMEOUT", "soon")
+	if _, err := LoadFile(filepath.Join(t.TempDir(), "none.toml")); err == nil {
+		t.Error("expected error for unparsable env duration")
+	}
+}
+
+func TestProviderDefaults(t *testing.T) {
+	cfg, err := LoadFile(writeConfig(t, "[llm]\nprovider = \"openai\"\n"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.LLM.Endpoint != "http://localhost:8080" {
+		t.Errorf("Endpoint = %q, want llama.cpp default", cfg.LLM.Endpoint)
+	}
+	if cfg.LLM.Model != "" {
+		t.Errorf("Model = %q, want empty", cfg.LLM.Model)
+	}
+
+	cfg, err = LoadFile(filepath.Join(t.TempDir(), "none.toml"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if cfg.LLM.Endpoint != "http://localhost:11434" || cfg.LLM.Model != "gemma3:4b" {
+		t.Errorf("ollama defaults = %q %q", cfg.LLM.Endpoint, cfg.LLM.Model)
+	}
+}
+
+func TestSettings(t *testing.T) {
+	setupHome(t)
+	cfg, err := LoadFile(filepath.Join(t.TempDir(), "none.toml"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	settings := cfg.Settings()
+	if len(settings) == 0 || settings[0].Key != "llm.provider" {
+		t.Fatalf("unexpected first setting: %+v", settings)
+	}
+	for _, s := range settings {
+		if s.Key == "dredge.timeout" && s.Value != "10s" {
+			t.Errorf("dredge.timeout = %q, want 10s", s.Value)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+const (
+	appName        = "dredger"
+	dbFileName     = "dredger.db"
+	defaultProfile = "default"
+)
+
+var profileNameRe = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// DataDir returns the directory holding dredger's databases. An existing
+// ~/.dredger wins so current installs keep working; otherwise
+// $XDG_DATA_HOME/dredger is used when set, falling back to ~/.dredger.
+func DataDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("find home directory: %w", err)
+	}
+	legacy := filepath.Join(home, "."+appName)
+	if info, err := os.Stat(legacy); err == nil && info.IsDir() {
+		return legacy, nil
+	}
+	if xdg := os.Getenv("XDG_DATA_HOME"); filepath.IsAbs(xdg) {
+		return filepath.Join(xdg, appName), nil
+	}
+	return legacy, nil
+}
+
+// DBPath resolves the database location. Flags beat the environment:
+// precedence is the explicit path (--db), then the named profile
+// (--profile), then DREDGER_DB, then DREDGER_PROFILE, then the default
+// database in DataDir.
+func DBPath(explicit, profile string) (string, error) {
+	if explicit != "" {
+		return explicit, nil
+	}
+	if profile == "" {
+		if env := os.Getenv("DREDGER_DB"); env != "" {
+			return env, nil
+		}
+		profile = os.Getenv("DREDGER_PROFILE")
+	}
+
+	dir, err := DataDir()
+	if err != nil {
+		return "", err
+	}
+	if profile == "" || profile == defaultProfile {
+		return filepath.Join(dir, dbFileName), nil
+	}
+	if !profileNameRe.MatchString(profile) {
+		return "", fmt.Errorf("invalid profile name %q: use letters, digits, '-' or '_'", profile)
+	}
+	return filepath.Join(dir, "profiles", profile, dbFileName), nil
+}
@@ -0,0 +1,129 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// setupHome points the home directory at a temporary one and clears the
+// XDG and DREDGER_* variables, so that tests do not see the real setup.
+func setupHome(t *testing.T) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_DATA_HOME", "")
+	t.Setenv("XDG_CONFIG_HOME", "")
+	for _, kv := range os.Environ() {
+		if name, _, _ := strings.Cut(kv, "="); strings.HasPrefix(name, envPrefix) {
+			t.Setenv(name, "")
+		}
+	}
+	t.Setenv("DREDGER_DB", "")
+	t.Setenv("DREDGER_PROFILE", "")
+	return home
+}
+
+func TestDBPathDefault(t *testing.T) {
+	home := setupHome(t)
+
+	got, err := DBPath("", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := filepath.Join(home, ".dredger", "dredger.db")
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestDBPathPrecedence(t *testing.T) {
+	home := setupHome(t)
+	t.Setenv("DREDGER_DB", "/tmp/env.db")
+	t.Setenv("DREDGER_PROFILE", "personal")
+
+	got, err := DBPath("/tmp/flag.db", "work")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "/tmp/flag.db" {
+		t.Errorf("flag: got %q, want %q", got, "/tmp/flag.db")
+	}
+
+	// The --profile flag beats the environment.
+	got, err = DBPath("", "work")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := filepath.Join(home, ".dredger", "profiles", "work", "dredger.db"); got != want {
+		t.Errorf("profile flag: got %q, want %q", got, want)
+	}
+
+	got, err = DBPath("", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "/tmp/env.db" {
+		t.Errorf("env: got %q, want %q", got, "/tmp/env.db")
+	}
+}
+
+func TestDBPathProfile(t *testing.T) {
+	home := setupHome(t)
+
+	got, err := DBPath("", "work")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := filepath.Join(home, ".dredger", "profiles", "work", "dredger.db")
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	t.Setenv("DREDGER_PROFILE", "personal")
+	got, err = DBPath("", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want = filepath.Join(home, ".dredger", "profiles", "personal", "dredger.db")
+	if got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestDBPathInvalidProfile(t *testing.T) {
+	setupHome(t)
+
+	for _, name := range []string{"../escape", "a/b", "with space"} {
+		if _, err := DBPath("", name); err == nil {
+			t.Errorf("expected error for profile %q", name)
+		}
+	}
+}
+
+func TestDataDirXDG(t *testing.T) {
+	home := setupHome(t)
+	xdg := filepath.Join(home, "xdg-data")
+	t.Setenv("XDG_DATA_HOME", xdg)
+
+	got, err := DataDir()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := filepath.Join(xdg, "dredger"); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	// An existing legacy directory takes priority over XDG.
+	if err := os.MkdirAll(filepath.Join(home, ".dredger"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	got, err = DataDir()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := filepath.Join(home, ".dredger"); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
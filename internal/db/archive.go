@@ -0,0 +1,41 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/alexzajac/the-dredger/internal/model"
+)
+
+// UpdateLinkArchive records the local offline copy of a link.
+func UpdateLinkArchive(db *sql.DB, id int64, path string, archivedAt time.Time) error {
+	_, err := db.Exec(`UPDATE links SET archive_path=?, archived_at=? WHERE id=?`, path, formatOptionalTime(archivedAt.UTC()), id)
+	if err != nil {
+		return fmt.Errorf("update link archive: %w", err)
+	}
+	return nil
+}
+
+// GetUnarchivedLinks returns saved links without an offline copy, oldest
+// first.
+func GetUnarchivedLinks(db *sql.DB) ([]model.Link, error) {
+	rows, err := db.Query(
+		`SELECT `+linkSelectCols+` FROM links WHERE status = ? AND archive_path = '' ORDER BY date_added ASC`,
+		int(model.Saved),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query unarchived links: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var links []model.Link
+	for rows.Next() {
+		l, err := scanLink(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan link: %w", err)
+		}
+		links = append(links, l)
+	}
+	return links, rows.Err()
+}
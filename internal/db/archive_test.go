@@ -0,0 +1,45 @@
+package db
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alexzajac/the-dredger/internal/model"
+)
+
+func TestLinkArchive(t *testing.T) {
+	db := setupTestDB(t)
+	saved, _ := InsertLink(db, model.Link{URL: "https://example.com/a", Status: model.Saved})
+	_, _ = InsertLink(db, model.Link{URL: "https://example.com/b", Status: model.Saved})
+	_, _ = InsertLink(db, model.Link{URL: "https://example.com/pending"})
+
+	dbPath, err := FilePath(db)
+	if err != nil {
+		t.Fatalf("file path: %v", err)
+	}
+	if filepath.Base(dbPath) != "test.db" {
+		t.Errorf("FilePath = %q", dbPath)
+	}
+
+	path := filepath.Join(filepath.Dir(dbPath), "archive", "1", "index.html")
+	at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	if err := UpdateLinkArchive(db, saved, path, at); err != nil {
+		t.Fatalf("update archive: %v", err)
+	}
+
+	links, err := GetUnarchivedLinks(db)
+	if err != nil {
+		t.Fatalf("unarchived links: %v", err)
+	}
+	if len(links) != 1 || links[0].URL != "https://example.com/b" {
+		t.Errorf("expected only the other saved link, got %+v", links)
+	}
+
+	all, _ := GetLinksByStatus(db, model.Saved)
+	for _, l := range all {
+		if l.ID == saved && (l.ArchivePath != path || !l.ArchivedAt.Equal(at)) {
+			t.Errorf("archive not round-tripped: %q %v", l.ArchivePath, l.ArchivedAt)
+		}
+	}
+}
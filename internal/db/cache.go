@@ -0,0 +1,150 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/alexzajac/the-dredger/internal/model"
+)
+
+// createPageCacheLinks creates the table recording which links own which
+// cached pages. A page can be shared, e.g. a redirect hop or an article two
+// links lead to, and is deleted when the last link owning it is. Pages
+// cached before they were owned are matched to links by URL, in the same
+// transaction, so the match-up happens exactly once.
+func createPageCacheLinks(db *sql.DB) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var exists int
+	err = tx.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'page_cache_links'`).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("check page_cache_links table: %w", err)
+	}
+	if exists > 0 {
+		return nil
+	}
+	for _, stmt := range []string{
+		`CREATE TABLE page_cache_links (
+			link_id INTEGER NOT NULL REFERENCES links(id) ON DELETE CASCADE,
+			url     TEXT NOT NULL REFERENCES page_cache(url) ON DELETE CASCADE,
+			PRIMARY KEY (link_id, url)
+		)`,
+		`CREATE INDEX idx_page_cache_links_url ON page_cache_links(url)`,
+		`CREATE TRIGGER page_cache_links_last_owner AFTER DELETE ON page_cache_links
+		 WHEN NOT EXISTS (SELECT 1 FROM page_cache_links WHERE url = OLD.url)
+		 BEGIN
+			DELETE FROM page_cache WHERE url = OLD.url;
+		 END`,
+		`INSERT OR IGNORE INTO page_cache_links (link_id, url)
+		 SELECT l.id, p.url FROM page_cache p JOIN links l ON l.url = p.url OR l.resolved_url = p.url`,
+	} {
+		if _, err := tx.Exec(stmt); err != nil {
+			return fmt.Errorf("create page_cache_links table: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// SaveCachedPage stores a fetched page, replacing any earlier copy of the
+// same URL, and records that page.LinkID, when set, owns it. A zero Status
+// is taken to be 200.
+func SaveCachedPage(db *sql.DB, page model.CachedPage) error {
+	if page.Status == 0 {
+		page.Status = http.StatusOK
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	_, err = tx.Exec(
+		`INSERT INTO page_cache (url, status, location, content_type, etag, last_modified, body, fetched_at)
+		 VALUES (?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+		 ON CONFLICT(url) DO UPDATE SET
+			status = excluded.status, location = excluded.location,
+			content_type = excluded.content_type, etag = excluded.etag,
+			last_modified = excluded.last_modified, body = excluded.body,
+			fetched_at = excluded.fetched_at`,
+		page.URL, page.Status, page.Location, page.ContentType, page.ETag, page.LastModified, page.Body,
+	)
+	if err != nil {
+		return fmt.Errorf("save cached page: %w", err)
+	}
+	if page.LinkID != 0 {
+		_, err = tx.Exec(`INSERT OR IGNORE INTO page_cache_links (link_id, url) VALUES (?, ?)`, page.LinkID, page.URL)
+		if err != nil {
+			return fmt.Errorf("save cached page owner: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// GetCachedPage returns the cached copy of url, or nil if there is none.
+func GetCachedPage(db *sql.DB, url string) (*model.CachedPage, error) {
+	var p model.CachedPage
+	var fetchedAt string
+	err := db.QueryRow(
+		`SELECT url, status, location, content_type, etag, last_modified, body, fetched_at FROM page_cache WHERE url = ?`, url,
+	).Scan(&p.URL, &p.Status, &p.Location, &p.ContentType, &p.ETag, &p.LastModified, &p.Body, &fetchedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get cached page: %w", err)
+	}
+	p.FetchedAt = parseDateStr(fetchedAt)
+	return &p, nil
+}
+
+// TouchCachedPage records that a cached page was revalidated.
+func TouchCachedPage(db *sql.DB, url string) error {
+	_, err := db.Exec(`UPDATE page_cache SET fetched_at = CURRENT_TIMESTAMP WHERE url = ?`, url)
+	if err != nil {
+		return fmt.Errorf("touch cached page: %w", err)
+	}
+	return nil
+}
+
+// PruneCachedPages evicts pages no link owns and pages last fetched more
+// than maxAge ago, then the oldest pages until the cache holds no more than
+// maxBytes of bodies. A zero limit is not applied. It returns the number of
+// pages evicted.
+func PruneCachedPages(db *sql.DB, maxAge time.Duration, maxBytes int64) (int64, error) {
+	res, err := db.Exec(`DELETE FROM page_cache WHERE url NOT IN (SELECT url FROM page_cache_links)`)
+	if err != nil {
+		return 0, fmt.Errorf("evict unowned cached pages: %w", err)
+	}
+	evicted, _ := res.RowsAffected()
+	if maxAge > 0 {
+		cutoff := time.Now().Add(-maxAge).UTC().Format("2006-01-02 15:04:05")
+		res, err := db.Exec(`DELETE FROM page_cache WHERE fetched_at < ?`, cutoff)
+		if err != nil {
+			return evicted, fmt.Errorf("evict old cached pages: %w", err)
+		}
+		n, _ := res.RowsAffected()
+		evicted += n
+	}
+	if maxBytes > 0 {
+		// Keep the newest pages whose bodies, added up newest first, fit.
+		res, err := db.Exec(
+			`DELETE FROM page_cache WHERE url IN (
+				SELECT url FROM (
+					SELECT url, SUM(LENGTH(body)) OVER (ORDER BY fetched_at DESC, url) AS total FROM page_cache
+				) WHERE total > ?
+			)`, maxBytes,
+		)
+		if err != nil {
+			return evicted, fmt.Errorf("evict cached pages over size: %w", err)
+		}
+		n, _ := res.RowsAffected()
+		evicted += n
+	}
+	return evicted, nil
+}
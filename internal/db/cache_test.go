@@ -0,0 +1,175 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/alexzajac/the-dredger/internal/model"
+)
+
+func TestCachedPage(t *testing.T) {
+	db := setupTestDB(t)
+
+	p, err := GetCachedPage(db, "https://example.com/")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if p != nil {
+		t.Fatalf("expected cache miss, got %+v", p)
+	}
+
+	page := model.CachedPage{
+		URL:         "https://example.com/",
+		ContentType: "text/html; charset=utf-8",
+		ETag:        `"v1"`,
+		Body:        []byte("<title>One</title>"),
+	}
+	if err := SaveCachedPage(db, page); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	page.ETag, page.Body = `"v2"`, []byte("<title>Two</title>")
+	if err := SaveCachedPage(db, page); err != nil {
+		t.Fatalf("save again: %v", err)
+	}
+
+	p, err = GetCachedPage(db, "https://example.com/")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if p == nil || p.ETag != `"v2"` || string(p.Body) != "<title>Two</title>" || p.ContentType != page.ContentType {
+		t.Fatalf("expected replaced page, got %+v", p)
+	}
+	if err := TouchCachedPage(db, page.URL); err != nil {
+		t.Fatalf("touch: %v", err)
+	}
+}
+
+func TestEnqueueRecrunch(t *testing.T) {
+	db := setupTestDB(t)
+
+	cached, _ := InsertLink(db, model.Link{URL: "https://example.com/cached"})
+	_, _ = InsertLink(db, model.Link{URL: "https://example.com/uncached"})
+	// Pages are matched by the link they were fetched for, not their URL.
+	_ = SaveCachedPage(db, model.CachedPage{URL: "https://api.example.com/item/1", LinkID: cached, Body: []byte("hi")})
+	_ = SaveCachedPage(db, model.CachedPage{URL: "https://example.com/uncached", Body: []byte("hi")})
+
+	n, err := EnqueueRecrunch(db)
+	if err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	if n != 1 {
+		t.Fatalf("expected only the cached link queued, got %d", n)
+	}
+	job, _ := ClaimDredgeJob(db)
+	if job == nil || job.LinkID != cached || job.Mode != model.JobRecrunch {
+		t.Fatalf("expected a recrunch job for the cached link, got %+v", job)
+	}
+}
+
+func TestCachedPagesGoWithTheirLinks(t *testing.T) {
+	db := setupTestDB(t)
+
+	a, _ := InsertLink(db, model.Link{URL: "https://short.example/a"})
+	b, _ := InsertLink(db, model.Link{URL: "https://example.com/post"})
+	c, _ := InsertLink(db, model.Link{URL: "https://example.com/other"})
+	// Link a redirects to the page link b was saved as, so both own it.
+	_ = SaveCachedPage(db, model.CachedPage{
+		URL: "https://short.example/a", LinkID: a, Status: 301, Location: "https://example.com/post",
+	})
+	_ = SaveCachedPage(db, model.CachedPage{URL: "https://example.com/post", LinkID: a, Body: []byte("post")})
+	_ = SaveCachedPage(db, model.CachedPage{URL: "https://example.com/post", LinkID: b, Body: []byte("post")})
+	_ = SaveCachedPage(db, model.CachedPage{URL: "https://example.com/other", LinkID: c, Body: []byte("other")})
+
+	p, _ := GetCachedPage(db, "https://short.example/a")
+	if p == nil || p.Status != 301 || p.Location != "https://example.com/post" {
+		t.Fatalf("expected the cached redirect, got %+v", p)
+	}
+	if n, _ := EnqueueRecrunch(db); n != 3 {
+		t.Errorf("expected every owner queued for recrunch, got %d", n)
+	}
+
+	if _, err := db.Exec(`DELETE FROM links WHERE id = ?`, b); err != nil {
+		t.Fatalf("delete link: %v", err)
+	}
+	if p, _ := GetCachedPage(db, "https://example.com/post"); p == nil {
+		t.Fatal("expected a shared page kept while another link owns it")
+	}
+	if _, err := db.Exec(`DELETE FROM links WHERE id = ?`, a); err != nil {
+		t.Fatalf("delete link: %v", err)
+	}
+	for _, url := range []string{"https://short.example/a", "https://example.com/post"} {
+		if p, _ := GetCachedPage(db, url); p != nil {
+			t.Errorf("expected %s deleted with its last owner, got %+v", url, p)
+		}
+	}
+	if p, _ := GetCachedPage(db, "https://example.com/other"); p == nil {
+		t.Fatal("expected other pages kept")
+	}
+
+	if _, err := DeleteAllLinks(db); err != nil {
+		t.Fatalf("delete all: %v", err)
+	}
+	if p, _ := GetCachedPage(db, "https://example.com/other"); p != nil {
+		t.Errorf("expected reset to clear the cache, got %+v", p)
+	}
+}
+
+func TestPageCacheOwnersMigration(t *testing.T) {
+	db := setupTestDB(t)
+
+	id, _ := InsertLink(db, model.Link{URL: "https://example.com/old"})
+	_ = SaveCachedPage(db, model.CachedPage{URL: "https://example.com/old", Body: []byte("old")})
+	// A database from before pages had owners.
+	if _, err := db.Exec(`DROP TABLE page_cache_links`); err != nil {
+		t.Fatalf("drop table: %v", err)
+	}
+	if err := InitSchema(db); err != nil {
+		t.Fatalf("init schema: %v", err)
+	}
+	job := func() *model.DredgeJob {
+		_, _ = EnqueueRecrunch(db)
+		j, _ := ClaimDredgeJob(db)
+		return j
+	}()
+	if job == nil || job.LinkID != id {
+		t.Errorf("expected the page matched to its link by URL, got %+v", job)
+	}
+}
+
+func TestPruneCachedPages(t *testing.T) {
+	db := setupTestDB(t)
+
+	id, _ := InsertLink(db, model.Link{URL: "https://example.com/newest"})
+	_ = SaveCachedPage(db, model.CachedPage{URL: "https://example.com/unowned", Body: []byte("x")})
+
+	for url, fetched := range map[string]string{
+		"https://example.com/old":    "-30 days",
+		"https://example.com/older":  "-2 hours",
+		"https://example.com/newest": "-1 minutes",
+	} {
+		_ = SaveCachedPage(db, model.CachedPage{URL: url, LinkID: id, Body: []byte("0123456789")})
+		_, _ = db.Exec(`UPDATE page_cache SET fetched_at = datetime('now', ?) WHERE url = ?`, fetched, url)
+	}
+
+	n, err := PruneCachedPages(db, 7*24*time.Hour, 15)
+	if err != nil {
+		t.Fatalf("prune: %v", err)
+	}
+	if n != 3 {
+		t.Errorf("expected 3 pages evicted, got %d", n)
+	}
+	for url, kept := range map[string]bool{
+		"https://example.com/unowned": false,
+		"https://example.com/old":     false,
+		"https://example.com/older":   false,
+		"https://example.com/newest":  true,
+	} {
+		if p, _ := GetCachedPage(db, url); (p != nil) != kept {
+			t.Errorf("%s: expected kept=%v", url, kept)
+		}
+	}
+
+	if n, _ := PruneCachedPages(db, 0, 0); n != 0 {
+		t.Errorf("expected no limits to evict nothing, got %d", n)
+	}
+}
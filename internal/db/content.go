@@ -0,0 +1,128 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/alexzajac/the-dredger/internal/model"
+)
+
+// wordsPerMinute is an adult's average silent reading speed for
+// non-fiction.
+const wordsPerMinute = 238
+
+// SaveLinkContent stores the extracted page text for a link, replacing
+// any earlier copy, and records the link's word count and reading time.
+func SaveLinkContent(db *sql.DB, linkID int64, text string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	_, err = tx.Exec(
+		`INSERT INTO link_content (link_id, text, fetched_at) VALUES (?, ?, CURRENT_TIMESTAMP)
+		 ON CONFLICT(link_id) DO UPDATE SET text = excluded.text, fetched_at = excluded.fetched_at`,
+		linkID, text,
+	)
+	if err != nil {
+		return fmt.Errorf("save link content: %w", err)
+	}
+	if err := updateReadingTime(tx, linkID, text); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func updateReadingTime(tx *sql.Tx, linkID int64, text string) error {
+	words := countWords(text)
+	_, err := tx.Exec(`UPDATE links SET word_count=?, reading_time=? WHERE id=?`,
+		words, int(readingTime(words)/time.Second), linkID)
+	if err != nil {
+		return fmt.Errorf("update reading time: %w", err)
+	}
+	return nil
+}
+
+// countWords counts the words in text extracted from a page, ignoring the
+// markers for headings, list items and quotes.
+func countWords(text string) int {
+	n := 0
+	for _, f := range strings.Fields(text) {
+		if strings.IndexFunc(f, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) >= 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// readingTime estimates how long words take to read, to the second.
+func readingTime(words int) time.Duration {
+	return (time.Duration(words) * time.Minute / wordsPerMinute).Truncate(time.Second)
+}
+
+// addWordCount adds the word_count column and, in the same transaction,
+// records the word count and reading time of links whose text was fetched
+// before they were tracked, so that a failed backfill is retried on the
+// next start rather than leaving those links unmeasured.
+func addWordCount(db *sql.DB) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	_, err = tx.Exec(`ALTER TABLE links ADD COLUMN word_count INTEGER DEFAULT 0`)
+	if err != nil {
+		if strings.Contains(err.Error(), "duplicate column") {
+			return nil
+		}
+		return fmt.Errorf("migration word_count: %w", err)
+	}
+
+	rows, err := tx.Query(`SELECT link_id, text FROM link_content WHERE text != ''`)
+	if err != nil {
+		return fmt.Errorf("query unmeasured content: %w", err)
+	}
+	texts := map[int64]string{}
+	for rows.Next() {
+		var id int64
+		var text string
+		if err := rows.Scan(&id, &text); err != nil {
+			_ = rows.Close()
+			return fmt.Errorf("scan link content: %w", err)
+		}
+		texts[id] = text
+	}
+	_ = rows.Close()
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("query unmeasured content: %w", err)
+	}
+	for id, text := range texts {
+		if err := updateReadingTime(tx, id, text); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetLinkContent returns the stored page text for a link, or nil if the
+// link has not been crawled yet.
+func GetLinkContent(db *sql.DB, linkID int64) (*model.LinkContent, error) {
+	var c model.LinkContent
+	var fetchedAt string
+	err := db.QueryRow(
+		`SELECT link_id, text, fetched_at FROM link_content WHERE link_id = ?`, linkID,
+	).Scan(&c.LinkID, &c.Text, &fetchedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get link content: %w", err)
+	}
+	c.FetchedAt = parseDateStr(fetchedAt)
+	return &c, nil
+}
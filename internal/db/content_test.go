@@ -0,0 +1,123 @@
+package db
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alexzajac/the-dredger/internal/model"
+)
+
+func TestLinkContent(t *testing.T) {
+	db := setupTestDB(t)
+
+	id, err := InsertLink(db, model.Link{URL: "https://example.com/post"})
+	if err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+
+	c, err := GetLinkContent(db, id)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if c != nil {
+		t.Fatalf("expected no content before crawl, got %+v", c)
+	}
+
+	if err := SaveLinkContent(db, id, "first"); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	if err := SaveLinkContent(db, id, "# Heading\n\nsecond"); err != nil {
+		t.Fatalf("save again: %v", err)
+	}
+	c, err = GetLinkContent(db, id)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if c == nil || c.Text != "# Heading\n\nsecond" {
+		t.Fatalf("expected replaced content, got %+v", c)
+	}
+	if c.FetchedAt.IsZero() {
+		t.Error("expected fetched_at to be set")
+	}
+
+	links, _ := GetLinks(db)
+	if l := findLink(links, id); l.WordCount != 2 || l.ReadingTime != 0 {
+		t.Errorf("word count %d, reading time %v; want 2 and under a second", l.WordCount, l.ReadingTime)
+	}
+
+	if err := DeleteLink(db, id); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if c, _ := GetLinkContent(db, id); c != nil {
+		t.Errorf("expected content to be deleted with its link, got %+v", c)
+	}
+}
+
+func TestReadingTime(t *testing.T) {
+	if n := countWords("# A heading\n\n- one item\n> a quote — with a dash\n\n2024 was 50% better."); n != 13 {
+		t.Errorf("countWords = %d, want 13", n)
+	}
+	if d := readingTime(wordsPerMinute * 10); d != 10*time.Minute {
+		t.Errorf("readingTime = %v, want 10m", d)
+	}
+	if d := readingTime(100); d != 25*time.Second {
+		t.Errorf("readingTime(100) = %v, want 25s", d)
+	}
+
+	db := setupTestDB(t)
+	id, _ := InsertLink(db, model.Link{URL: "https://example.com/long"})
+	if _, err := db.Exec(`INSERT INTO link_content (link_id, text) VALUES (?, ?)`, id, strings.Repeat("word ", 476)); err != nil {
+		t.Fatalf("insert content: %v", err)
+	}
+	// A database from before word counts were kept is measured when the
+	// column is added.
+	if _, err := db.Exec(`ALTER TABLE links DROP COLUMN word_count`); err != nil {
+		t.Fatalf("drop column: %v", err)
+	}
+	if err := InitSchema(db); err != nil {
+		t.Fatalf("init schema: %v", err)
+	}
+	links, _ := GetLinks(db)
+	if l := findLink(links, id); l.WordCount != 476 || l.ReadingTime != 2*time.Minute {
+		t.Errorf("backfilled word count %d, reading time %v; want 476 and 2m", l.WordCount, l.ReadingTime)
+	}
+
+	// Later starts leave it alone.
+	_, _ = db.Exec(`UPDATE links SET word_count = 0 WHERE id = ?`, id)
+	if err := InitSchema(db); err != nil {
+		t.Fatalf("init schema again: %v", err)
+	}
+	links, _ = GetLinks(db)
+	if l := findLink(links, id); l.WordCount != 0 {
+		t.Errorf("expected no backfill on later starts, got word count %d", l.WordCount)
+	}
+}
+
+func TestReadingTimeBackfillRetried(t *testing.T) {
+	db := setupTestDB(t)
+	id, _ := InsertLink(db, model.Link{URL: "https://example.com/long"})
+	if _, err := db.Exec(`INSERT INTO link_content (link_id, text) VALUES (?, ?)`, id, strings.Repeat("word ", 476)); err != nil {
+		t.Fatalf("insert content: %v", err)
+	}
+	if _, err := db.Exec(`ALTER TABLE links DROP COLUMN word_count`); err != nil {
+		t.Fatalf("drop column: %v", err)
+	}
+
+	// A backfill that fails takes the new column with it...
+	_, _ = db.Exec(`CREATE TRIGGER fail_backfill BEFORE UPDATE OF reading_time ON links
+		BEGIN SELECT RAISE(ABORT, 'disk full'); END`)
+	if err := InitSchema(db); err == nil {
+		t.Fatal("expected the failed backfill to be reported")
+	}
+	_, _ = db.Exec(`DROP TRIGGER fail_backfill`)
+
+	// ...so that the next start measures the links again.
+	if err := InitSchema(db); err != nil {
+		t.Fatalf("init schema: %v", err)
+	}
+	links, _ := GetLinks(db)
+	if l := findLink(links, id); l.WordCount != 476 {
+		t.Errorf("word count after retried backfill = %d, want 476", l.WordCount)
+	}
+}
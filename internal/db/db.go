@@ -31,10 +31,25 @@ func Open(path string) (*sql.DB, error) {
 		_ = db.Close()
 		return nil, fmt.Errorf("enable foreign keys: %w", err)
 	}
+	// Wait for another process using the database, such as a CLI dredge
+	// next to the TUI, rather than failing with SQLITE_BUSY.
+	if _, err := db.Exec("PRAGMA busy_timeout=5000"); err != nil {
+		_ = db.Close()
+		return nil, fmt.Errorf("set busy timeout: %w", err)
+	}
 
 	return db, nil
 }
 
+// FilePath returns the file the database was opened from.
+func FilePath(db *sql.DB) (string, error) {
+	var path string
+	if err := db.QueryRow(`SELECT file FROM pragma_database_list WHERE name = 'main'`).Scan(&path); err != nil {
+		return "", fmt.Errorf("find database file: %w", err)
+	}
+	return path, nil
+}
+
 func InitSchema(db *sql.DB) error {
 	_, err := db.Exec(`
 		CREATE TABLE IF NOT EXISTS links (
@@ -57,6 +72,33 @@ func InitSchema(db *sql.DB) error {
 		`ALTER TABLE links ADD COLUMN dredge_state INTEGER DEFAULT 0`,
 		`ALTER TABLE links ADD COLUMN dredge_error TEXT DEFAULT ''`,
 		`ALTER TABLE links ADD COLUMN summary TEXT DEFAULT ''`,
+		`ALTER TABLE links ADD COLUMN canonical_url TEXT DEFAULT ''`,
+		`ALTER TABLE links ADD COLUMN site_name TEXT DEFAULT ''`,
+		`ALTER TABLE links ADD COLUMN author TEXT DEFAULT ''`,
+		`ALTER TABLE links ADD COLUMN published_at TEXT DEFAULT ''`,
+		`ALTER TABLE links ADD COLUMN modified_at TEXT DEFAULT ''`,
+		`ALTER TABLE links ADD COLUMN image_url TEXT DEFAULT ''`,
+		`ALTER TABLE links ADD COLUMN page_type TEXT DEFAULT ''`,
+		`ALTER TABLE links ADD COLUMN lang TEXT DEFAULT ''`,
+		`ALTER TABLE links ADD COLUMN content_type TEXT DEFAULT ''`,
+		`ALTER TABLE links ADD COLUMN dredge_error_class INTEGER DEFAULT 0`,
+		`ALTER TABLE links ADD COLUMN details TEXT DEFAULT ''`,
+		`ALTER TABLE links ADD COLUMN media_duration INTEGER DEFAULT 0`,
+		`ALTER TABLE links ADD COLUMN resolved_url TEXT DEFAULT ''`,
+		`ALTER TABLE links ADD COLUMN redirect_chain TEXT DEFAULT ''`,
+		`ALTER TABLE links ADD COLUMN check_status INTEGER DEFAULT 0`,
+		`ALTER TABLE links ADD COLUMN check_error TEXT DEFAULT ''`,
+		`ALTER TABLE links ADD COLUMN checked_at TEXT DEFAULT ''`,
+		`ALTER TABLE links ADD COLUMN check_failures INTEGER DEFAULT 0`,
+		`ALTER TABLE links ADD COLUMN dead INTEGER DEFAULT 0`,
+		`ALTER TABLE links ADD COLUMN archive_url TEXT DEFAULT ''`,
+		`ALTER TABLE links ADD COLUMN archive_path TEXT DEFAULT ''`,
+		`ALTER TABLE links ADD COLUMN archived_at TEXT DEFAULT ''`,
+		`ALTER TABLE links ADD COLUMN read_at TEXT DEFAULT ''`,
+		`ALTER TABLE links ADD COLUMN reader_position REAL DEFAULT 0`,
+		`ALTER TABLE links ADD COLUMN read_count INTEGER DEFAULT 0`,
+		`ALTER TABLE links ADD COLUMN progress REAL DEFAULT 0`,
+		`ALTER TABLE links ADD COLUMN reading_time INTEGER DEFAULT 0`,
 	}
 	for _, m := range migrations {
 		_, err = db.Exec(m)
@@ -64,12 +106,90 @@ func InitSchema(db *sql.DB) error {
 			return fmt.Errorf("migration %q: %w", m, err)
 		}
 	}
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS suggested_tags (
+			id         INTEGER PRIMARY KEY AUTOINCREMENT,
+			link_id    INTEGER NOT NULL REFERENCES links(id) ON DELETE CASCADE,
+			tag        TEXT NOT NULL,
+			confidence REAL DEFAULT 0,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			UNIQUE(link_id, tag)
+		);
+	`)
+	if err != nil {
+		return fmt.Errorf("create suggested_tags table: %w", err)
+	}
+
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS link_content (
+			link_id    INTEGER PRIMARY KEY REFERENCES links(id) ON DELETE CASCADE,
+			text       TEXT NOT NULL DEFAULT '',
+			fetched_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		);
+	`)
+	if err != nil {
+		return fmt.Errorf("create link_content table: %w", err)
+	}
+
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS dredge_jobs (
+			id          INTEGER PRIMARY KEY AUTOINCREMENT,
+			link_id     INTEGER NOT NULL UNIQUE REFERENCES links(id) ON DELETE CASCADE,
+			state       INTEGER DEFAULT 0,
+			attempts    INTEGER DEFAULT 0,
+			next_run_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			last_error  TEXT DEFAULT '',
+			created_at  DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_at  DATETIME DEFAULT CURRENT_TIMESTAMP
+		);
+	`)
+	if err != nil {
+		return fmt.Errorf("create dredge_jobs table: %w", err)
+	}
+
+	for _, m := range []string{
+		`ALTER TABLE dredge_jobs ADD COLUMN mode INTEGER DEFAULT 0`,
+		`ALTER TABLE dredge_jobs ADD COLUMN lease_until TEXT DEFAULT ''`,
+	} {
+		_, err = db.Exec(m)
+		if err != nil && !strings.Contains(err.Error(), "duplicate column") {
+			return fmt.Errorf("migrate dredge_jobs: %w", err)
+		}
+	}
+
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS page_cache (
+			url           TEXT PRIMARY KEY,
+			content_type  TEXT DEFAULT '',
+			etag          TEXT DEFAULT '',
+			last_modified TEXT DEFAULT '',
+			body          BLOB,
+			fetched_at    DATETIME DEFAULT CURRENT_TIMESTAMP
+		);
+	`)
+	if err != nil {
+		return fmt.Errorf("create page_cache table: %w", err)
+	}
+	for _, m := range []string{
+		`ALTER TABLE page_cache ADD COLUMN status INTEGER DEFAULT 200`,
+		`ALTER TABLE page_cache ADD COLUMN location TEXT DEFAULT ''`,
+	} {
+		_, err = db.Exec(m)
+		if err != nil && !strings.Contains(err.Error(), "duplicate column") {
+			return fmt.Errorf("migrate page_cache: %w", err)
+		}
+	}
+	if err := createPageCacheLinks(db); err != nil {
+		return err
+	}
 
 	// Indexes for performance
 	indexes := []string{
 		`CREATE INDEX IF NOT EXISTS idx_links_status ON links(status)`,
 		`CREATE INDEX IF NOT EXISTS idx_links_enriched ON links(enriched)`,
 		`CREATE INDEX IF NOT EXISTS idx_links_dredge_state ON links(dredge_state)`,
+		`CREATE INDEX IF NOT EXISTS idx_links_canonical_url ON links(canonical_url)`,
+		`CREATE INDEX IF NOT EXISTS idx_dredge_jobs_state ON dredge_jobs(state, next_run_at)`,
 	}
 	for _, idx := range indexes {
 		if _, err := db.Exec(idx); err != nil {
@@ -77,5 +197,5 @@ func InitSchema(db *sql.DB) error {
 		}
 	}
 
-	return nil
+	return addWordCount(db)
 }
@@ -0,0 +1,216 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/alexzajac/the-dredger/internal/model"
+)
+
+// DuplicateGroup is a set of links that point at the same page. Keep is
+// the link that survives a merge.
+type DuplicateGroup struct {
+	Keep       model.Link
+	Duplicates []model.Link
+}
+
+// DedupeKey normalises a URL for duplicate detection: scheme, "www.",
+// fragment and trailing slash are ignored and the host is lower-cased.
+func DedupeKey(rawURL string) string {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || u.Host == "" {
+		return rawURL
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Host), "www.")
+	path := strings.TrimSuffix(u.EscapedPath(), "/")
+	key := host + path
+	if u.RawQuery != "" {
+		key += "?" + u.RawQuery
+	}
+	return key
+}
+
+// linkKey is the identity of a link: its canonical URL when that can be
+// trusted, otherwise where it ends up after redirects.
+func linkKey(l model.Link) string {
+	if trustedCanonical(l) {
+		return DedupeKey(l.CanonicalURL)
+	}
+	return DedupeKey(l.FinalURL())
+}
+
+// trustedCanonical reports whether a link's canonical URL names the page
+// itself. Plenty of sites declare their homepage, or some other page, as
+// canonical for everything they serve, so only a canonical URL on the
+// same host with a path of its own counts, or one that agrees with where
+// the link ends up.
+func trustedCanonical(l model.Link) bool {
+	if l.CanonicalURL == "" {
+		return false
+	}
+	if DedupeKey(l.CanonicalURL) == DedupeKey(l.FinalURL()) {
+		return true
+	}
+	canon, err := url.Parse(l.CanonicalURL)
+	if err != nil {
+		return false
+	}
+	final, err := url.Parse(l.FinalURL())
+	if err != nil {
+		return false
+	}
+	sameHost := strings.TrimPrefix(strings.ToLower(canon.Hostname()), "www.") ==
+		strings.TrimPrefix(strings.ToLower(final.Hostname()), "www.")
+	return sameHost && strings.Trim(canon.Path, "/") != ""
+}
+
+// FindDuplicates groups links whose canonical (or resolved, or saved) URLs
+// match. A link also joins a group when its saved URL is another link's
+// canonical or resolved URL, so shortened links join the page they lead to.
+func FindDuplicates(db *sql.DB) ([]DuplicateGroup, error) {
+	links, err := GetLinks(db)
+	if err != nil {
+		return nil, err
+	}
+
+	// Union links sharing either key so that A(url=x) and B(canonical=x)
+	// end up together even though their own keys differ.
+	parent := make([]int, len(links))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	byKey := make(map[string]int)
+	for i, l := range links {
+		for _, k := range []string{linkKey(l), DedupeKey(l.FinalURL()), DedupeKey(l.URL)} {
+			if j, ok := byKey[k]; ok {
+				parent[find(i)] = find(j)
+			} else {
+				byKey[k] = i
+			}
+		}
+	}
+
+	members := make(map[int][]model.Link)
+	var roots []int
+	for i, l := range links {
+		r := find(i)
+		if _, ok := members[r]; !ok {
+			roots = append(roots, r)
+		}
+		members[r] = append(members[r], l)
+	}
+
+	var groups []DuplicateGroup
+	for _, r := range roots {
+		group := members[r]
+		if len(group) < 2 {
+			continue
+		}
+		sort.SliceStable(group, func(i, j int) bool { return keepBefore(group[i], group[j]) })
+		groups = append(groups, DuplicateGroup{Keep: group[0], Duplicates: group[1:]})
+	}
+	return groups, nil
+}
+
+// keepBefore orders links by which one should survive a merge: saved
+// first, then pending, then pruned, and the oldest within each.
+func keepBefore(a, b model.Link) bool {
+	rank := func(s model.Status) int {
+		switch s {
+		case model.Saved:
+			return 0
+		case model.Unprocessed:
+			return 1
+		default:
+			return 2
+		}
+	}
+	if rank(a.Status) != rank(b.Status) {
+		return rank(a.Status) < rank(b.Status)
+	}
+	return a.ID < b.ID
+}
+
+// MergeDuplicates folds each duplicate's tags into the kept link and
+// deletes the duplicates. A kept link without an offline copy takes over a
+// duplicate's; the duplicates' other copies are deleted with them. It
+// returns the number of links removed.
+func MergeDuplicates(db *sql.DB, groups []DuplicateGroup) (int, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	// Copies moved to their kept link, as [from, to] directories, so that
+	// they can be put back if the merge fails.
+	var moved [][2]string
+	undo := func() {
+		for _, m := range moved {
+			_ = os.Rename(m[1], m[0])
+		}
+	}
+	var discard []string
+
+	removed := 0
+	for _, g := range groups {
+		tags := g.Keep.Tags
+		hasArchive := g.Keep.ArchivePath != ""
+		for _, d := range g.Duplicates {
+			for _, t := range d.Tags {
+				if !containsFold(tags, t) {
+					tags = append(tags, t)
+				}
+			}
+			if _, err := tx.Exec(`DELETE FROM links WHERE id = ?`, d.ID); err != nil {
+				undo()
+				return 0, fmt.Errorf("delete duplicate %d: %w", d.ID, err)
+			}
+			removed++
+
+			if d.ArchivePath == "" {
+				continue
+			}
+			from := filepath.Dir(d.ArchivePath)
+			to := filepath.Join(filepath.Dir(from), strconv.FormatInt(g.Keep.ID, 10))
+			if hasArchive || os.Rename(from, to) != nil {
+				discard = append(discard, from)
+				continue
+			}
+			moved = append(moved, [2]string{from, to})
+			path := filepath.Join(to, filepath.Base(d.ArchivePath))
+			if _, err := tx.Exec(`UPDATE links SET archive_path=?, archived_at=? WHERE id=?`,
+				path, formatOptionalTime(d.ArchivedAt.UTC()), g.Keep.ID); err != nil {
+				undo()
+				return 0, fmt.Errorf("move offline copy to %d: %w", g.Keep.ID, err)
+			}
+			hasArchive = true
+		}
+		if _, err := tx.Exec(`UPDATE links SET tags = ? WHERE id = ?`, strings.Join(tags, ","), g.Keep.ID); err != nil {
+			undo()
+			return 0, fmt.Errorf("merge tags into %d: %w", g.Keep.ID, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		undo()
+		return 0, fmt.Errorf("commit transaction: %w", err)
+	}
+	for _, dir := range discard {
+		_ = os.RemoveAll(dir)
+	}
+	return removed, nil
+}
@@ -0,0 +1,244 @@
+package db
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/alexzajac/the-dredger/internal/model"
+)
+
+func TestDedupeKey(t *testing.T) {
+	same := []string{
+		"https://www.Example.com/post/",
+		"http://example.com/post",
+		"https://example.com/post#comments",
+	}
+	for _, u := range same {
+		if got := DedupeKey(u); got != "example.com/post" {
+			t.Errorf("DedupeKey(%q) = %q", u, got)
+		}
+	}
+	if DedupeKey("https://example.com/post?id=1") == DedupeKey("https://example.com/post?id=2") {
+		t.Error("query strings should distinguish pages")
+	}
+}
+
+func TestFindAndMergeDuplicates(t *testing.T) {
+	db := setupTestDB(t)
+
+	insert := func(l model.Link) int64 {
+		t.Helper()
+		id, err := InsertLink(db, l)
+		if err != nil {
+			t.Fatalf("insert: %v", err)
+		}
+		return id
+	}
+	amp := insert(model.Link{URL: "https://example.com/amp/post", Tags: []string{"news"}})
+	orig := insert(model.Link{URL: "https://example.com/post", Status: model.Saved, Tags: []string{"go"}})
+	slash := insert(model.Link{URL: "https://www.example.com/post/", Status: model.Pruned})
+	other := insert(model.Link{URL: "https://example.com/other"})
+
+	if err := UpdateLinkMeta(db, model.Link{ID: amp, Title: "Post", CanonicalURL: "https://example.com/post"}); err != nil {
+		t.Fatalf("update meta: %v", err)
+	}
+
+	groups, err := FindDuplicates(db)
+	if err != nil {
+		t.Fatalf("find duplicates: %v", err)
+	}
+	if len(groups) != 1 {
+		t.Fatalf("expected 1 group, got %d", len(groups))
+	}
+	if groups[0].Keep.ID != orig {
+		t.Errorf("expected the saved link to be kept, got %d", groups[0].Keep.ID)
+	}
+	if len(groups[0].Duplicates) != 2 {
+		t.Fatalf("expected 2 duplicates, got %+v", groups[0].Duplicates)
+	}
+
+	removed, err := MergeDuplicates(db, groups)
+	if err != nil {
+		t.Fatalf("merge: %v", err)
+	}
+	if removed != 2 {
+		t.Errorf("expected 2 removed, got %d", removed)
+	}
+
+	links, _ := GetLinks(db)
+	ids := map[int64]model.Link{}
+	for _, l := range links {
+		ids[l.ID] = l
+	}
+	if _, ok := ids[amp]; ok {
+		t.Error("amp copy should be deleted")
+	}
+	if _, ok := ids[slash]; ok {
+		t.Error("trailing-slash copy should be deleted")
+	}
+	if _, ok := ids[other]; !ok {
+		t.Error("unrelated link should remain")
+	}
+	if got := ids[orig].Tags; len(got) != 2 || got[0] != "go" || got[1] != "news" {
+		t.Errorf("expected merged tags [go news], got %v", got)
+	}
+}
+
+func TestUpdateDredgeResultMetadata(t *testing.T) {
+	db := setupTestDB(t)
+	id, _ := InsertLink(db, model.Link{URL: "https://example.com"})
+
+	published := time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)
+	err := UpdateDredgeResult(db, model.Link{
+		ID:            id,
+		Title:         "Example",
+		Summary:       "A summary.",
+		Tags:          []string{"web"},
+		CanonicalURL:  "https://example.com/",
+		SiteName:      "Example Site",
+		Author:        "Ada",
+		PublishedAt:   published,
+		PageType:      "article",
+		Language:      "en",
+		MediaDuration: 95 * time.Second,
+	})
+	if err != nil {
+		t.Fatalf("update: %v", err)
+	}
+
+	links, _ := GetLinks(db)
+	l := links[0]
+	if l.DredgeState != model.DredgeComplete || l.Summary != "A summary." || l.Author != "Ada" ||
+		l.SiteName != "Example Site" || l.CanonicalURL != "https://example.com/" || l.Language != "en" {
+		t.Errorf("unexpected link: %+v", l)
+	}
+	if !l.PublishedAt.Equal(published) {
+		t.Errorf("PublishedAt = %v, want %v", l.PublishedAt, published)
+	}
+	if !l.ModifiedAt.IsZero() {
+		t.Errorf("ModifiedAt should stay zero, got %v", l.ModifiedAt)
+	}
+	if l.MediaDuration != 95*time.Second {
+		t.Errorf("MediaDuration = %v, want 1m35s", l.MediaDuration)
+	}
+}
+
+func TestUpdateDredgeResultDetails(t *testing.T) {
+	db := setupTestDB(t)
+	id, _ := InsertLink(db, model.Link{URL: "https://github.com/octo/widget"})
+
+	pushed := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	repo := &model.RepoDetails{FullName: "octo/widget", Stars: 42, Language: "Go", Topics: []string{"cli"}, PushedAt: pushed}
+	if err := UpdateDredgeResult(db, model.Link{ID: id, Title: "octo/widget", Details: model.Details{Repo: repo}}); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+
+	links, _ := GetLinks(db)
+	got := links[0].Details.Repo
+	if got == nil || got.Stars != 42 || got.Language != "Go" || !got.PushedAt.Equal(pushed) || len(got.Topics) != 1 {
+		t.Fatalf("details not round-tripped: %+v", got)
+	}
+
+	// Details that are no longer found are cleared.
+	_ = UpdateDredgeResult(db, model.Link{ID: id, Title: "octo/widget"})
+	links, _ = GetLinks(db)
+	if !links[0].Details.IsZero() {
+		t.Errorf("expected details cleared, got %+v", links[0].Details)
+	}
+}
+
+func TestFindDuplicatesByResolvedURL(t *testing.T) {
+	db := setupTestDB(t)
+	short, _ := InsertLink(db, model.Link{URL: "https://t.co/abc"})
+	article, _ := InsertLink(db, model.Link{URL: "https://example.com/post", Status: model.Saved})
+	_, _ = InsertLink(db, model.Link{URL: "https://bit.ly/other"})
+
+	chain := []string{"https://t.co/abc", "https://example.com/post?utm_source=x", "https://example.com/post"}
+	if err := UpdateLinkMeta(db, model.Link{ID: short, ResolvedURL: "https://example.com/post", RedirectChain: chain}); err != nil {
+		t.Fatalf("update meta: %v", err)
+	}
+
+	links, _ := GetLinks(db)
+	for _, l := range links {
+		if l.ID == short && (l.FinalURL() != "https://example.com/post" || len(l.RedirectChain) != 3 || l.RedirectChain[1] != chain[1]) {
+			t.Errorf("redirect not round-tripped: %q %v", l.ResolvedURL, l.RedirectChain)
+		}
+	}
+
+	groups, err := FindDuplicates(db)
+	if err != nil {
+		t.Fatalf("find duplicates: %v", err)
+	}
+	if len(groups) != 1 || groups[0].Keep.ID != article || len(groups[0].Duplicates) != 1 || groups[0].Duplicates[0].ID != short {
+		t.Errorf("expected the shortened link to duplicate the article, got %+v", groups)
+	}
+}
+
+func TestFindDuplicatesIgnoresSharedCanonical(t *testing.T) {
+	db := setupTestDB(t)
+	first, _ := InsertLink(db, model.Link{URL: "https://blog.example.com/2024/first-post"})
+	second, _ := InsertLink(db, model.Link{URL: "https://blog.example.com/2024/second-post"})
+	elsewhere, _ := InsertLink(db, model.Link{URL: "https://mirror.example.net/first-post"})
+	// Every page declares the homepage, or another site, as canonical.
+	for _, l := range []model.Link{
+		{ID: first, CanonicalURL: "https://blog.example.com/"},
+		{ID: second, CanonicalURL: "https://blog.example.com/"},
+		{ID: elsewhere, CanonicalURL: "https://blog.example.com"},
+	} {
+		if err := UpdateLinkMeta(db, l); err != nil {
+			t.Fatalf("update meta: %v", err)
+		}
+	}
+
+	groups, err := FindDuplicates(db)
+	if err != nil {
+		t.Fatalf("find duplicates: %v", err)
+	}
+	if len(groups) != 0 {
+		t.Errorf("unrelated articles sharing a homepage canonical were grouped: %+v", groups)
+	}
+}
+
+func TestMergeDuplicatesArchives(t *testing.T) {
+	db := setupTestDB(t)
+	dir := t.TempDir()
+	keep, _ := InsertLink(db, model.Link{URL: "https://example.com/post", Status: model.Saved})
+	copyA, _ := InsertLink(db, model.Link{URL: "https://www.example.com/post/"})
+	copyB, _ := InsertLink(db, model.Link{URL: "http://example.com/post"})
+
+	archive := func(id int64) string {
+		path := filepath.Join(dir, strconv.FormatInt(id, 10), "index.html")
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("<p>copy</p>"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		_ = UpdateLinkArchive(db, id, path, time.Now())
+		return path
+	}
+	pathA, pathB := archive(copyA), archive(copyB)
+
+	groups, _ := FindDuplicates(db)
+	if _, err := MergeDuplicates(db, groups); err != nil {
+		t.Fatalf("merge: %v", err)
+	}
+
+	links, _ := GetLinks(db)
+	kept := findLink(links, keep)
+	want := filepath.Join(dir, strconv.FormatInt(keep, 10), "index.html")
+	if kept.ArchivePath != want {
+		t.Errorf("kept link's copy = %q, want %q", kept.ArchivePath, want)
+	}
+	if _, err := os.Stat(want); err != nil {
+		t.Errorf("moved copy: %v", err)
+	}
+	for _, path := range []string{pathA, pathB} {
+		if _, err := os.Stat(filepath.Dir(path)); !os.IsNotExist(err) {
+			t.Errorf("duplicate's copy %s should be gone, got %v", path, err)
+		}
+	}
+}
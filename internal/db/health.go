@@ -0,0 +1,109 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/alexzajac/the-dredger/internal/model"
+)
+
+// GetLinksDueCheck returns saved links that the dead-link checker has not
+// looked at since checkedBefore, least recently checked first.
+func GetLinksDueCheck(db *sql.DB, checkedBefore time.Time) ([]model.Link, error) {
+	rows, err := db.Query(
+		`SELECT `+linkSelectCols+` FROM links
+		 WHERE status = ? AND (checked_at = '' OR checked_at < ?)
+		 ORDER BY checked_at ASC, id ASC`,
+		int(model.Saved), formatOptionalTime(checkedBefore.UTC()),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query links due check: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var links []model.Link
+	for rows.Next() {
+		l, err := scanLink(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan link: %w", err)
+		}
+		links = append(links, l)
+	}
+	return links, rows.Err()
+}
+
+// UpdateLinkCheck stores the outcome of a health check held in link.
+func UpdateLinkCheck(db *sql.DB, link model.Link) error {
+	dead := 0
+	if link.Dead {
+		dead = 1
+	}
+	// Check times are kept in UTC so that they sort as strings.
+	_, err := db.Exec(
+		`UPDATE links SET check_status=?, check_error=?, checked_at=?, check_failures=?, dead=? WHERE id=?`,
+		link.CheckStatus, link.CheckError, formatOptionalTime(link.CheckedAt.UTC()), link.CheckFailures, dead, link.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("update link check: %w", err)
+	}
+	return nil
+}
+
+// UpdateArchiveURL records the archived copy found for a link.
+func UpdateArchiveURL(db *sql.DB, id int64, archiveURL string) error {
+	_, err := db.Exec(`UPDATE links SET archive_url=? WHERE id=?`, archiveURL, id)
+	if err != nil {
+		return fmt.Errorf("update archive url: %w", err)
+	}
+	return nil
+}
+
+// GetDeadLinks returns the links flagged dead that have not been pruned,
+// most recently checked first.
+func GetDeadLinks(db *sql.DB) ([]model.Link, error) {
+	rows, err := db.Query(
+		`SELECT `+linkSelectCols+` FROM links WHERE dead = 1 AND status != ? ORDER BY checked_at DESC`,
+		int(model.Pruned),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query dead links: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var links []model.Link
+	for rows.Next() {
+		l, err := scanLink(rows)
+		if err != nil {
+			return nil, fmt.Errorf("scan link: %w", err)
+		}
+		links = append(links, l)
+	}
+	return links, rows.Err()
+}
+
+// ReplaceLinkURL points a link at a new address, such as the page's new
+// home or an archived copy. The health record, redirect and archived
+// copies of the old URL are cleared, and the offline copy deleted; the link
+// should be dredged again afterwards.
+func ReplaceLinkURL(db *sql.DB, id int64, newURL string) error {
+	var archivePath string
+	err := db.QueryRow(`SELECT archive_path FROM links WHERE id=?`, id).Scan(&archivePath)
+	if err != nil && err != sql.ErrNoRows {
+		return fmt.Errorf("replace link url: %w", err)
+	}
+	_, err = db.Exec(
+		`UPDATE links SET url=?, resolved_url='', redirect_chain='', archive_url='', archive_path='', archived_at='', reader_position=0, progress=0,
+		 check_status=0, check_error='', checked_at='', check_failures=0, dead=0 WHERE id=?`,
+		newURL, id,
+	)
+	if err != nil {
+		return fmt.Errorf("replace link url: %w", err)
+	}
+	if archivePath != "" {
+		_ = os.RemoveAll(filepath.Dir(archivePath))
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package db
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/alexzajac/the-dredger/internal/model"
+)
+
+func TestLinkChecks(t *testing.T) {
+	db := setupTestDB(t)
+	fresh, _ := InsertLink(db, model.Link{URL: "https://example.com/fresh", Status: model.Saved})
+	stale, _ := InsertLink(db, model.Link{URL: "https://example.com/stale", Status: model.Saved})
+	never, _ := InsertLink(db, model.Link{URL: "https://example.com/never", Status: model.Saved})
+	_, _ = InsertLink(db, model.Link{URL: "https://example.com/pending"})
+
+	now := time.Now()
+	if err := UpdateLinkCheck(db, model.Link{ID: fresh, CheckStatus: 200, CheckedAt: now.Add(-time.Hour)}); err != nil {
+		t.Fatalf("update check: %v", err)
+	}
+	gone := model.Link{ID: stale, CheckStatus: 404, CheckedAt: now.Add(-30 * 24 * time.Hour), CheckFailures: 1, Dead: true}
+	if err := UpdateLinkCheck(db, gone); err != nil {
+		t.Fatalf("update check: %v", err)
+	}
+
+	due, err := GetLinksDueCheck(db, now.Add(-7*24*time.Hour))
+	if err != nil {
+		t.Fatalf("links due check: %v", err)
+	}
+	if len(due) != 2 || due[0].ID != never || due[1].ID != stale {
+		t.Fatalf("expected the unchecked then the stale link to be due, got %+v", due)
+	}
+	if got := due[1]; got.CheckStatus != 404 || got.CheckFailures != 1 || !got.Dead || got.CheckedAt.Unix() != gone.CheckedAt.Unix() {
+		t.Errorf("check not round-tripped: %+v", got)
+	}
+
+	dead, err := GetDeadLinks(db)
+	if err != nil {
+		t.Fatalf("dead links: %v", err)
+	}
+	if len(dead) != 1 || dead[0].ID != stale {
+		t.Fatalf("expected only the 404 link to be dead, got %+v", dead)
+	}
+
+	if err := ReplaceLinkURL(db, stale, "https://example.org/moved"); err != nil {
+		t.Fatalf("replace url: %v", err)
+	}
+	dead, _ = GetDeadLinks(db)
+	if len(dead) != 0 {
+		t.Errorf("replaced link still dead: %+v", dead)
+	}
+	links, _ := GetLinksByStatus(db, model.Saved)
+	for _, l := range links {
+		if l.ID == stale && (l.URL != "https://example.org/moved" || !l.CheckedAt.IsZero() || l.CheckFailures != 0) {
+			t.Errorf("replace did not reset the link: %+v", l)
+		}
+	}
+}
+
+func TestReplaceLinkURLDeletesOfflineCopy(t *testing.T) {
+	db := setupTestDB(t)
+	id, _ := InsertLink(db, model.Link{URL: "https://example.com/old", Status: model.Saved})
+	path := filepath.Join(t.TempDir(), strconv.FormatInt(id, 10), "index.html")
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("<p>old</p>"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_ = UpdateLinkArchive(db, id, path, time.Now())
+
+	if err := ReplaceLinkURL(db, id, "https://example.com/new"); err != nil {
+		t.Fatalf("replace url: %v", err)
+	}
+	if _, err := os.Stat(filepath.Dir(path)); !os.IsNotExist(err) {
+		t.Errorf("expected the old offline copy deleted, got %v", err)
+	}
+}
@@ -0,0 +1,315 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/alexzajac/the-dredger/internal/model"
+)
+
+// JobLease is how long a claimed job belongs to the process that claimed
+// it. The process renews the lease while it works on the job; a job whose
+// lease has run out was left behind by a process that died.
+const JobLease = time.Minute
+
+// leaseModifier is the SQLite datetime modifier for JobLease from now.
+var leaseModifier = fmt.Sprintf("+%d seconds", int(JobLease/time.Second))
+
+// EnqueueDredgeJobs queues the given links for dredging and marks them
+// Queued. A link that already has a job is re-queued to run now unless it
+// is currently running. It returns how many links were queued.
+func EnqueueDredgeJobs(db *sql.DB, linkIDs []int64) (int, error) {
+	return enqueueJobs(db, linkIDs, model.JobFull)
+}
+
+func enqueueJobs(db *sql.DB, linkIDs []int64, mode model.JobMode) (int, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	queued := 0
+	for _, id := range linkIDs {
+		res, err := tx.Exec(
+			`INSERT INTO dredge_jobs (link_id, state, mode) VALUES (?, ?, ?)
+			 ON CONFLICT(link_id) DO UPDATE SET
+				state = excluded.state, mode = excluded.mode, attempts = 0, last_error = '',
+				next_run_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+			 WHERE dredge_jobs.state != ?`,
+			id, int(model.JobQueued), int(mode), int(model.JobRunning),
+		)
+		if err != nil {
+			return 0, fmt.Errorf("enqueue link %d: %w", id, err)
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			continue
+		}
+		if _, err := tx.Exec(`UPDATE links SET dredge_state=?, dredge_error='', dredge_error_class=0 WHERE id=?`, int(model.DredgeQueued), id); err != nil {
+			return 0, fmt.Errorf("mark link %d queued: %w", id, err)
+		}
+		queued++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("commit transaction: %w", err)
+	}
+	return queued, nil
+}
+
+// ClaimDredgeJob marks the oldest due job as running under a fresh lease
+// and returns it, or nil when nothing is due. Jobs for pruned links are
+// skipped.
+func ClaimDredgeJob(db *sql.DB) (*model.DredgeJob, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var job model.DredgeJob
+	var tags, nextRun, added string
+	var status, state, mode int
+	err = tx.QueryRow(
+		`SELECT j.id, j.link_id, l.url, l.tags, l.status, l.date_added, j.state, j.mode, j.attempts, j.next_run_at, j.last_error
+		 FROM dredge_jobs j JOIN links l ON l.id = j.link_id
+		 WHERE j.state = ? AND j.next_run_at <= datetime('now') AND l.status != ?
+		 ORDER BY j.next_run_at ASC, j.id ASC LIMIT 1`,
+		int(model.JobQueued), int(model.Pruned),
+	).Scan(&job.ID, &job.LinkID, &job.URL, &tags, &status, &added, &state, &mode, &job.Attempts, &nextRun, &job.LastError)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("select dredge job: %w", err)
+	}
+
+	if _, err := tx.Exec(
+		`UPDATE dredge_jobs SET state=?, attempts=attempts+1, lease_until=datetime('now', ?), updated_at=CURRENT_TIMESTAMP WHERE id=?`,
+		int(model.JobRunning), leaseModifier, job.ID,
+	); err != nil {
+		return nil, fmt.Errorf("claim dredge job: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("commit transaction: %w", err)
+	}
+
+	job.State = model.JobRunning
+	job.Mode = model.JobMode(mode)
+	job.Attempts++
+	job.NextRunAt = parseDateStr(nextRun)
+	job.Status = model.Status(status)
+	job.DateAdded = parseDateStr(added)
+	if tags != "" {
+		job.Tags = strings.Split(tags, ",")
+	}
+	return &job, nil
+}
+
+// RenewDredgeJobLease extends the lease on a running job by JobLease from
+// now.
+func RenewDredgeJobLease(db *sql.DB, id int64) error {
+	_, err := db.Exec(`UPDATE dredge_jobs SET lease_until=datetime('now', ?) WHERE id=? AND state=?`,
+		leaseModifier, id, int(model.JobRunning))
+	if err != nil {
+		return fmt.Errorf("renew dredge job lease: %w", err)
+	}
+	return nil
+}
+
+// CompleteDredgeJob marks a job as done.
+func CompleteDredgeJob(db *sql.DB, id int64) error {
+	_, err := db.Exec(
+		`UPDATE dredge_jobs SET state=?, last_error='', updated_at=CURRENT_TIMESTAMP WHERE id=?`,
+		int(model.JobDone), id,
+	)
+	if err != nil {
+		return fmt.Errorf("complete dredge job: %w", err)
+	}
+	return nil
+}
+
+// FailDredgeJob marks a job as failed with the given error.
+func FailDredgeJob(db *sql.DB, id int64, jobErr string) error {
+	_, err := db.Exec(
+		`UPDATE dredge_jobs SET state=?, last_error=?, updated_at=CURRENT_TIMESTAMP WHERE id=?`,
+		int(model.JobFailed), jobErr, id,
+	)
+	if err != nil {
+		return fmt.Errorf("fail dredge job: %w", err)
+	}
+	return nil
+}
+
+// RetryDredgeJob puts a failed job back in the queue to run again at
+// next, keeping its attempt count.
+func RetryDredgeJob(db *sql.DB, id int64, jobErr string, next time.Time) error {
+	_, err := db.Exec(
+		`UPDATE dredge_jobs SET state=?, last_error=?, next_run_at=?, updated_at=CURRENT_TIMESTAMP WHERE id=?`,
+		int(model.JobQueued), jobErr, next.UTC().Format("2006-01-02 15:04:05"), id,
+	)
+	if err != nil {
+		return fmt.Errorf("retry dredge job: %w", err)
+	}
+	return nil
+}
+
+// RequeueDredgeJob puts an interrupted job back in the queue without
+// counting the attempt, and marks its link Queued again.
+func RequeueDredgeJob(db *sql.DB, job model.DredgeJob) error {
+	_, err := db.Exec(
+		`UPDATE dredge_jobs SET state=?, attempts=MAX(attempts-1, 0), updated_at=CURRENT_TIMESTAMP WHERE id=?`,
+		int(model.JobQueued), job.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("requeue dredge job: %w", err)
+	}
+	return UpdateDredgeState(db, job.LinkID, model.DredgeQueued, "")
+}
+
+// ReclaimDredgeJobs recovers from a process that exited mid-dredge:
+// running jobs whose lease has run out go back to the queue, and links
+// left in Crawling or Crunching without a live job are queued. Jobs that
+// another process is still working on are left alone. It returns the
+// number of links reclaimed.
+func ReclaimDredgeJobs(db *sql.DB) (int, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	const live = `SELECT link_id FROM dredge_jobs WHERE state = ? AND lease_until >= datetime('now')`
+	const expired = `SELECT link_id FROM dredge_jobs WHERE state = ? AND lease_until < datetime('now')`
+
+	var reclaimed int
+	err = tx.QueryRow(
+		`SELECT COUNT(*) FROM links
+		 WHERE (dredge_state IN (?, ?) AND id NOT IN (`+live+`))
+		 OR id IN (`+expired+`)`,
+		int(model.DredgeCrawling), int(model.DredgeCrunching), int(model.JobRunning), int(model.JobRunning),
+	).Scan(&reclaimed)
+	if err != nil {
+		return 0, fmt.Errorf("count stale jobs: %w", err)
+	}
+
+	if _, err := tx.Exec(
+		`UPDATE dredge_jobs SET state=?, lease_until='', updated_at=CURRENT_TIMESTAMP
+		 WHERE state=? AND lease_until < datetime('now')`,
+		int(model.JobQueued), int(model.JobRunning),
+	); err != nil {
+		return 0, fmt.Errorf("reclaim running jobs: %w", err)
+	}
+
+	if _, err := tx.Exec(
+		`INSERT INTO dredge_jobs (link_id, state)
+		 SELECT id, ? FROM links WHERE dredge_state IN (?, ?) AND id NOT IN (`+live+`)
+		 ON CONFLICT(link_id) DO UPDATE SET state = excluded.state, updated_at = CURRENT_TIMESTAMP`,
+		int(model.JobQueued), int(model.DredgeCrawling), int(model.DredgeCrunching), int(model.JobRunning),
+	); err != nil {
+		return 0, fmt.Errorf("reclaim stuck links: %w", err)
+	}
+
+	if _, err := tx.Exec(
+		`UPDATE links SET dredge_state=? WHERE dredge_state IN (?, ?) AND id IN (SELECT link_id FROM dredge_jobs WHERE state=?)`,
+		int(model.DredgeQueued), int(model.DredgeCrawling), int(model.DredgeCrunching), int(model.JobQueued),
+	); err != nil {
+		return 0, fmt.Errorf("mark reclaimed links queued: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("commit transaction: %w", err)
+	}
+	return reclaimed, nil
+}
+
+// CountDueDredgeJobs returns how many queued jobs are ready to run.
+func CountDueDredgeJobs(db *sql.DB) (int, error) {
+	var n int
+	err := db.QueryRow(
+		`SELECT COUNT(*) FROM dredge_jobs j JOIN links l ON l.id = j.link_id
+		 WHERE j.state = ? AND j.next_run_at <= datetime('now') AND l.status != ?`,
+		int(model.JobQueued), int(model.Pruned),
+	).Scan(&n)
+	if err != nil {
+		return 0, fmt.Errorf("count dredge jobs: %w", err)
+	}
+	return n, nil
+}
+
+// NextDredgeJobAt returns when the earliest queued job becomes due, and
+// false when the queue is empty.
+func NextDredgeJobAt(db *sql.DB) (time.Time, bool, error) {
+	var next sql.NullString
+	err := db.QueryRow(
+		`SELECT MIN(j.next_run_at) FROM dredge_jobs j JOIN links l ON l.id = j.link_id
+		 WHERE j.state = ? AND l.status != ?`,
+		int(model.JobQueued), int(model.Pruned),
+	).Scan(&next)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("query next dredge job: %w", err)
+	}
+	if !next.Valid {
+		return time.Time{}, false, nil
+	}
+	return parseDateStr(next.String), true, nil
+}
+
+// EnqueueUndredged queues every link that has never been dredged, oldest
+// first. Links whose earlier job failed are left alone.
+func EnqueueUndredged(db *sql.DB) (int, error) {
+	return enqueueWhere(db, model.JobFull,
+		`SELECT id FROM links
+		 WHERE enriched = 0 AND status != ? AND id NOT IN (SELECT link_id FROM dredge_jobs)
+		 ORDER BY date_added ASC`,
+		int(model.Pruned),
+	)
+}
+
+// EnqueueRecrunch queues every link with a cached page to be re-crunched
+// offline from the cache. Pages are matched to the links they were fetched
+// for, so links that redirect or are described through an API are
+// included.
+func EnqueueRecrunch(db *sql.DB) (int, error) {
+	return enqueueWhere(db, model.JobRecrunch,
+		`SELECT id FROM links
+		 WHERE status != ? AND id IN (SELECT link_id FROM page_cache_links)
+		 ORDER BY date_added ASC`,
+		int(model.Pruned),
+	)
+}
+
+// enqueueWhere queues the links whose IDs query selects.
+func enqueueWhere(db *sql.DB, mode model.JobMode, query string, args ...any) (int, error) {
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return 0, fmt.Errorf("query links to dredge: %w", err)
+	}
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			_ = rows.Close()
+			return 0, fmt.Errorf("scan link id: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	_ = rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+	return enqueueJobs(db, ids, mode)
+}
+
+// EnqueueCapsized queues every capsized link again, including ones whose
+// jobs ran out of retries, with a fresh attempt count.
+func EnqueueCapsized(db *sql.DB) (int, error) {
+	return enqueueWhere(db, model.JobFull,
+		`SELECT id FROM links
+		 WHERE status != ? AND (dredge_state = ? OR id IN (SELECT link_id FROM dredge_jobs WHERE state = ?))
+		 ORDER BY date_added ASC`,
+		int(model.Pruned), int(model.DredgeCapsized), int(model.JobFailed),
+	)
+}
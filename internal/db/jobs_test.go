@@ -0,0 +1,261 @@
+package db
+
+import (
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/alexzajac/the-dredger/internal/model"
+)
+
+func dredgeStateOf(t *testing.T, db *sql.DB, id int64) model.DredgeState {
+	t.Helper()
+	var state int
+	if err := db.QueryRow(`SELECT dredge_state FROM links WHERE id=?`, id).Scan(&state); err != nil {
+		t.Fatalf("read dredge state: %v", err)
+	}
+	return model.DredgeState(state)
+}
+
+func TestDredgeJobLifecycle(t *testing.T) {
+	db := setupTestDB(t)
+
+	first, _ := InsertLink(db, model.Link{URL: "https://example.com/1", Tags: []string{"go"}})
+	second, _ := InsertLink(db, model.Link{URL: "https://example.com/2"})
+
+	n, err := EnqueueDredgeJobs(db, []int64{first, second})
+	if err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	if n != 2 {
+		t.Fatalf("expected 2 queued, got %d", n)
+	}
+	if s := dredgeStateOf(t, db, first); s != model.DredgeQueued {
+		t.Errorf("expected link marked Queued, got %v", s)
+	}
+
+	job, err := ClaimDredgeJob(db)
+	if err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+	if job == nil || job.LinkID != first || job.URL != "https://example.com/1" {
+		t.Fatalf("expected first link's job, got %+v", job)
+	}
+	if job.State != model.JobRunning || job.Attempts != 1 {
+		t.Errorf("expected running job on attempt 1, got %+v", job)
+	}
+	if len(job.Tags) != 1 || job.Tags[0] != "go" {
+		t.Errorf("expected link tags on job, got %v", job.Tags)
+	}
+
+	// A running job is not re-queued underneath its worker.
+	if n, _ := EnqueueDredgeJobs(db, []int64{first}); n != 0 {
+		t.Errorf("expected running job to be left alone, got %d queued", n)
+	}
+
+	if err := CompleteDredgeJob(db, job.ID); err != nil {
+		t.Fatalf("complete: %v", err)
+	}
+
+	job, _ = ClaimDredgeJob(db)
+	if job == nil || job.LinkID != second {
+		t.Fatalf("expected second link's job, got %+v", job)
+	}
+	if err := FailDredgeJob(db, job.ID, "crawl: 404"); err != nil {
+		t.Fatalf("fail: %v", err)
+	}
+
+	if job, _ := ClaimDredgeJob(db); job != nil {
+		t.Fatalf("expected empty queue, got %+v", job)
+	}
+
+	// Finished jobs can be queued again, e.g. for a manual re-dredge.
+	if n, _ := EnqueueDredgeJobs(db, []int64{first, second}); n != 2 {
+		t.Errorf("expected finished jobs to be re-queued, got %d", n)
+	}
+	if due, _ := CountDueDredgeJobs(db); due != 2 {
+		t.Errorf("expected 2 due jobs, got %d", due)
+	}
+}
+
+func TestClaimDredgeJobSkipsPruned(t *testing.T) {
+	db := setupTestDB(t)
+
+	id, _ := InsertLink(db, model.Link{URL: "https://example.com/pruned"})
+	if _, err := EnqueueDredgeJobs(db, []int64{id}); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	if err := UpdateLink(db, model.Link{ID: id, Status: model.Pruned}); err != nil {
+		t.Fatalf("prune: %v", err)
+	}
+
+	job, err := ClaimDredgeJob(db)
+	if err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+	if job != nil {
+		t.Errorf("expected pruned link to be skipped, got %+v", job)
+	}
+}
+
+func TestReclaimDredgeJobs(t *testing.T) {
+	db := setupTestDB(t)
+
+	running, _ := InsertLink(db, model.Link{URL: "https://example.com/running"})
+	stuck, _ := InsertLink(db, model.Link{URL: "https://example.com/stuck"})
+	live, _ := InsertLink(db, model.Link{URL: "https://example.com/live"})
+
+	if _, err := EnqueueDredgeJobs(db, []int64{running}); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	job, _ := ClaimDredgeJob(db)
+	if job == nil {
+		t.Fatal("expected a job to claim")
+	}
+	_ = UpdateDredgeState(db, running, model.DredgeCrunching, "")
+	// The process working on it died and its lease ran out.
+	if _, err := db.Exec(`UPDATE dredge_jobs SET lease_until = datetime('now', '-1 seconds') WHERE id = ?`, job.ID); err != nil {
+		t.Fatal(err)
+	}
+	// A link left mid-crawl by a version without the job queue.
+	_ = UpdateDredgeState(db, stuck, model.DredgeCrawling, "")
+
+	// Another process is still working on this one.
+	_, _ = EnqueueDredgeJobs(db, []int64{live})
+	liveJob, _ := ClaimDredgeJob(db)
+	_ = UpdateDredgeState(db, live, model.DredgeCrawling, "")
+	if err := RenewDredgeJobLease(db, liveJob.ID); err != nil {
+		t.Fatalf("renew lease: %v", err)
+	}
+
+	n, err := ReclaimDredgeJobs(db)
+	if err != nil {
+		t.Fatalf("reclaim: %v", err)
+	}
+	if n != 2 {
+		t.Errorf("expected 2 reclaimed, got %d", n)
+	}
+	for _, id := range []int64{running, stuck} {
+		if s := dredgeStateOf(t, db, id); s != model.DredgeQueued {
+			t.Errorf("link %d: expected Queued, got %v", id, s)
+		}
+	}
+	if due, _ := CountDueDredgeJobs(db); due != 2 {
+		t.Errorf("expected 2 due jobs after reclaim, got %d", due)
+	}
+	if s := dredgeStateOf(t, db, live); s != model.DredgeCrawling {
+		t.Errorf("a job with a live lease should be left alone, got %v", s)
+	}
+}
+
+func TestEnqueueUndredged(t *testing.T) {
+	db := setupTestDB(t)
+
+	fresh, _ := InsertLink(db, model.Link{URL: "https://example.com/fresh"})
+	done, _ := InsertLink(db, model.Link{URL: "https://example.com/done"})
+	pruned, _ := InsertLink(db, model.Link{URL: "https://example.com/pruned"})
+	_ = UpdateDredgeResult(db, model.Link{ID: done, Title: "Done"})
+	_ = UpdateLink(db, model.Link{ID: pruned, Status: model.Pruned})
+
+	n, err := EnqueueUndredged(db)
+	if err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	if n != 1 {
+		t.Fatalf("expected only the fresh link queued, got %d", n)
+	}
+	job, _ := ClaimDredgeJob(db)
+	if job == nil || job.LinkID != fresh {
+		t.Fatalf("expected fresh link's job, got %+v", job)
+	}
+	_ = FailDredgeJob(db, job.ID, "boom")
+
+	// A failed link is not picked up again automatically.
+	if n, _ := EnqueueUndredged(db); n != 0 {
+		t.Errorf("expected failed link to stay out of the queue, got %d", n)
+	}
+}
+
+func TestRetryDredgeJob(t *testing.T) {
+	db := setupTestDB(t)
+
+	id, _ := InsertLink(db, model.Link{URL: "https://example.com/flaky"})
+	if _, err := EnqueueDredgeJobs(db, []int64{id}); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	job, _ := ClaimDredgeJob(db)
+	if job == nil {
+		t.Fatal("expected a job to claim")
+	}
+
+	next := time.Now().Add(time.Hour)
+	if err := RetryDredgeJob(db, job.ID, "503", next); err != nil {
+		t.Fatalf("retry: %v", err)
+	}
+	if job, _ := ClaimDredgeJob(db); job != nil {
+		t.Fatalf("expected job to wait for its backoff, got %+v", job)
+	}
+	at, ok, err := NextDredgeJobAt(db)
+	if err != nil || !ok {
+		t.Fatalf("next: %v %v", ok, err)
+	}
+	if d := at.Sub(next); d < -time.Second || d > time.Second {
+		t.Errorf("expected next run at %s, got %s", next, at)
+	}
+
+	// A due retry keeps counting attempts.
+	_ = RetryDredgeJob(db, job.ID, "503", time.Now().Add(-time.Second))
+	job, _ = ClaimDredgeJob(db)
+	if job == nil || job.Attempts != 2 || job.LastError != "503" {
+		t.Fatalf("expected second attempt, got %+v", job)
+	}
+}
+
+func TestEnqueueCapsized(t *testing.T) {
+	db := setupTestDB(t)
+
+	failed, _ := InsertLink(db, model.Link{URL: "https://example.com/failed"})
+	legacy, _ := InsertLink(db, model.Link{URL: "https://example.com/legacy"})
+	_, _ = InsertLink(db, model.Link{URL: "https://example.com/fine"})
+
+	_, _ = EnqueueDredgeJobs(db, []int64{failed})
+	job, _ := ClaimDredgeJob(db)
+	_ = FailDredgeJob(db, job.ID, "404")
+	_ = UpdateDredgeFailure(db, failed, model.DredgeCapsized, model.ErrorPermanent, "404")
+	// Capsized before the job queue existed.
+	_ = UpdateDredgeState(db, legacy, model.DredgeCapsized, "timeout")
+
+	n, err := EnqueueCapsized(db)
+	if err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	if n != 2 {
+		t.Fatalf("expected 2 capsized links queued, got %d", n)
+	}
+	if s := dredgeStateOf(t, db, failed); s != model.DredgeQueued {
+		t.Errorf("expected Queued, got %v", s)
+	}
+	job, _ = ClaimDredgeJob(db)
+	if job == nil || job.LinkID != failed || job.Attempts != 1 {
+		t.Errorf("expected failed link with a fresh attempt count, got %+v", job)
+	}
+}
+
+func TestUpdateDredgeFailureClass(t *testing.T) {
+	db := setupTestDB(t)
+
+	id, _ := InsertLink(db, model.Link{URL: "https://example.com/gone"})
+	if err := UpdateDredgeFailure(db, id, model.DredgeCapsized, model.ErrorPermanent, "410 Gone"); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	links, _ := GetLinks(db)
+	if links[0].DredgeErrorClass != model.ErrorPermanent || links[0].DredgeError != "410 Gone" {
+		t.Errorf("unexpected link %+v", links[0])
+	}
+
+	_ = UpdateDredgeState(db, id, model.DredgeCrawling, "")
+	links, _ = GetLinks(db)
+	if links[0].DredgeErrorClass != model.ErrorNone {
+		t.Errorf("expected class cleared, got %v", links[0].DredgeErrorClass)
+	}
+}
@@ -2,6 +2,7 @@ package db
 
 import (
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"strings"
 	"time"
@@ -21,27 +22,74 @@ func InsertLink(db *sql.DB, link model.Link) (int64, error) {
 	return res.LastInsertId()
 }
 
-const linkSelectCols = `id, url, title, description, tags, status, enriched, date_added, dredge_state, dredge_error, summary`
+const linkSelectCols = `id, url, title, description, tags, status, enriched, date_added, dredge_state, dredge_error, summary,
+	canonical_url, site_name, author, published_at, modified_at, image_url, page_type, lang, content_type, dredge_error_class, details, media_duration,
+	resolved_url, redirect_chain, check_status, check_error, checked_at, check_failures, dead, archive_url, archive_path, archived_at,
+	read_at, reader_position, read_count, progress, word_count, reading_time`
 
 func scanLink(scanner interface{ Scan(...any) error }) (model.Link, error) {
 	var l model.Link
-	var tags, dateStr, dredgeError, summary string
-	var status, enriched, dredgeState int
-	if err := scanner.Scan(&l.ID, &l.URL, &l.Title, &l.Description, &tags, &status, &enriched, &dateStr, &dredgeState, &dredgeError, &summary); err != nil {
+	var tags, dateStr, dredgeError, summary, published, modified, details, chain, checked, archived, read string
+	var status, enriched, dredgeState, errorClass, mediaSecs, dead, readingSecs int
+	if err := scanner.Scan(&l.ID, &l.URL, &l.Title, &l.Description, &tags, &status, &enriched, &dateStr, &dredgeState, &dredgeError, &summary,
+		&l.CanonicalURL, &l.SiteName, &l.Author, &published, &modified, &l.ImageURL, &l.PageType, &l.Language, &l.ContentType, &errorClass, &details, &mediaSecs,
+		&l.ResolvedURL, &chain, &l.CheckStatus, &l.CheckError, &checked, &l.CheckFailures, &dead, &l.ArchiveURL, &l.ArchivePath, &archived,
+		&read, &l.ReaderPosition, &l.ReadCount, &l.Progress, &l.WordCount, &readingSecs); err != nil {
 		return l, err
 	}
+	l.PublishedAt = parseOptionalTime(published)
+	l.ModifiedAt = parseOptionalTime(modified)
 	l.Status = model.Status(status)
 	l.Enriched = enriched != 0
 	l.DredgeState = model.DredgeState(dredgeState)
 	l.DredgeError = dredgeError
+	l.DredgeErrorClass = model.ErrorClass(errorClass)
 	l.Summary = summary
 	if tags != "" {
 		l.Tags = strings.Split(tags, ",")
 	}
 	l.DateAdded = parseDateStr(dateStr)
+	l.MediaDuration = time.Duration(mediaSecs) * time.Second
+	l.ReadingTime = time.Duration(readingSecs) * time.Second
+	l.CheckedAt = parseOptionalTime(checked)
+	l.Dead = dead != 0
+	l.ArchivedAt = parseOptionalTime(archived)
+	l.ReadAt = parseOptionalTime(read)
+	if chain != "" {
+		// URLs cannot contain newlines, so the chain is stored one per line.
+		l.RedirectChain = strings.Split(chain, "\n")
+	}
+	if details != "" {
+		_ = json.Unmarshal([]byte(details), &l.Details)
+	}
 	return l, nil
 }
 
+// formatDetails stores empty details as an empty string.
+func formatDetails(d model.Details) string {
+	if d.IsZero() {
+		return ""
+	}
+	data, err := json.Marshal(d)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// formatOptionalTime stores a zero time as an empty string.
+func formatOptionalTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+func parseOptionalTime(s string) time.Time {
+	t, _ := time.Parse(time.RFC3339, s)
+	return t
+}
+
 // parseDateStr tries multiple time formats and falls back to time.Now().
 func parseDateStr(s string) time.Time {
 	formats := []string{
@@ -112,10 +160,10 @@ func UpdateLink(db *sql.DB, link model.Link) error {
 func RestoreLink(db *sql.DB, link model.Link) error {
 	tags := strings.Join(link.Tags, ",")
 	_, err := db.Exec(
-		`UPDATE links SET url=?, title=?, description=?, tags=?, status=?, date_added=?, dredge_state=?, dredge_error=?, summary=? WHERE id=?`,
+		`UPDATE links SET url=?, title=?, description=?, tags=?, status=?, date_added=?, dredge_state=?, dredge_error=?, dredge_error_class=?, summary=? WHERE id=?`,
 		link.URL, link.Title, link.Description, tags, int(link.Status),
 		link.DateAdded.Format("2006-01-02 15:04:05"),
-		int(link.DredgeState), link.DredgeError, link.Summary, link.ID,
+		int(link.DredgeState), link.DredgeError, int(link.DredgeErrorClass), link.Summary, link.ID,
 	)
 	if err != nil {
 		return fmt.Errorf("restore link: %w", err)
@@ -149,8 +197,27 @@ func GetUnprocessedLinks(db *sql.DB) ([]model.Link, error) {
 	return links, rows.Err()
 }
 
-func UpdateLinkMeta(db *sql.DB, id int64, title, description string) error {
-	_, err := db.Exec(`UPDATE links SET title=?, description=?, enriched=1 WHERE id=?`, title, description, id)
+// pageMetaCols are the crawled metadata columns written by UpdateLinkMeta
+// and UpdateDredgeResult, in the order of pageMetaArgs.
+const pageMetaCols = `title=?, description=?, canonical_url=?, site_name=?, author=?,
+	published_at=?, modified_at=?, image_url=?, page_type=?, lang=?, content_type=?, details=?, media_duration=?,
+	resolved_url=?, redirect_chain=?`
+
+func pageMetaArgs(link model.Link) []any {
+	return []any{
+		link.Title, link.Description, link.CanonicalURL, link.SiteName, link.Author,
+		formatOptionalTime(link.PublishedAt), formatOptionalTime(link.ModifiedAt),
+		link.ImageURL, link.PageType, link.Language, link.ContentType,
+		formatDetails(link.Details), int(link.MediaDuration / time.Second),
+		link.ResolvedURL, strings.Join(link.RedirectChain, "\n"),
+	}
+}
+
+// UpdateLinkMeta stores crawled page metadata without touching the
+// summary or tags.
+func UpdateLinkMeta(db *sql.DB, link model.Link) error {
+	args := append(pageMetaArgs(link), link.ID)
+	_, err := db.Exec(`UPDATE links SET `+pageMetaCols+`, enriched=1 WHERE id=?`, args...)
 	if err != nil {
 		return fmt.Errorf("update link meta: %w", err)
 	}
@@ -181,6 +248,27 @@ func GetNextSaved(db *sql.DB) (*model.Link, error) {
 	return GetNextSavedExcluding(db, 0)
 }
 
+// GetNextUnread returns the newest saved link not yet read, or nil.
+func GetNextUnread(db *sql.DB) (*model.Link, error) {
+	return GetNextUnreadExcluding(db, 0)
+}
+
+func GetNextUnreadExcluding(db *sql.DB, excludeID int64) (*model.Link, error) {
+	row := db.QueryRow(
+		`SELECT `+linkSelectCols+`
+		 FROM links WHERE status = 1 AND read_at = '' AND id != ?
+		 ORDER BY date_added DESC LIMIT 1`, excludeID,
+	)
+	l, err := scanLink(row)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("get next unread: %w", err)
+	}
+	return &l, nil
+}
+
 func GetNextSavedExcluding(db *sql.DB, excludeID int64) (*model.Link, error) {
 	row := db.QueryRow(
 		`SELECT `+linkSelectCols+`
@@ -206,12 +294,18 @@ func ResetLinkStatus(db *sql.DB, id int64, originalDateAdded time.Time) error {
 	return nil
 }
 
-// UpdateDredgeState sets the dredge state and optional error for a link.
+// UpdateDredgeState sets the dredge state and optional error for a link,
+// clearing any error class.
 // It re-checks the link status before writing to avoid overwriting a pruned link.
 func UpdateDredgeState(db *sql.DB, id int64, state model.DredgeState, dredgeErr string) error {
+	return UpdateDredgeFailure(db, id, state, model.ErrorNone, dredgeErr)
+}
+
+// UpdateDredgeFailure is UpdateDredgeState for a classified error.
+func UpdateDredgeFailure(db *sql.DB, id int64, state model.DredgeState, class model.ErrorClass, dredgeErr string) error {
 	_, err := db.Exec(
-		`UPDATE links SET dredge_state=?, dredge_error=? WHERE id=? AND status != ?`,
-		int(state), dredgeErr, id, int(model.Pruned),
+		`UPDATE links SET dredge_state=?, dredge_error=?, dredge_error_class=? WHERE id=? AND status != ?`,
+		int(state), dredgeErr, int(class), id, int(model.Pruned),
 	)
 	if err != nil {
 		return fmt.Errorf("update dredge state: %w", err)
@@ -219,13 +313,14 @@ func UpdateDredgeState(db *sql.DB, id int64, state model.DredgeState, dredgeErr
 	return nil
 }
 
-// UpdateDredgeResult sets the dredge state to complete and stores the fetched metadata.
+// UpdateDredgeResult sets the dredge state to complete and stores the fetched
+// metadata, summary and tags from link.
 // Skips if the link has been pruned (race condition guard).
-func UpdateDredgeResult(db *sql.DB, id int64, title, description, summary string, tags []string) error {
-	tagStr := strings.Join(tags, ",")
+func UpdateDredgeResult(db *sql.DB, link model.Link) error {
+	args := append(pageMetaArgs(link), link.Summary, strings.Join(link.Tags, ","), int(model.DredgeComplete), link.ID, int(model.Pruned))
 	_, err := db.Exec(
-		`UPDATE links SET title=?, description=?, summary=?, tags=?, enriched=1, dredge_state=? WHERE id=? AND status != ?`,
-		title, description, summary, tagStr, int(model.DredgeComplete), id, int(model.Pruned),
+		`UPDATE links SET `+pageMetaCols+`, summary=?, tags=?, enriched=1, dredge_state=? WHERE id=? AND status != ?`,
+		args...,
 	)
 	if err != nil {
 		return fmt.Errorf("update dredge result: %w", err)
@@ -239,10 +334,12 @@ type LinkStats struct {
 	Saved       int
 	Pruned      int
 	Total       int
+	// SavedRead counts the saved links read at least once.
+	SavedRead int
 }
 
 func CountLinksByStatus(db *sql.DB) (LinkStats, error) {
-	rows, err := db.Query(`SELECT status, COUNT(*) FROM links GROUP BY status`)
+	rows, err := db.Query(`SELECT status, COUNT(*), SUM(read_at != '') FROM links GROUP BY status`)
 	if err != nil {
 		return LinkStats{}, fmt.Errorf("count links by status: %w", err)
 	}
@@ -250,8 +347,8 @@ func CountLinksByStatus(db *sql.DB) (LinkStats, error) {
 
 	var stats LinkStats
 	for rows.Next() {
-		var status, count int
-		if err := rows.Scan(&status, &count); err != nil {
+		var status, count, read int
+		if err := rows.Scan(&status, &count, &read); err != nil {
 			return LinkStats{}, fmt.Errorf("scan link count: %w", err)
 		}
 		switch model.Status(status) {
@@ -259,6 +356,7 @@ func CountLinksByStatus(db *sql.DB) (LinkStats, error) {
 			stats.Unprocessed = count
 		case model.Saved:
 			stats.Saved = count
+			stats.SavedRead = read
 		case model.Pruned:
 			stats.Pruned = count
 		}
@@ -305,10 +403,14 @@ func DeletePrunedLinks(db *sql.DB) (int64, error) {
 	return res.RowsAffected()
 }
 
+// DeleteAllLinks deletes every link, and with them the whole page cache.
 func DeleteAllLinks(db *sql.DB) (int64, error) {
 	res, err := db.Exec(`DELETE FROM links`)
 	if err != nil {
 		return 0, fmt.Errorf("delete all links: %w", err)
 	}
+	if _, err := db.Exec(`DELETE FROM page_cache`); err != nil {
+		return 0, fmt.Errorf("clear page cache: %w", err)
+	}
 	return res.RowsAffected()
 }
@@ -0,0 +1,104 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/alexzajac/the-dredger/internal/model"
+)
+
+// UpdateReaderPosition records where the reader view was left in a link's
+// text and how far through it (0 to 1) the reader got. Progress only ever
+// grows.
+func UpdateReaderPosition(db *sql.DB, id int64, position, progress float64) error {
+	_, err := db.Exec(`UPDATE links SET reader_position=?, progress=MAX(progress, ?) WHERE id=?`,
+		clampFraction(position), clampFraction(progress), id)
+	if err != nil {
+		return fmt.Errorf("update reader position: %w", err)
+	}
+	return nil
+}
+
+// MarkRead records that a link was read at readAt, counting the read.
+func MarkRead(db *sql.DB, id int64, readAt time.Time) error {
+	_, err := db.Exec(`UPDATE links SET read_at=?, read_count=read_count+1, progress=1 WHERE id=?`,
+		formatOptionalTime(readAt.UTC()), id)
+	if err != nil {
+		return fmt.Errorf("mark read: %w", err)
+	}
+	return nil
+}
+
+// MarkUnread clears a link's read state so that it shows up as unread
+// again. Its read count is kept.
+func MarkUnread(db *sql.DB, id int64) error {
+	_, err := db.Exec(`UPDATE links SET read_at='', progress=0, reader_position=0 WHERE id=?`, id)
+	if err != nil {
+		return fmt.Errorf("mark unread: %w", err)
+	}
+	return nil
+}
+
+func clampFraction(f float64) float64 {
+	return min(max(f, 0), 1)
+}
+
+// BacklogLengths are the reading times ReadingBacklog groups links by.
+var BacklogLengths = []time.Duration{5 * time.Minute, 15 * time.Minute, 30 * time.Minute, time.Hour}
+
+// ReadingBacklog is what is left to read: the pending links and the saved
+// links that have not been read.
+type ReadingBacklog struct {
+	Pending BacklogTotal
+	Unread  BacklogTotal
+	// Lengths counts the backlog links with a reading time under each of
+	// BacklogLengths in turn, then those that take longer.
+	Lengths []int
+}
+
+// BacklogTotal sums the reading time of a group of links, less what has
+// been read of them already.
+type BacklogTotal struct {
+	Links       int
+	ReadingTime time.Duration
+	// Unmeasured counts the links whose text has not been fetched, so
+	// have no reading time.
+	Unmeasured int
+}
+
+// GetReadingBacklog totals the reading time of the links still to be read.
+func GetReadingBacklog(db *sql.DB) (ReadingBacklog, error) {
+	rows, err := db.Query(`SELECT status, reading_time, progress FROM links WHERE status IN (?, ?) AND read_at = ''`,
+		int(model.Unprocessed), int(model.Saved))
+	if err != nil {
+		return ReadingBacklog{}, fmt.Errorf("query reading backlog: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	backlog := ReadingBacklog{Lengths: make([]int, len(BacklogLengths)+1)}
+	for rows.Next() {
+		var status, secs int
+		var progress float64
+		if err := rows.Scan(&status, &secs, &progress); err != nil {
+			return ReadingBacklog{}, fmt.Errorf("scan reading backlog: %w", err)
+		}
+		total := &backlog.Unread
+		if model.Status(status) == model.Unprocessed {
+			total = &backlog.Pending
+		}
+		total.Links++
+		if secs == 0 {
+			total.Unmeasured++
+			continue
+		}
+		d := time.Duration(secs) * time.Second
+		total.ReadingTime += time.Duration(float64(d) * (1 - clampFraction(progress))).Truncate(time.Second)
+		i := 0
+		for i < len(BacklogLengths) && d >= BacklogLengths[i] {
+			i++
+		}
+		backlog.Lengths[i]++
+	}
+	return backlog, rows.Err()
+}
@@ -0,0 +1,102 @@
+package db
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alexzajac/the-dredger/internal/model"
+)
+
+func TestReading(t *testing.T) {
+	db := setupTestDB(t)
+	id, _ := InsertLink(db, model.Link{URL: "https://example.com/a", Status: model.Saved})
+	other, _ := InsertLink(db, model.Link{URL: "https://example.com/b", Status: model.Saved})
+
+	if err := UpdateReaderPosition(db, id, 0.4, 0.5); err != nil {
+		t.Fatalf("update position: %v", err)
+	}
+	if err := UpdateReaderPosition(db, id, 0.1, 0.2); err != nil {
+		t.Fatalf("update position: %v", err)
+	}
+	links, _ := GetLinks(db)
+	if l := findLink(links, id); l.ReaderPosition != 0.1 || l.Progress != 0.5 {
+		t.Fatalf("position %v, progress %v; want 0.1 and the furthest, 0.5", l.ReaderPosition, l.Progress)
+	}
+
+	first := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	_ = MarkRead(db, id, first)
+	if err := MarkRead(db, id, first.Add(time.Hour)); err != nil {
+		t.Fatalf("mark read: %v", err)
+	}
+	links, _ = GetLinks(db)
+	l := findLink(links, id)
+	if l.Unread() || !l.ReadAt.Equal(first.Add(time.Hour)) || l.ReadCount != 2 || l.Progress != 1 {
+		t.Fatalf("reads not recorded: %+v", l)
+	}
+
+	if next, _ := GetNextUnread(db); next == nil || next.ID != other {
+		t.Errorf("next unread = %+v, want the other link", next)
+	}
+	stats, _ := CountLinksByStatus(db)
+	if stats.Saved != 2 || stats.SavedRead != 1 {
+		t.Errorf("stats = %+v", stats)
+	}
+
+	if err := MarkUnread(db, id); err != nil {
+		t.Fatalf("mark unread: %v", err)
+	}
+	links, _ = GetLinks(db)
+	if l := findLink(links, id); !l.Unread() || l.ReadCount != 2 || l.Progress != 0 {
+		t.Errorf("unread link: %+v", l)
+	}
+
+	if err := ReplaceLinkURL(db, id, "https://example.com/c"); err != nil {
+		t.Fatalf("replace url: %v", err)
+	}
+	links, _ = GetLinks(db)
+	if l := findLink(links, id); l.ReaderPosition != 0 {
+		t.Errorf("a new URL should start the reader at the top, got %v", l.ReaderPosition)
+	}
+}
+
+func findLink(links []model.Link, id int64) model.Link {
+	for _, l := range links {
+		if l.ID == id {
+			return l
+		}
+	}
+	return model.Link{}
+}
+
+func TestReadingBacklog(t *testing.T) {
+	db := setupTestDB(t)
+	pending, _ := InsertLink(db, model.Link{URL: "https://example.com/pending"})
+	_, _ = InsertLink(db, model.Link{URL: "https://example.com/unfetched"})
+	half, _ := InsertLink(db, model.Link{URL: "https://example.com/half", Status: model.Saved})
+	read, _ := InsertLink(db, model.Link{URL: "https://example.com/read", Status: model.Saved})
+	pruned, _ := InsertLink(db, model.Link{URL: "https://example.com/pruned", Status: model.Pruned})
+
+	words := func(n int) string { return strings.Repeat("word ", n) }
+	_ = SaveLinkContent(db, pending, words(wordsPerMinute*3))
+	_ = SaveLinkContent(db, half, words(wordsPerMinute*40))
+	_ = SaveLinkContent(db, read, words(wordsPerMinute*10))
+	_ = SaveLinkContent(db, pruned, words(wordsPerMinute*10))
+	_ = UpdateReaderPosition(db, half, 0.5, 0.5)
+	_ = MarkRead(db, read, time.Now())
+
+	backlog, err := GetReadingBacklog(db)
+	if err != nil {
+		t.Fatalf("backlog: %v", err)
+	}
+	if want := (BacklogTotal{Links: 2, ReadingTime: 3 * time.Minute, Unmeasured: 1}); backlog.Pending != want {
+		t.Errorf("pending = %+v, want %+v", backlog.Pending, want)
+	}
+	if want := (BacklogTotal{Links: 1, ReadingTime: 20 * time.Minute}); backlog.Unread != want {
+		t.Errorf("unread = %+v, want %+v (what is left of the half-read link)", backlog.Unread, want)
+	}
+	if want := []int{1, 0, 0, 1, 0}; !slices.Equal(backlog.Lengths, want) {
+		t.Errorf("lengths = %v, want %v", backlog.Lengths, want)
+	}
+}
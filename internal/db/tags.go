@@ -0,0 +1,143 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/alexzajac/the-dredger/internal/model"
+)
+
+// TopTags returns the n most used tags across non-pruned links, most
+// frequent first. Ties are broken alphabetically.
+func TopTags(db *sql.DB, n int) ([]string, error) {
+	rows, err := db.Query(`SELECT tags FROM links WHERE tags != '' AND status != ?`, int(model.Pruned))
+	if err != nil {
+		return nil, fmt.Errorf("query tags: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var tags string
+		if err := rows.Scan(&tags); err != nil {
+			return nil, fmt.Errorf("scan tags: %w", err)
+		}
+		for _, t := range strings.Split(tags, ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				counts[t]++
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	tags := make([]string, 0, len(counts))
+	for t := range counts {
+		tags = append(tags, t)
+	}
+	sort.Slice(tags, func(i, j int) bool {
+		if counts[tags[i]] != counts[tags[j]] {
+			return counts[tags[i]] > counts[tags[j]]
+		}
+		return tags[i] < tags[j]
+	})
+	if len(tags) > n {
+		tags = tags[:n]
+	}
+	return tags, nil
+}
+
+// AddSuggestedTags queues tags for review. Tags already queued for the
+// link are left as they are.
+func AddSuggestedTags(db *sql.DB, linkID int64, tags []model.SuggestedTag) error {
+	for _, t := range tags {
+		_, err := db.Exec(
+			`INSERT INTO suggested_tags (link_id, tag, confidence) VALUES (?, ?, ?)
+			 ON CONFLICT(link_id, tag) DO NOTHING`,
+			linkID, t.Tag, t.Confidence,
+		)
+		if err != nil {
+			return fmt.Errorf("add suggested tag: %w", err)
+		}
+	}
+	return nil
+}
+
+// GetSuggestedTags returns the review queue for non-pruned links, grouped
+// by tag so that the same proposal for several links sits together.
+func GetSuggestedTags(db *sql.DB) ([]model.SuggestedTag, error) {
+	rows, err := db.Query(
+		`SELECT s.id, s.link_id, l.title, l.url, s.tag, s.confidence
+		 FROM suggested_tags s JOIN links l ON l.id = s.link_id
+		 WHERE l.status != ?
+		 ORDER BY s.tag, s.confidence DESC`, int(model.Pruned),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("query suggested tags: %w", err)
+	}
+	defer func() { _ = rows.Close() }()
+
+	var out []model.SuggestedTag
+	for rows.Next() {
+		var s model.SuggestedTag
+		if err := rows.Scan(&s.ID, &s.LinkID, &s.LinkTitle, &s.LinkURL, &s.Tag, &s.Confidence); err != nil {
+			return nil, fmt.Errorf("scan suggested tag: %w", err)
+		}
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
+
+// ApproveSuggestedTag adds the suggested tag to its link and removes it
+// from the queue.
+func ApproveSuggestedTag(db *sql.DB, id int64) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	var linkID int64
+	var tag, tags string
+	err = tx.QueryRow(
+		`SELECT s.link_id, s.tag, l.tags FROM suggested_tags s JOIN links l ON l.id = s.link_id WHERE s.id = ?`, id,
+	).Scan(&linkID, &tag, &tags)
+	if err != nil {
+		return fmt.Errorf("get suggested tag: %w", err)
+	}
+
+	var list []string
+	if tags != "" {
+		list = strings.Split(tags, ",")
+	}
+	if !containsFold(list, tag) {
+		list = append(list, tag)
+	}
+	if _, err := tx.Exec(`UPDATE links SET tags=? WHERE id=?`, strings.Join(list, ","), linkID); err != nil {
+		return fmt.Errorf("update link tags: %w", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM suggested_tags WHERE id=?`, id); err != nil {
+		return fmt.Errorf("delete suggested tag: %w", err)
+	}
+	return tx.Commit()
+}
+
+// RejectSuggestedTag drops a suggestion without touching the link.
+func RejectSuggestedTag(db *sql.DB, id int64) error {
+	if _, err := db.Exec(`DELETE FROM suggested_tags WHERE id=?`, id); err != nil {
+		return fmt.Errorf("reject suggested tag: %w", err)
+	}
+	return nil
+}
+
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,92 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/alexzajac/the-dredger/internal/model"
+)
+
+func TestTopTags(t *testing.T) {
+	db := setupTestDB(t)
+
+	links := []model.Link{
+		{URL: "https://a.com", Tags: []string{"go", "cli"}},
+		{URL: "https://b.com", Tags: []string{"go", "databases"}},
+		{URL: "https://c.com", Tags: []string{"go", "cli"}},
+		{URL: "https://d.com", Tags: []string{"pruned-only"}, Status: model.Pruned},
+	}
+	for _, l := range links {
+		if _, err := InsertLink(db, l); err != nil {
+			t.Fatalf("insert: %v", err)
+		}
+	}
+
+	got, err := TopTags(db, 2)
+	if err != nil {
+		t.Fatalf("top tags: %v", err)
+	}
+	if len(got) != 2 || got[0] != "go" || got[1] != "cli" {
+		t.Errorf("TopTags = %v, want [go cli]", got)
+	}
+
+	all, err := TopTags(db, 10)
+	if err != nil {
+		t.Fatalf("top tags: %v", err)
+	}
+	for _, tag := range all {
+		if tag == "pruned-only" {
+			t.Error("tags from pruned links should be ignored")
+		}
+	}
+}
+
+func TestSuggestedTagsApproveReject(t *testing.T) {
+	db := setupTestDB(t)
+
+	id, err := InsertLink(db, model.Link{URL: "https://example.com", Title: "Example", Tags: []string{"go"}})
+	if err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+
+	suggestions := []model.SuggestedTag{{Tag: "wasm", Confidence: 0.4}, {Tag: "compilers", Confidence: 0.6}}
+	if err := AddSuggestedTags(db, id, suggestions); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	// Re-adding the same tag for the same link is a no-op.
+	if err := AddSuggestedTags(db, id, suggestions[:1]); err != nil {
+		t.Fatalf("re-add: %v", err)
+	}
+
+	queue, err := GetSuggestedTags(db)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if len(queue) != 2 {
+		t.Fatalf("got %d suggestions, want 2", len(queue))
+	}
+	if queue[0].Tag != "compilers" || queue[0].LinkTitle != "Example" {
+		t.Errorf("unexpected first suggestion: %+v", queue[0])
+	}
+
+	if err := ApproveSuggestedTag(db, queue[0].ID); err != nil {
+		t.Fatalf("approve: %v", err)
+	}
+	if err := RejectSuggestedTag(db, queue[1].ID); err != nil {
+		t.Fatalf("reject: %v", err)
+	}
+
+	links, err := GetLinks(db)
+	if err != nil {
+		t.Fatalf("get links: %v", err)
+	}
+	if len(links[0].Tags) != 2 || links[0].Tags[1] != "compilers" {
+		t.Errorf("Tags = %v, want [go compilers]", links[0].Tags)
+	}
+	queue, err = GetSuggestedTags(db)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if len(queue) != 0 {
+		t.Errorf("queue should be empty, got %d", len(queue))
+	}
+}
@@ -0,0 +1,463 @@
+package dredge
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+
+	"github.com/alexzajac/the-dredger/internal/config"
+	"github.com/alexzajac/the-dredger/internal/db"
+	"github.com/alexzajac/the-dredger/internal/model"
+)
+
+// ErrArchiveTooLarge is returned for pages bigger than archive.max_bytes.
+var ErrArchiveTooLarge = errors.New("page too large to archive")
+
+var cssURLRe = regexp.MustCompile(`url\(\s*['"]?([^'")]+?)['"]?\s*\)`)
+
+// Archiver keeps offline copies of saved pages, one directory per link.
+// HTML pages are stored as a single file with their stylesheets inlined
+// and their images and fonts embedded as data URIs, and scripts removed,
+// so the copy opens in a browser without the site. Other documents, such
+// as PDFs, are stored as they are.
+type Archiver struct {
+	db            *sql.DB
+	client        *http.Client
+	dir           string
+	maxBytes      int64
+	maxAssetBytes int64
+}
+
+// NewArchiver returns an archiver writing to archive.dir, or to an archive
+// directory next to the database when that is not set.
+func NewArchiver(database *sql.DB, cfg config.Config) (*Archiver, error) {
+	dir := cfg.Archive.Dir
+	if dir == "" {
+		dbPath, err := db.FilePath(database)
+		if err != nil {
+			return nil, err
+		}
+		if dbPath == "" {
+			return nil, errors.New("archive.dir must be set for an in-memory database")
+		}
+		dir = filepath.Join(filepath.Dir(dbPath), "archive")
+	}
+	return &Archiver{
+		db: database,
+		// Assets are fetched without the page cache, which would otherwise
+		// fill with stylesheets and fonts.
+		client: &http.Client{
+			Timeout:   cfg.Dredge.Timeout,
+			Transport: newPoliteTransport(http.DefaultTransport, cfg.Dredge),
+		},
+		dir:           dir,
+		maxBytes:      cfg.Archive.MaxBytes,
+		maxAssetBytes: cfg.Archive.MaxAssetBytes,
+	}, nil
+}
+
+// Archive stores a fresh copy of the page link leads to, replacing any
+// earlier one, and returns the link with ArchivePath and ArchivedAt set.
+func (a *Archiver) Archive(ctx context.Context, link model.Link) (model.Link, error) {
+	pageURL := link.FinalURL()
+	body, resp, err := a.fetch(ctx, pageURL, "text/html,application/xhtml+xml,*/*;q=0.8", a.maxBytes)
+	if err != nil {
+		return link, err
+	}
+	if body == nil {
+		return link, fmt.Errorf("%s: %w (limit %s)", pageURL, ErrArchiveTooLarge, formatBytes(a.maxBytes))
+	}
+
+	name := "index.html"
+	contentType := sniffContentType(resp.Header.Get("Content-Type"), body)
+	if contentType == TypeHTML {
+		body, err = a.singleFile(ctx, pageURL, decodeText(body, resp.Header.Get("Content-Type")))
+		if err != nil {
+			return link, err
+		}
+	} else {
+		name = "page" + fileExtension(contentType)
+	}
+	if int64(len(body)) > a.maxBytes {
+		return link, fmt.Errorf("%s: %w (limit %s)", pageURL, ErrArchiveTooLarge, formatBytes(a.maxBytes))
+	}
+
+	dir := filepath.Join(a.dir, strconv.FormatInt(link.ID, 10))
+	path, err := replaceArchive(dir, name, body)
+	if err != nil {
+		return link, err
+	}
+	link.ArchivePath, link.ArchivedAt = path, time.Now()
+	return link, db.UpdateLinkArchive(a.db, link.ID, link.ArchivePath, link.ArchivedAt)
+}
+
+// replaceArchive writes body to dir/name, clearing out any earlier copy.
+// The file is written beside the directory first so that a failed write
+// leaves the old copy in place.
+func replaceArchive(dir, name string, body []byte) (string, error) {
+	if err := os.MkdirAll(filepath.Dir(dir), 0o755); err != nil {
+		return "", fmt.Errorf("create archive dir: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(dir), filepath.Base(dir)+".*.tmp")
+	if err != nil {
+		return "", fmt.Errorf("write archive: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err := tmp.Write(body); err != nil {
+		_ = tmp.Close()
+		return "", fmt.Errorf("write archive: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return "", fmt.Errorf("write archive: %w", err)
+	}
+
+	if err := os.RemoveAll(dir); err != nil {
+		return "", fmt.Errorf("remove old archive: %w", err)
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("create archive dir: %w", err)
+	}
+	path := filepath.Join(dir, name)
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", fmt.Errorf("write archive: %w", err)
+	}
+	return path, nil
+}
+
+// fetch downloads rawURL. The body is nil, without an error, when it is
+// larger than limit.
+func (a *Archiver) fetch(ctx context.Context, rawURL, accept string, limit int64) ([]byte, *http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Accept", accept)
+	resp, err := a.client.Do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("fetch %s: %w", rawURL, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, newHTTPStatusError(rawURL, resp)
+	}
+	if resp.ContentLength > limit {
+		return nil, resp, nil
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, nil, fmt.Errorf("read %s: %w", rawURL, err)
+	}
+	if int64(len(body)) > limit {
+		return nil, resp, nil
+	}
+	return body, resp, nil
+}
+
+// archivedText extracts the article text from link's offline copy, or
+// returns "" when it has no HTML copy.
+func archivedText(link model.Link) string {
+	if filepath.Ext(link.ArchivePath) != ".html" {
+		return ""
+	}
+	f, err := os.Open(link.ArchivePath)
+	if err != nil {
+		return ""
+	}
+	defer func() { _ = f.Close() }()
+	return ExtractContent(f)
+}
+
+// fileExtension picks a file extension for a content type.
+func fileExtension(contentType string) string {
+	switch contentType {
+	case TypePDF:
+		return ".pdf"
+	case "text/plain":
+		return ".txt"
+	}
+	if exts, _ := mime.ExtensionsByType(contentType); len(exts) > 0 {
+		return exts[0]
+	}
+	return ""
+}
+
+// inliner embeds a page's assets into it, within a byte budget shared by
+// the whole page.
+type inliner struct {
+	a      *Archiver
+	ctx    context.Context
+	budget int64
+	// assets maps each asset URL to its data URI, or "" when it could
+	// not be embedded.
+	assets map[string]string
+}
+
+// singleFile turns a UTF-8 HTML page into a self-contained document.
+func (a *Archiver) singleFile(ctx context.Context, pageURL string, page []byte) ([]byte, error) {
+	// With scripting off, <noscript> content parses as markup, which is
+	// what a reader without the page's scripts should see.
+	doc, err := html.ParseWithOptions(bytes.NewReader(page), html.ParseOptionEnableScripting(false))
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", pageURL, err)
+	}
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return nil, fmt.Errorf("parse %s: %w", pageURL, err)
+	}
+	if href := findBaseHref(doc); href != "" {
+		if u, err := base.Parse(href); err == nil {
+			base = u
+		}
+	}
+
+	in := &inliner{a: a, ctx: ctx, budget: a.maxBytes - int64(len(page)), assets: make(map[string]string)}
+	in.walk(doc, base)
+	addArchiveHead(doc, pageURL)
+
+	var buf bytes.Buffer
+	if err := html.Render(&buf, doc); err != nil {
+		return nil, fmt.Errorf("render archive: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+func findBaseHref(n *html.Node) string {
+	if n.Type == html.ElementNode && n.DataAtom == atom.Base {
+		return attr(n, "href")
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if href := findBaseHref(c); href != "" {
+			return href
+		}
+	}
+	return ""
+}
+
+// walk rewrites n's subtree in place: scripts, event handlers and
+// preloads go, assets are embedded and every other reference is made
+// absolute so that links still lead somewhere.
+func (in *inliner) walk(n *html.Node, base *url.URL) {
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		if c.Type == html.ElementNode {
+			switch {
+			case c.DataAtom == atom.Script, c.DataAtom == atom.Base,
+				c.DataAtom == atom.Link && hasToken(attr(c, "rel"), "preload"),
+				c.DataAtom == atom.Link && hasToken(attr(c, "rel"), "modulepreload"),
+				c.DataAtom == atom.Link && hasToken(attr(c, "rel"), "prefetch"),
+				c.DataAtom == atom.Meta && (hasAttr(c, "charset") || strings.EqualFold(attr(c, "http-equiv"), "content-type")),
+				// <picture> falls back to its <img>, which is embedded.
+				c.DataAtom == atom.Source && n.DataAtom == atom.Picture:
+				n.RemoveChild(c)
+				c = next
+				continue
+			case c.DataAtom == atom.Noscript:
+				// Hoist the fallback content in place of the element and
+				// carry on from its first node, so it is walked too.
+				if c.FirstChild != nil {
+					next = c.FirstChild
+				}
+				for gc := c.FirstChild; gc != nil; {
+					gnext := gc.NextSibling
+					c.RemoveChild(gc)
+					n.InsertBefore(gc, c)
+					gc = gnext
+				}
+				n.RemoveChild(c)
+				c = next
+				continue
+			}
+			in.element(c, base)
+		}
+		in.walk(c, base)
+		c = next
+	}
+}
+
+// element embeds or absolutises the references of one element.
+func (in *inliner) element(n *html.Node, base *url.URL) {
+	attrs := n.Attr[:0]
+	for _, at := range n.Attr {
+		if strings.HasPrefix(strings.ToLower(at.Key), "on") || at.Key == "integrity" || at.Key == "srcset" ||
+			at.Key == "sizes" || at.Key == "loading" {
+			continue
+		}
+		attrs = append(attrs, at)
+	}
+	n.Attr = attrs
+
+	switch n.DataAtom {
+	case atom.Img:
+		// Lazy loaders keep the real image in a data attribute.
+		if lazy := attr(n, "data-src"); lazy != "" && (attr(n, "src") == "" || strings.HasPrefix(attr(n, "src"), "data:")) {
+			setAttr(n, "src", lazy)
+		}
+	case atom.Link:
+		rel := attr(n, "rel")
+		switch {
+		case hasToken(rel, "stylesheet"):
+			if css, ok := in.stylesheet(resolveRef(base, attr(n, "href"))); ok {
+				n.DataAtom, n.Data = atom.Style, "style"
+				media := attr(n, "media")
+				n.Attr = nil
+				if media != "" {
+					setAttr(n, "media", media)
+				}
+				n.AppendChild(&html.Node{Type: html.TextNode, Data: css})
+				return
+			}
+		case hasToken(rel, "icon"):
+			if data := in.embed(resolveRef(base, attr(n, "href"))); data != "" {
+				setAttr(n, "href", data)
+				return
+			}
+		}
+	case atom.Style:
+		if c := n.FirstChild; c != nil && c.Type == html.TextNode {
+			c.Data = in.css(c.Data, base)
+		}
+	}
+
+	for i, at := range n.Attr {
+		switch at.Key {
+		case "style":
+			n.Attr[i].Val = in.css(at.Val, base)
+		case "src", "poster":
+			abs := resolveRef(base, at.Val)
+			if n.DataAtom == atom.Img || n.DataAtom == atom.Input || at.Key == "poster" {
+				if data := in.embed(abs); data != "" {
+					abs = data
+				}
+			}
+			n.Attr[i].Val = abs
+		case "href", "action", "cite":
+			n.Attr[i].Val = resolveRef(base, at.Val)
+		}
+	}
+}
+
+// stylesheet fetches a stylesheet and embeds what it references.
+func (in *inliner) stylesheet(rawURL string) (string, bool) {
+	if !strings.HasPrefix(rawURL, "http") {
+		return "", false
+	}
+	body, _, err := in.a.fetch(in.ctx, rawURL, "text/css,*/*;q=0.1", min(in.a.maxAssetBytes, in.budget))
+	if err != nil || body == nil {
+		return "", false
+	}
+	in.budget -= int64(len(body))
+	sheetURL, err := url.Parse(rawURL)
+	if err != nil {
+		return "", false
+	}
+	return in.css(string(body), sheetURL), true
+}
+
+// css embeds the images and fonts referenced by url() in a stylesheet,
+// resolving them against base.
+func (in *inliner) css(css string, base *url.URL) string {
+	return cssURLRe.ReplaceAllStringFunc(css, func(m string) string {
+		ref := cssURLRe.FindStringSubmatch(m)[1]
+		if strings.HasPrefix(ref, "data:") || strings.HasPrefix(ref, "#") {
+			return m
+		}
+		abs := resolveRef(base, ref)
+		if data := in.embed(abs); data != "" {
+			abs = data
+		}
+		return `url("` + abs + `")`
+	})
+}
+
+// embed returns rawURL's content as a data URI, or "" when it cannot be
+// fetched or does not fit the limits.
+func (in *inliner) embed(rawURL string) string {
+	if !strings.HasPrefix(rawURL, "http") {
+		return ""
+	}
+	if data, ok := in.assets[rawURL]; ok {
+		return data
+	}
+	in.assets[rawURL] = ""
+	// Base64 grows the asset by a third.
+	limit := min(in.a.maxAssetBytes, in.budget*3/4)
+	if limit <= 0 {
+		return ""
+	}
+	body, resp, err := in.a.fetch(in.ctx, rawURL, "image/*,font/*,*/*;q=0.5", limit)
+	if err != nil || body == nil {
+		return ""
+	}
+	contentType := sniffContentType(resp.Header.Get("Content-Type"), body)
+	data := "data:" + contentType + ";base64," + base64.StdEncoding.EncodeToString(body)
+	in.budget -= int64(len(data))
+	in.assets[rawURL] = data
+	return data
+}
+
+// resolveRef makes ref absolute against base, leaving fragments, data URIs
+// and anything unparsable as they are.
+func resolveRef(base *url.URL, ref string) string {
+	ref = strings.TrimSpace(ref)
+	if ref == "" || strings.HasPrefix(ref, "#") || strings.HasPrefix(ref, "data:") {
+		return ref
+	}
+	u, err := base.Parse(ref)
+	if err != nil {
+		return ref
+	}
+	return u.String()
+}
+
+func setAttr(n *html.Node, key, val string) {
+	for i, a := range n.Attr {
+		if a.Key == key {
+			n.Attr[i].Val = val
+			return
+		}
+	}
+	n.Attr = append(n.Attr, html.Attribute{Key: key, Val: val})
+}
+
+// addArchiveHead declares the copy's encoding, which is always UTF-8 once
+// rendered, and notes where and when it was taken.
+func addArchiveHead(doc *html.Node, pageURL string) {
+	head := findElement(doc, atom.Head)
+	if head == nil {
+		return
+	}
+	note := &html.Node{Type: html.CommentNode, Data: fmt.Sprintf(" Archived by the Dredger from %s on %s ",
+		strings.ReplaceAll(pageURL, "--", "%2D%2D"), time.Now().UTC().Format(time.RFC3339))}
+	charset := &html.Node{Type: html.ElementNode, DataAtom: atom.Meta, Data: "meta",
+		Attr: []html.Attribute{{Key: "charset", Val: "utf-8"}}}
+	head.InsertBefore(note, head.FirstChild)
+	head.InsertBefore(charset, note)
+}
+
+func findElement(n *html.Node, a atom.Atom) *html.Node {
+	if n.Type == html.ElementNode && n.DataAtom == a {
+		return n
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if found := findElement(c, a); found != nil {
+			return found
+		}
+	}
+	return nil
+}
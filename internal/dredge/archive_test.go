@@ -0,0 +1,150 @@
+package dredge
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/alexzajac/the-dredger/internal/config"
+	"github.com/alexzajac/the-dredger/internal/db"
+	"github.com/alexzajac/the-dredger/internal/model"
+)
+
+const archivePage = `<!DOCTYPE html>
+<html><head>
+<meta charset="iso-8859-1">
+<title>Caf` + "\xe9" + `</title>
+<link rel="stylesheet" href="/css/site.css">
+<link rel="preload" href="/font.woff2" as="font">
+<script src="/app.js"></script>
+</head><body onload="track()">
+<p>Caf` + "\xe9" + ` <a href="/about">about</a></p>
+<img src="/img/dot.png" srcset="/img/dot@2x.png 2x">
+<img src="data:image/gif;base64,R0lGOD" data-src="/img/dot.png">
+<img src="/img/huge.png">
+<noscript><img src="/img/fallback.png"></noscript>
+</body></html>`
+
+func archiveServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	png := "\x89PNG\r\n\x1a\n" + strings.Repeat("\x00", 16)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/page":
+			w.Header().Set("Content-Type", "text/html; charset=iso-8859-1")
+			_, _ = w.Write([]byte(archivePage))
+		case "/css/site.css":
+			w.Header().Set("Content-Type", "text/css")
+			_, _ = w.Write([]byte(`body { background: url('../img/bg.png') }`))
+		case "/img/dot.png", "/img/bg.png", "/img/fallback.png":
+			w.Header().Set("Content-Type", "image/png")
+			_, _ = w.Write([]byte(png))
+		case "/img/huge.png":
+			w.Header().Set("Content-Type", "image/png")
+			_, _ = w.Write([]byte(png + strings.Repeat("\x00", 4096)))
+		case "/paper.pdf":
+			w.Header().Set("Content-Type", "application/pdf")
+			_, _ = w.Write([]byte("%PDF-1.4 not really"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestArchiverSingleFile(t *testing.T) {
+	srv := archiveServer(t)
+	database := openTestDB(t)
+	cfg := config.Default()
+	cfg.Dredge.HostRate = 1000
+	cfg.Archive.Dir = t.TempDir()
+	cfg.Archive.MaxAssetBytes = 1024
+	archiver, err := NewArchiver(database, cfg)
+	if err != nil {
+		t.Fatalf("new archiver: %v", err)
+	}
+
+	id, _ := db.InsertLink(database, model.Link{URL: srv.URL + "/page", Status: model.Saved})
+	link, err := archiver.Archive(context.Background(), model.Link{ID: id, URL: srv.URL + "/page"})
+	if err != nil {
+		t.Fatalf("archive: %v", err)
+	}
+	if want := filepath.Join(cfg.Archive.Dir, "1", "index.html"); link.ArchivePath != want {
+		t.Errorf("path = %q, want %q", link.ArchivePath, want)
+	}
+	raw, err := os.ReadFile(link.ArchivePath)
+	if err != nil {
+		t.Fatalf("read copy: %v", err)
+	}
+	page := string(raw)
+
+	for _, want := range []string{
+		`<meta charset="utf-8"/>`,
+		"Café",
+		`url("data:image/png;base64,`,
+		`<img src="data:image/png;base64,`,
+		`href="` + srv.URL + `/about"`,
+		`<img src="` + srv.URL + `/img/huge.png"/>`,
+		"Archived by the Dredger from " + srv.URL + "/page",
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("copy lacks %q:\n%s", want, page)
+		}
+	}
+	for _, gone := range []string{"<script", "onload", "srcset", "preload", "iso-8859-1", "noscript", "/css/site.css", "R0lGOD"} {
+		if strings.Contains(page, gone) {
+			t.Errorf("copy still has %q:\n%s", gone, page)
+		}
+	}
+	if n := strings.Count(page, `<img src="data:image/png;base64,`); n != 3 {
+		t.Errorf("%d images embedded, want 3 (plain, lazy and noscript)", n)
+	}
+
+	links, _ := db.GetLinks(database)
+	if len(links) != 1 || links[0].ArchivePath != link.ArchivePath || links[0].ArchivedAt.IsZero() {
+		t.Fatalf("archive not recorded: %+v", links)
+	}
+	if unarchived, _ := db.GetUnarchivedLinks(database); len(unarchived) != 0 {
+		t.Errorf("%d links still unarchived", len(unarchived))
+	}
+}
+
+func TestArchiverDocumentsAndLimits(t *testing.T) {
+	srv := archiveServer(t)
+	database := openTestDB(t)
+	cfg := config.Default()
+	cfg.Dredge.HostRate = 1000
+	cfg.Archive.Dir = t.TempDir()
+	archiver, err := NewArchiver(database, cfg)
+	if err != nil {
+		t.Fatalf("new archiver: %v", err)
+	}
+	ctx := context.Background()
+
+	id, _ := db.InsertLink(database, model.Link{URL: srv.URL + "/paper.pdf", Status: model.Saved})
+	link, err := archiver.Archive(ctx, model.Link{ID: id, URL: srv.URL + "/paper.pdf"})
+	if err != nil {
+		t.Fatalf("archive pdf: %v", err)
+	}
+	if filepath.Base(link.ArchivePath) != "page.pdf" {
+		t.Errorf("pdf stored as %q", link.ArchivePath)
+	}
+
+	archiver.maxBytes = 64
+	if _, err := archiver.Archive(ctx, model.Link{ID: id, URL: srv.URL + "/page"}); !errors.Is(err, ErrArchiveTooLarge) {
+		t.Errorf("oversized page: err = %v", err)
+	}
+	if _, err := os.Stat(link.ArchivePath); err != nil {
+		t.Errorf("a failed archive should keep the old copy: %v", err)
+	}
+
+	if _, err := archiver.Archive(ctx, model.Link{ID: id, URL: srv.URL + "/missing"}); err == nil {
+		t.Error("expected an error for a missing page")
+	}
+}
@@ -0,0 +1,201 @@
+package dredge
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/alexzajac/the-dredger/internal/db"
+	"github.com/alexzajac/the-dredger/internal/model"
+)
+
+// ErrNotCached is returned offline for a page that was never fetched.
+var ErrNotCached = errors.New("page not in cache")
+
+// CacheHeader is set on responses served from the page cache, to "hit"
+// offline or "revalidated" after a 304.
+const CacheHeader = "X-Dredger-Cache"
+
+type offlineKey struct{}
+
+// withOffline marks requests made with ctx to be served from the page
+// cache only.
+func withOffline(ctx context.Context) context.Context {
+	return context.WithValue(ctx, offlineKey{}, true)
+}
+
+func isOffline(ctx context.Context) bool {
+	offline, _ := ctx.Value(offlineKey{}).(bool)
+	return offline
+}
+
+type cacheLinkKey struct{}
+
+// withCacheLink files pages cached by requests made with ctx under the
+// link with the given id, whatever URL they were fetched from.
+func withCacheLink(ctx context.Context, id int64) context.Context {
+	return context.WithValue(ctx, cacheLinkKey{}, id)
+}
+
+func cacheLink(ctx context.Context) int64 {
+	id, _ := ctx.Value(cacheLinkKey{}).(int64)
+	return id
+}
+
+// cachingTransport keeps successful GET responses, and redirects, in the
+// page_cache table, unless they were authorized or are marked no-store.
+// Later requests for the same URL are made conditional on the cached ETag
+// and Last-Modified, and a 304 is answered from the cache. Requests whose
+// context is offline never reach the network; cached redirects are
+// replayed so that the client follows them to the page.
+type cachingTransport struct {
+	base http.RoundTripper
+	db   *sql.DB
+}
+
+func (t *cachingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	// Authorized responses, e.g. from the GitHub API with a token, may be
+	// private and are never stored.
+	if req.Method != http.MethodGet || req.Header.Get("Authorization") != "" {
+		return t.base.RoundTrip(req)
+	}
+	key := req.URL.String()
+	cached, _ := db.GetCachedPage(t.db, key)
+
+	if isOffline(req.Context()) {
+		if cached == nil {
+			return nil, fmt.Errorf("%s: %w", key, ErrNotCached)
+		}
+		return cachedResponse(req, cached, "hit"), nil
+	}
+
+	if cached != nil && req.Header.Get("If-None-Match") == "" && req.Header.Get("If-Modified-Since") == "" {
+		req = req.Clone(req.Context())
+		if cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case noStore(resp.Header):
+	case resp.StatusCode == http.StatusNotModified && cached != nil:
+		_ = resp.Body.Close()
+		_ = db.TouchCachedPage(t.db, key)
+		return cachedResponse(req, cached, "revalidated"), nil
+	case isRedirect(resp.StatusCode) && resp.Header.Get("Location") != "":
+		_ = db.SaveCachedPage(t.db, model.CachedPage{
+			URL:      key,
+			LinkID:   cacheLink(req.Context()),
+			Status:   resp.StatusCode,
+			Location: resp.Header.Get("Location"),
+		})
+	case resp.StatusCode == http.StatusOK && !isMedia(sniffContentType(resp.Header.Get("Content-Type"), nil)):
+		resp.Body = &cacheWriter{
+			ReadCloser: resp.Body,
+			save: func(body []byte) {
+				_ = db.SaveCachedPage(t.db, model.CachedPage{
+					URL:          key,
+					LinkID:       cacheLink(req.Context()),
+					ContentType:  resp.Header.Get("Content-Type"),
+					ETag:         resp.Header.Get("ETag"),
+					LastModified: resp.Header.Get("Last-Modified"),
+					Body:         body,
+				})
+			},
+		}
+	}
+	return resp, nil
+}
+
+// noStore reports whether a response's Cache-Control forbids keeping it:
+// "no-store", or "private" since the cache outlives the session.
+func noStore(h http.Header) bool {
+	for _, v := range h.Values("Cache-Control") {
+		for _, d := range strings.Split(v, ",") {
+			d, _, _ = strings.Cut(strings.TrimSpace(d), "=")
+			if strings.EqualFold(d, "no-store") || strings.EqualFold(d, "private") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func isRedirect(status int) bool {
+	switch status {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusSeeOther,
+		http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+		return true
+	}
+	return false
+}
+
+// cachedResponse builds a response from a cached page: a 200 with its
+// body, or the redirect it recorded.
+func cachedResponse(req *http.Request, page *model.CachedPage, how string) *http.Response {
+	status := cmp.Or(page.Status, http.StatusOK)
+	header := make(http.Header)
+	if page.Location != "" {
+		header.Set("Location", page.Location)
+	}
+	if page.ContentType != "" {
+		header.Set("Content-Type", page.ContentType)
+	}
+	if page.ETag != "" {
+		header.Set("ETag", page.ETag)
+	}
+	if page.LastModified != "" {
+		header.Set("Last-Modified", page.LastModified)
+	}
+	header.Set("Content-Length", strconv.Itoa(len(page.Body)))
+	header.Set(CacheHeader, how)
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		StatusCode:    status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(page.Body)),
+		ContentLength: int64(len(page.Body)),
+		Request:       req,
+	}
+}
+
+// cacheWriter buffers a body as it is read and saves it once it has been
+// read to the end. Bodies larger than maxBodyBytes, or abandoned part way,
+// are not cached.
+type cacheWriter struct {
+	io.ReadCloser
+	buf   bytes.Buffer
+	save  func([]byte)
+	saved bool
+}
+
+func (w *cacheWriter) Read(p []byte) (int, error) {
+	n, err := w.ReadCloser.Read(p)
+	if w.buf.Len()+n <= maxBodyBytes {
+		w.buf.Write(p[:n])
+	} else {
+		w.saved = true // too big; never save a truncated copy
+	}
+	if err == io.EOF && !w.saved {
+		w.saved = true
+		w.save(w.buf.Bytes())
+	}
+	return n, err
+}
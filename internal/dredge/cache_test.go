@@ -0,0 +1,240 @@
+package dredge
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+
+	"github.com/alexzajac/the-dredger/internal/db"
+	"github.com/alexzajac/the-dredger/internal/model"
+)
+
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	database, err := db.Open(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("open db: %v", err)
+	}
+	if err := db.InitSchema(database); err != nil {
+		t.Fatalf("init schema: %v", err)
+	}
+	t.Cleanup(func() { _ = database.Close() })
+	return database
+}
+
+func get(t *testing.T, ctx context.Context, client *http.Client, url string) (*http.Response, string, error) {
+	t.Helper()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	body, _ := io.ReadAll(resp.Body)
+	return resp, string(body), nil
+}
+
+func TestCachingTransport(t *testing.T) {
+	var full, notModified atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		full.Add(1)
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = io.WriteString(w, "<title>Cached</title>")
+	}))
+	defer srv.Close()
+
+	database := openTestDB(t)
+	client := &http.Client{Transport: &cachingTransport{base: srv.Client().Transport, db: database}}
+	ctx := context.Background()
+
+	_, body, err := get(t, ctx, client, srv.URL+"/page")
+	if err != nil || body != "<title>Cached</title>" {
+		t.Fatalf("first fetch: %q %v", body, err)
+	}
+
+	resp, body, err := get(t, ctx, client, srv.URL+"/page")
+	if err != nil {
+		t.Fatalf("second fetch: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK || body != "<title>Cached</title>" {
+		t.Errorf("expected cached body on 304, got %d %q", resp.StatusCode, body)
+	}
+	if resp.Header.Get(CacheHeader) != "revalidated" || resp.Header.Get("Content-Type") != "text/html; charset=utf-8" {
+		t.Errorf("unexpected headers %v", resp.Header)
+	}
+	if full.Load() != 1 || notModified.Load() != 1 {
+		t.Errorf("expected one full fetch and one 304, got %d and %d", full.Load(), notModified.Load())
+	}
+
+	// Offline requests are served from the cache without the network.
+	resp, body, err = get(t, withOffline(ctx), client, srv.URL+"/page")
+	if err != nil || body != "<title>Cached</title>" || resp.Header.Get(CacheHeader) != "hit" {
+		t.Errorf("offline hit: %q %v", body, err)
+	}
+	if _, _, err := get(t, withOffline(ctx), client, srv.URL+"/other"); !errors.Is(err, ErrNotCached) {
+		t.Errorf("expected ErrNotCached offline, got %v", err)
+	}
+	if n := full.Load() + notModified.Load(); n != 2 {
+		t.Errorf("offline requests reached the server: %d requests", n)
+	}
+}
+
+func TestCachingTransportSkipsPrivate(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/no-store":
+			w.Header().Set("Cache-Control", "no-store")
+		case "/private":
+			w.Header().Set("Cache-Control", "max-age=60, private")
+		}
+		_, _ = io.WriteString(w, "secret")
+	}))
+	defer srv.Close()
+
+	database := openTestDB(t)
+	client := &http.Client{Transport: &cachingTransport{base: srv.Client().Transport, db: database}}
+	ctx := context.Background()
+
+	for _, path := range []string{"/no-store", "/private"} {
+		if _, _, err := get(t, ctx, client, srv.URL+path); err != nil {
+			t.Fatalf("fetch %s: %v", path, err)
+		}
+	}
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/authorized", nil)
+	req.Header.Set("Authorization", "Bearer secret")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("authorized fetch: %v", err)
+	}
+	_, _ = io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+
+	for _, path := range []string{"/no-store", "/private", "/authorized"} {
+		if p, _ := db.GetCachedPage(database, srv.URL+path); p != nil {
+			t.Errorf("%s was cached", path)
+		}
+	}
+}
+
+func TestRecrunchIsOffline(t *testing.T) {
+	database := openTestDB(t)
+	const pageURL = "http://dredger.invalid/post"
+
+	id, _ := db.InsertLink(database, model.Link{URL: pageURL})
+	_ = db.SaveCachedPage(database, model.CachedPage{
+		URL:         pageURL,
+		LinkID:      id,
+		ContentType: "text/html",
+		Body:        []byte(`<html><head><title>From Cache</title></head><body><p>Body text.</p></body></html>`),
+	})
+	if _, err := db.EnqueueRecrunch(database); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	job, _ := db.ClaimDredgeJob(database)
+	if job == nil {
+		t.Fatal("expected a recrunch job")
+	}
+
+	failing := roundTripFunc(func(*http.Request) (*http.Response, error) {
+		t.Error("recrunch reached the network")
+		return nil, errors.New("offline")
+	})
+	s := &Service{
+		db:     database,
+		client: &http.Client{Transport: &cachingTransport{base: failing, db: database}},
+		llm:    NoopSummarizer{},
+	}
+	result := s.process(context.Background(), *job, false, nil)
+	if result.Err != nil {
+		t.Fatalf("process: %v", result.Err)
+	}
+	if result.Title != "From Cache" {
+		t.Errorf("expected title from cached page, got %q", result.Title)
+	}
+}
+
+func TestRecrunchFollowsCachedRedirects(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/short" {
+			http.Redirect(w, r, "/post", http.StatusFound)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = io.WriteString(w, `<html><head><title>Redirected</title></head><body><p>Body text.</p></body></html>`)
+	}))
+	defer srv.Close()
+
+	database := openTestDB(t)
+	id, _ := db.InsertLink(database, model.Link{URL: srv.URL + "/short"})
+	online := &Service{
+		db:     database,
+		client: &http.Client{Transport: &cachingTransport{base: srv.Client().Transport, db: database}},
+		llm:    NoopSummarizer{},
+	}
+	if result := online.fetchOne(context.Background(), id, srv.URL+"/short"); result.Err != nil {
+		t.Fatalf("fetch: %v", result.Err)
+	}
+
+	if n, err := db.EnqueueRecrunch(database); err != nil || n != 1 {
+		t.Fatalf("expected the redirected link queued, got %d %v", n, err)
+	}
+	job, _ := db.ClaimDredgeJob(database)
+	failing := roundTripFunc(func(*http.Request) (*http.Response, error) {
+		t.Error("recrunch reached the network")
+		return nil, errors.New("offline")
+	})
+	offline := &Service{
+		db:     database,
+		client: &http.Client{Transport: &cachingTransport{base: failing, db: database}},
+		llm:    NoopSummarizer{},
+	}
+	result := offline.process(context.Background(), *job, false, nil)
+	if result.Err != nil {
+		t.Fatalf("process: %v", result.Err)
+	}
+	if result.Title != "Redirected" {
+		t.Errorf("expected title from the page redirected to, got %q", result.Title)
+	}
+}
+
+func TestPromptTestBypassesCache(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = io.WriteString(w, `<html><head><title>Preview</title></head><body><p>Body text.</p></body></html>`)
+	}))
+	defer srv.Close()
+
+	database := openTestDB(t)
+	prompts, err := LoadPrompts(t.TempDir())
+	if err != nil {
+		t.Fatalf("load prompts: %v", err)
+	}
+	s := &Service{
+		db:      database,
+		client:  &http.Client{Transport: &cachingTransport{base: srv.Client().Transport, db: database}},
+		llm:     NoopSummarizer{},
+		prompts: prompts,
+	}
+	if _, err := s.PromptTest(context.Background(), srv.URL+"/post"); err != nil {
+		t.Fatalf("prompt test: %v", err)
+	}
+	if p, _ := db.GetCachedPage(database, srv.URL+"/post"); p != nil {
+		t.Errorf("prompt test wrote to the page cache: %+v", p)
+	}
+}
+
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
@@ -0,0 +1,162 @@
+package dredge
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/alexzajac/the-dredger/internal/config"
+	"github.com/alexzajac/the-dredger/internal/db"
+	"github.com/alexzajac/the-dredger/internal/model"
+)
+
+// Checker finds saved links that no longer load. Each check records the
+// status code and time on the link and keeps a count of consecutive
+// failures; a link is flagged dead when its page is gone or the count
+// reaches the configured limit.
+type Checker struct {
+	db          *sql.DB
+	client      *http.Client
+	workers     int
+	interval    time.Duration
+	maxFailures int
+	// wayback, when set, finds archived copies of dead links.
+	wayback *Wayback
+}
+
+func NewChecker(database *sql.DB, cfg config.Config) *Checker {
+	return &Checker{
+		db: database,
+		client: &http.Client{
+			Timeout:   cfg.Dredge.Timeout,
+			Transport: newPoliteTransport(http.DefaultTransport, cfg.Dredge),
+		},
+		workers:     max(cfg.Dredge.Workers, 1),
+		interval:    cfg.Check.Interval,
+		maxFailures: max(cfg.Check.MaxFailures, 1),
+		wayback:     NewWayback(cfg),
+	}
+}
+
+// Due returns the saved links not checked within the check interval.
+func (c *Checker) Due() ([]model.Link, error) {
+	return db.GetLinksDueCheck(c.db, time.Now().Add(-c.interval))
+}
+
+// Run checks links concurrently and sends each one, with its health
+// fields updated, on the returned channel. The channel is closed once
+// every link is done or ctx is cancelled.
+func (c *Checker) Run(ctx context.Context, links []model.Link) <-chan model.Link {
+	jobs := make(chan model.Link)
+	out := make(chan model.Link, c.workers)
+	var wg sync.WaitGroup
+	for range c.workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for link := range jobs {
+				checked, err := c.Check(ctx, link)
+				if err != nil {
+					continue
+				}
+				select {
+				case out <- checked:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		defer close(out)
+		defer wg.Wait()
+		defer close(jobs)
+		for _, link := range links {
+			select {
+			case jobs <- link:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// Check requests one link, records the outcome and returns the link with
+// its health fields updated. A link found dead for the first time gets
+// the archived copy closest to when it was saved, if there is one. A check
+// interrupted by ctx is not recorded.
+func (c *Checker) Check(ctx context.Context, link model.Link) (model.Link, error) {
+	status, err := c.probe(ctx, link.URL)
+	if ctx.Err() != nil {
+		return link, ctx.Err()
+	}
+	recordCheck(&link, status, err, time.Now(), c.maxFailures)
+	if err := db.UpdateLinkCheck(c.db, link); err != nil {
+		return link, err
+	}
+	if link.Dead && link.ArchiveURL == "" && c.wayback != nil {
+		if archiveURL, err := c.wayback.Closest(ctx, link.URL, link.DateAdded); err == nil && archiveURL != "" {
+			link.ArchiveURL = archiveURL
+			return link, db.UpdateArchiveURL(c.db, link.ID, archiveURL)
+		}
+	}
+	return link, nil
+}
+
+// probe returns the status code link's URL answers with, after redirects.
+// Plenty of servers reject or mishandle HEAD, so a HEAD that fails is
+// confirmed with a GET before the link is held against it.
+func (c *Checker) probe(ctx context.Context, rawURL string) (int, error) {
+	status, err := c.request(ctx, http.MethodHead, rawURL)
+	if err == nil && status < 400 {
+		return status, nil
+	}
+	if ctx.Err() != nil {
+		return 0, ctx.Err()
+	}
+	return c.request(ctx, http.MethodGet, rawURL)
+}
+
+func (c *Checker) request(ctx context.Context, method, rawURL string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,*/*;q=0.8")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	// Only the status matters; the body is left unread.
+	_ = resp.Body.Close()
+	return resp.StatusCode, nil
+}
+
+// recordCheck updates link's health fields for a check made at now that
+// answered status or failed with err.
+func recordCheck(link *model.Link, status int, err error, now time.Time, maxFailures int) {
+	link.CheckedAt = now
+	link.CheckStatus = status
+	link.CheckError = ""
+	if err != nil {
+		link.CheckError = err.Error()
+	}
+	switch {
+	case err == nil && status < 400:
+		link.CheckFailures = 0
+		link.Dead = false
+	case status == http.StatusNotFound || status == http.StatusGone:
+		link.CheckFailures++
+		link.Dead = true
+	case status == http.StatusTooManyRequests || errors.Is(err, ErrDisallowed):
+		// Being rate limited or turned away by robots.txt says nothing
+		// about whether the page is still there.
+	default:
+		link.CheckFailures++
+		link.Dead = link.Dead || link.CheckFailures >= maxFailures
+	}
+}
@@ -0,0 +1,116 @@
+package dredge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alexzajac/the-dredger/internal/config"
+	"github.com/alexzajac/the-dredger/internal/db"
+	"github.com/alexzajac/the-dredger/internal/model"
+)
+
+func TestRecordCheck(t *testing.T) {
+	now := time.Now()
+	failing := errors.New("connection refused")
+
+	link := model.Link{}
+	for i := 1; i <= 3; i++ {
+		recordCheck(&link, 0, failing, now, 3)
+		if link.CheckFailures != i || link.Dead != (i == 3) {
+			t.Fatalf("after %d failures: failures=%d dead=%v", i, link.CheckFailures, link.Dead)
+		}
+	}
+	if link.CheckError != failing.Error() || !link.CheckedAt.Equal(now) {
+		t.Errorf("check not recorded: %+v", link)
+	}
+
+	recordCheck(&link, 200, nil, now, 3)
+	if link.Dead || link.CheckFailures != 0 || link.CheckError != "" || link.CheckStatus != 200 {
+		t.Errorf("a good check should revive the link: %+v", link)
+	}
+
+	recordCheck(&link, 410, nil, now, 3)
+	if !link.Dead {
+		t.Error("410 should mark the link dead at once")
+	}
+	recordCheck(&link, 503, nil, now, 3)
+	if !link.Dead || link.CheckFailures != 2 {
+		t.Errorf("a 503 should not revive a gone link: %+v", link)
+	}
+
+	link = model.Link{}
+	recordCheck(&link, 429, nil, now, 1)
+	if link.Dead || link.CheckFailures != 0 {
+		t.Errorf("429 should not count as a failure: %+v", link)
+	}
+}
+
+func TestCheckerRun(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok":
+		case "/moved":
+			http.Redirect(w, r, "/ok", http.StatusMovedPermanently)
+		case "/no-head":
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+			}
+		case "/broken":
+			w.WriteHeader(http.StatusInternalServerError)
+		case "/wayback/available":
+			_, _ = fmt.Fprintf(w, `{"archived_snapshots":{"closest":{"available":true,"status":"200",
+				"url":"http://web.archive.org/web/20200101000000/%s","timestamp":"20200101000000"}}}`, r.URL.Query().Get("url"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	database := openTestDB(t)
+	want := map[string]bool{"/ok": false, "/moved": false, "/no-head": false, "/broken": false, "/missing": true}
+	for path := range want {
+		if _, err := db.InsertLink(database, model.Link{URL: srv.URL + path, Status: model.Saved}); err != nil {
+			t.Fatalf("insert: %v", err)
+		}
+	}
+
+	cfg := config.Default()
+	cfg.Dredge.HostRate = 1000
+	cfg.Wayback.Endpoint = srv.URL + "/wayback/available"
+	checker := NewChecker(database, cfg)
+	due, err := checker.Due()
+	if err != nil || len(due) != len(want) {
+		t.Fatalf("due = %d links, %v", len(due), err)
+	}
+
+	checked := 0
+	for link := range checker.Run(context.Background(), due) {
+		checked++
+		path := link.URL[len(srv.URL):]
+		if link.Dead != want[path] {
+			t.Errorf("%s: dead = %v, want %v (status %d, %q)", path, link.Dead, want[path], link.CheckStatus, link.CheckError)
+		}
+		if path == "/broken" && (link.CheckStatus != 500 || link.CheckFailures != 1) {
+			t.Errorf("/broken: status %d, failures %d", link.CheckStatus, link.CheckFailures)
+		}
+		if wantArchive := want[path]; (link.ArchiveURL != "") != wantArchive {
+			t.Errorf("%s: archive url = %q", path, link.ArchiveURL)
+		}
+	}
+	if checked != len(want) {
+		t.Errorf("checked %d links, want %d", checked, len(want))
+	}
+
+	if due, _ := checker.Due(); len(due) != 0 {
+		t.Errorf("%d links still due after checking", len(due))
+	}
+	dead, _ := db.GetDeadLinks(database)
+	if len(dead) != 1 || dead[0].ArchiveURL != "https://web.archive.org/web/20200101000000/"+srv.URL+"/missing" {
+		t.Errorf("dead links = %+v", dead)
+	}
+}
@@ -0,0 +1,338 @@
+package dredge
+
+import (
+	"io"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// charsPerToken is a rough average for English prose, used to turn the
+// llm.max_content_tokens budget into a character count.
+const charsPerToken = 4
+
+// Tags whose content is never part of the article.
+var junkTags = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Nav: true,
+	atom.Footer: true, atom.Header: true, atom.Aside: true, atom.Form: true,
+	atom.Iframe: true, atom.Svg: true, atom.Button: true, atom.Template: true,
+	atom.Select: true, atom.Object: true, atom.Embed: true,
+}
+
+// Tags that start a new block when rendering text.
+var blockTags = map[atom.Atom]bool{
+	atom.P: true, atom.Div: true, atom.Section: true, atom.Article: true,
+	atom.Main: true, atom.H1: true, atom.H2: true, atom.H3: true, atom.H4: true,
+	atom.H5: true, atom.H6: true, atom.Ul: true, atom.Ol: true, atom.Li: true,
+	atom.Pre: true, atom.Blockquote: true, atom.Table: true, atom.Tr: true,
+	atom.Td: true, atom.Th: true, atom.Dl: true, atom.Dt: true, atom.Dd: true,
+	atom.Figure: true, atom.Figcaption: true, atom.Hr: true, atom.Br: true,
+	atom.Tbody: true, atom.Thead: true,
+}
+
+var (
+	negativeClass = regexp.MustCompile(`(?i)comment|sidebar|footer|foot|nav|menu|share|social|promo|related|advert|sponsor|cookie|banner|subscribe|newsletter|popup|modal|breadcrumb|masthead|widget`)
+	positiveClass = regexp.MustCompile(`(?i)article|content|entry|main|post|story|body|text|blog`)
+)
+
+// ExtractContent finds the main article in an HTML page and returns it as
+// lightly formatted text: "#" headings, "-" list items and blank lines
+// between paragraphs. Navigation, footers, scripts and other boilerplate
+// are dropped. It returns "" when the page has no readable text.
+func ExtractContent(body io.Reader) string {
+	doc, err := html.Parse(body)
+	if err != nil {
+		return ""
+	}
+	stripJunk(doc)
+
+	root := bestCandidate(doc)
+	if root == nil {
+		return ""
+	}
+	var r textRenderer
+	r.render(root, 0)
+	r.flush()
+	return r.String()
+}
+
+// stripJunk removes boilerplate elements in place.
+func stripJunk(n *html.Node) {
+	for c := n.FirstChild; c != nil; {
+		next := c.NextSibling
+		if c.Type == html.CommentNode || (c.Type == html.ElementNode && isJunk(c)) {
+			n.RemoveChild(c)
+		} else {
+			stripJunk(c)
+		}
+		c = next
+	}
+}
+
+func isJunk(n *html.Node) bool {
+	if junkTags[n.DataAtom] {
+		return true
+	}
+	if n.DataAtom == atom.Body || n.DataAtom == atom.Html ||
+		n.DataAtom == atom.Article || n.DataAtom == atom.Main {
+		return false
+	}
+	if attr(n, "aria-hidden") == "true" || hasAttr(n, "hidden") {
+		return true
+	}
+	switch attr(n, "role") {
+	case "navigation", "banner", "contentinfo", "complementary", "dialog":
+		return true
+	}
+	classID := attr(n, "class") + " " + attr(n, "id")
+	return negativeClass.MatchString(classID) && !positiveClass.MatchString(classID)
+}
+
+// bestCandidate scores each paragraph's ancestors by the amount of prose
+// beneath them and returns the highest scoring container, falling back to
+// <body>.
+func bestCandidate(doc *html.Node) *html.Node {
+	scores := make(map[*html.Node]float64)
+	var order []*html.Node
+
+	add := func(n *html.Node, score float64) {
+		if n == nil || n.Type != html.ElementNode {
+			return
+		}
+		if _, ok := scores[n]; !ok {
+			scores[n] = classWeight(n)
+			order = append(order, n)
+		}
+		scores[n] += score
+	}
+
+	var body *html.Node
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.DataAtom {
+			case atom.Body:
+				body = n
+			case atom.P, atom.Pre, atom.Blockquote, atom.Td, atom.Li:
+				text := collapseSpace(textOf(n))
+				if len(text) >= 25 {
+					score := 1 + float64(strings.Count(text, ",")) + min(float64(len(text))/100, 3)
+					add(n.Parent, score)
+					if n.Parent != nil {
+						add(n.Parent.Parent, score/2)
+					}
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(doc)
+
+	var best *html.Node
+	var bestScore float64
+	for _, n := range order {
+		score := scores[n] * (1 - linkDensity(n))
+		if best == nil || score > bestScore {
+			best, bestScore = n, score
+		}
+	}
+	if best == nil {
+		return body
+	}
+	return best
+}
+
+func classWeight(n *html.Node) float64 {
+	var w float64
+	switch n.DataAtom {
+	case atom.Article, atom.Main:
+		w += 10
+	}
+	classID := attr(n, "class") + " " + attr(n, "id")
+	if positiveClass.MatchString(classID) {
+		w += 25
+	}
+	if negativeClass.MatchString(classID) {
+		w -= 25
+	}
+	return w
+}
+
+// linkDensity is the share of n's text that sits inside links.
+func linkDensity(n *html.Node) float64 {
+	total := len(collapseSpace(textOf(n)))
+	if total == 0 {
+		return 0
+	}
+	var linked int
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.ElementNode && n.DataAtom == atom.A {
+			linked += len(collapseSpace(textOf(n)))
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return float64(linked) / float64(total)
+}
+
+// textRenderer turns a DOM subtree into paragraphs, headings and list
+// items.
+type textRenderer struct {
+	blocks []textBlock
+	inline strings.Builder
+}
+
+type textBlock struct {
+	text     string
+	listItem bool
+}
+
+func (r *textRenderer) render(n *html.Node, depth int) {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		switch {
+		case c.Type == html.TextNode:
+			r.inline.WriteString(c.Data)
+		case c.Type != html.ElementNode:
+		case !blockTags[c.DataAtom]:
+			r.inline.WriteString(textOf(c))
+		default:
+			r.flush()
+			r.renderBlock(c, depth)
+		}
+	}
+}
+
+func (r *textRenderer) renderBlock(n *html.Node, depth int) {
+	switch n.DataAtom {
+	case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+		level := int(n.Data[1] - '0')
+		r.add(strings.Repeat("#", level)+" ", collapseSpace(textOf(n)), false)
+	case atom.Pre:
+		if text := strings.Trim(textOf(n), "\n"); strings.TrimSpace(text) != "" {
+			r.blocks = append(r.blocks, textBlock{text: text})
+		}
+	case atom.Ul, atom.Ol:
+		r.render(n, depth+1)
+	case atom.Li:
+		// The item's own text becomes the bullet; nested lists follow it.
+		indent := strings.Repeat("  ", max(depth-1, 0))
+		var sub textRenderer
+		sub.render(n, depth)
+		sub.flush()
+		for i, b := range sub.blocks {
+			if i == 0 && !b.listItem {
+				r.add(indent+"- ", b.text, true)
+				continue
+			}
+			r.blocks = append(r.blocks, b)
+		}
+	case atom.Blockquote:
+		var sub textRenderer
+		sub.render(n, depth)
+		sub.flush()
+		for _, b := range sub.blocks {
+			r.add("> ", b.text, b.listItem)
+		}
+	case atom.Br, atom.Hr:
+	default:
+		r.render(n, depth)
+		r.flush()
+	}
+}
+
+// flush ends the current run of inline text as a paragraph.
+func (r *textRenderer) flush() {
+	r.add("", collapseSpace(r.inline.String()), false)
+	r.inline.Reset()
+}
+
+func (r *textRenderer) add(prefix, text string, listItem bool) {
+	if text == "" {
+		return
+	}
+	r.blocks = append(r.blocks, textBlock{text: prefix + text, listItem: listItem})
+}
+
+func (r *textRenderer) String() string {
+	var b strings.Builder
+	for i, block := range r.blocks {
+		if i > 0 {
+			// Keep list items together; separate everything else by a blank line.
+			if block.listItem && r.blocks[i-1].listItem {
+				b.WriteString("\n")
+			} else {
+				b.WriteString("\n\n")
+			}
+		}
+		b.WriteString(block.text)
+	}
+	return b.String()
+}
+
+// Excerpt trims text to roughly maxTokens, preferring to cut at a
+// paragraph and then a word boundary. A non-positive budget yields "".
+func Excerpt(text string, maxTokens int) string {
+	limit := maxTokens * charsPerToken
+	if limit <= 0 {
+		return ""
+	}
+	if len(text) <= limit {
+		return text
+	}
+	cut := text[:limit]
+	if i := strings.LastIndex(cut, "\n\n"); i > limit/2 {
+		cut = cut[:i]
+	} else if i := strings.LastIndexAny(cut, " \n"); i > limit/2 {
+		cut = cut[:i]
+	} else {
+		for len(cut) > 0 && !utf8.RuneStart(text[len(cut)]) {
+			cut = cut[:len(cut)-1]
+		}
+	}
+	return strings.TrimSpace(cut) + " …"
+}
+
+func textOf(n *html.Node) string {
+	if n.Type == html.TextNode {
+		return n.Data
+	}
+	var b strings.Builder
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		b.WriteString(textOf(c))
+		if c.Type == html.ElementNode && blockTags[c.DataAtom] {
+			b.WriteByte(' ')
+		}
+	}
+	return b.String()
+}
+
+func collapseSpace(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+func hasAttr(n *html.Node, key string) bool {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
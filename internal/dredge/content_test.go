@@ -0,0 +1,81 @@
+package dredge
+
+import (
+	"strings"
+	"testing"
+)
+
+const articlePage = `<!DOCTYPE html>
+<html>
+<head><title>Why SQLite</title><script>var tracking = "nope";</script></head>
+<body>
+  <header><a href="/">Home</a> <a href="/about">About</a></header>
+  <nav><ul><li><a href="/a">Archive of everything we have ever written</a></li></ul></nav>
+  <div class="sidebar-widget"><p>Subscribe to the newsletter for weekly updates, tips and more.</p></div>
+  <article class="post">
+    <h1>Why   SQLite</h1>
+    <p>SQLite is an embedded database, which means it runs inside your process, with no server to manage.</p>
+    <h2>Trade-offs</h2>
+    <ul>
+      <li>One writer at a time</li>
+      <li>Great read performance
+        <ul><li>Especially with WAL</li></ul>
+      </li>
+    </ul>
+    <p>It is a good default for <a href="/cli">command line tools</a>, desktop apps, and small services.</p>
+    <blockquote><p>Small. Fast. Reliable.</p></blockquote>
+  </article>
+  <footer><p>Copyright 2024, all rights reserved, do not copy this page please.</p></footer>
+</body>
+</html>`
+
+func TestExtractContent(t *testing.T) {
+	got := ExtractContent(strings.NewReader(articlePage))
+
+	want := `# Why SQLite
+
+SQLite is an embedded database, which means it runs inside your process, with no server to manage.
+
+## Trade-offs
+
+- One writer at a time
+- Great read performance
+  - Especially with WAL
+
+It is a good default for command line tools, desktop apps, and small services.
+
+> Small. Fast. Reliable.`
+	if got != want {
+		t.Errorf("ExtractContent:\n%s\n--- want ---\n%s", got, want)
+	}
+}
+
+func TestExtractContentNoArticle(t *testing.T) {
+	got := ExtractContent(strings.NewReader(`<html><body><div>Just a short line<br>and another</div></body></html>`))
+	if got != "Just a short line\n\nand another" {
+		t.Errorf("got %q", got)
+	}
+	if got := ExtractContent(strings.NewReader(`<html><body><script>x()</script></body></html>`)); got != "" {
+		t.Errorf("expected no text, got %q", got)
+	}
+}
+
+func TestExcerpt(t *testing.T) {
+	text := strings.Repeat("word ", 30) + "\n\n" + strings.Repeat("more ", 30)
+	if got := Excerpt(text, 1000); got != text {
+		t.Error("text within budget should be unchanged")
+	}
+	if got := Excerpt(text, 0); got != "" {
+		t.Errorf("zero budget should give no text, got %q", got)
+	}
+
+	got := Excerpt(text, 50) // 200 chars: cut at the paragraph break
+	if got != strings.TrimSpace(strings.Repeat("word ", 30))+" …" {
+		t.Errorf("got %q", got)
+	}
+
+	got = Excerpt(strings.Repeat("abcd ", 100), 10)
+	if len(got) > 40+len(" …") || !strings.HasSuffix(got, "abcd …") {
+		t.Errorf("expected a word-boundary cut, got %q", got)
+	}
+}
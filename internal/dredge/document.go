@@ -0,0 +1,170 @@
+package dredge
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html/charset"
+)
+
+// sniffLen is how much of a body http.DetectContentType looks at, and all
+// we read of images, audio and video.
+const sniffLen = 512
+
+// Content types stored on links and used to pick a parser.
+const (
+	TypeHTML = "text/html"
+	TypePDF  = "application/pdf"
+)
+
+// sniffContentType returns the media type of a response, without
+// parameters. The Content-Type header is trusted unless it is missing or
+// generic, in which case the first bytes of the body decide.
+func sniffContentType(header string, head []byte) string {
+	mediaType, _, err := mime.ParseMediaType(header)
+	if err != nil || mediaType == "" || mediaType == "application/octet-stream" || mediaType == "binary/octet-stream" {
+		mediaType, _, _ = mime.ParseMediaType(http.DetectContentType(head))
+	}
+	mediaType = strings.ToLower(mediaType)
+	if mediaType == "application/xhtml+xml" {
+		return TypeHTML
+	}
+	return mediaType
+}
+
+// isMedia reports whether a content type is a binary format we only
+// describe from its headers and URL, so the body need not be downloaded.
+func isMedia(contentType string) bool {
+	return strings.HasPrefix(contentType, "image/") ||
+		strings.HasPrefix(contentType, "video/") ||
+		strings.HasPrefix(contentType, "audio/")
+}
+
+// parseDocument extracts metadata and readable text from a fetched body
+// according to its content type. resp supplies the headers: charset, size,
+// filename and modification time.
+func parseDocument(pageURL, contentType string, resp *http.Response, body []byte) (PageMeta, string) {
+	var meta PageMeta
+	var content string
+
+	switch {
+	case contentType == TypeHTML:
+		decoded := decodeText(body, resp.Header.Get("Content-Type"))
+		meta = ScrapeMetadata(bytes.NewReader(decoded))
+		meta.resolveRefs(pageURL)
+		content = ExtractContent(bytes.NewReader(decoded))
+	case contentType == TypePDF:
+		doc := ParsePDF(body)
+		meta = PageMeta{
+			Author:    doc.Author,
+			Published: doc.Created,
+			Modified:  doc.Modified,
+			Type:      "pdf",
+		}
+		meta.Title = firstNonEmpty(doc.Title, firstLine(doc.Text), fileName(pageURL))
+		content = doc.Text
+	case strings.HasPrefix(contentType, "text/"):
+		text := string(decodeText(body, resp.Header.Get("Content-Type")))
+		meta.Title = firstNonEmpty(firstLine(text), fileName(pageURL))
+		content = strings.TrimSpace(text)
+	default:
+		meta = mediaMeta(pageURL, contentType, resp)
+	}
+
+	meta.ContentType = contentType
+	return meta, content
+}
+
+// decodeText converts body to UTF-8 using a byte order mark, the charset
+// in the Content-Type header or a <meta charset> tag. Undeclared bodies
+// that are not valid UTF-8 are read as windows-1252, as browsers do.
+func decodeText(body []byte, contentTypeHeader string) []byte {
+	enc, name, certain := charset.DetermineEncoding(body, contentTypeHeader)
+	if name == "utf-8" || (!certain && utf8.Valid(body)) {
+		return body
+	}
+	decoded, err := enc.NewDecoder().Bytes(body)
+	if err != nil {
+		return body
+	}
+	return decoded
+}
+
+// mediaMeta describes an image, audio or video file from its URL and
+// response headers.
+func mediaMeta(pageURL, contentType string, resp *http.Response) PageMeta {
+	meta := PageMeta{Title: fileName(pageURL)}
+
+	kind, subtype, _ := strings.Cut(contentType, "/")
+	desc := strings.ToUpper(strings.TrimPrefix(subtype, "x-")) + " " + kind
+	if size, err := strconv.ParseInt(resp.Header.Get("Content-Length"), 10, 64); err == nil && size > 0 {
+		desc += ", " + formatBytes(size)
+	}
+	meta.Description = desc
+
+	if _, params, err := mime.ParseMediaType(resp.Header.Get("Content-Disposition")); err == nil && params["filename"] != "" {
+		meta.Title = params["filename"]
+	}
+	if t, err := http.ParseTime(resp.Header.Get("Last-Modified")); err == nil {
+		meta.Modified = t
+	}
+	switch kind {
+	case "image":
+		meta.Image = pageURL
+		meta.Type = "image"
+	case "video", "audio":
+		meta.Type = kind
+	}
+	return meta
+}
+
+// fileName returns the unescaped last path segment of a URL, or its host
+// when the path is empty.
+func fileName(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	name := path.Base(u.Path)
+	if name == "/" || name == "." {
+		return u.Hostname()
+	}
+	if unescaped, err := url.PathUnescape(name); err == nil {
+		name = unescaped
+	}
+	return name
+}
+
+// firstLine returns the first non-blank line of text if it is short enough
+// to serve as a title.
+func firstLine(text string) string {
+	for line := range strings.Lines(text) {
+		if line = strings.TrimSpace(line); line != "" {
+			if len(line) > 200 {
+				return ""
+			}
+			return line
+		}
+	}
+	return ""
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,135 @@
+package dredge
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// buildPDF assembles a minimal PDF with an Info dictionary and one
+// Flate-compressed page content stream.
+func buildPDF(t *testing.T, info, content string) []byte {
+	t.Helper()
+	var z bytes.Buffer
+	w := zlib.NewWriter(&z)
+	_, _ = w.Write([]byte(content))
+	_ = w.Close()
+
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.4\n")
+	b.WriteString("1 0 obj\n<< /Type /Catalog /Outlines 5 0 R >>\nendobj\n")
+	b.WriteString("5 0 obj\n<< /Title (Chapter 1 bookmark) >>\nendobj\n")
+	fmt.Fprintf(&b, "4 0 obj\n<< /Length %d /Filter /FlateDecode >>\nstream\n", z.Len())
+	b.Write(z.Bytes())
+	b.WriteString("\nendstream\nendobj\n")
+	b.WriteString("9 0 obj\n" + info + "\nendobj\n")
+	b.WriteString("trailer\n<< /Root 1 0 R /Info 9 0 R >>\n%%EOF\n")
+	return b.Bytes()
+}
+
+func TestParsePDF(t *testing.T) {
+	data := buildPDF(t,
+		`<< /Title (Attention Is All You Need) /Author <FEFF0041006400E0> /CreationDate (D:20170612173025+02'00') >>`,
+		"BT /F1 12 Tf 72 720 Td (Abstract) Tj 0 -14 Td [(The domi) -20 (nant models \\(2017\\)) ] TJ ET")
+
+	doc := ParsePDF(data)
+	if doc.Title != "Attention Is All You Need" {
+		t.Errorf("Title = %q", doc.Title)
+	}
+	if doc.Author != "Adà" {
+		t.Errorf("Author = %q", doc.Author)
+	}
+	if got := doc.Created.UTC().Format("2006-01-02 15:04"); got != "2017-06-12 15:30" {
+		t.Errorf("Created = %s", got)
+	}
+	if doc.Text != "Abstract\nThe dominant models (2017)" {
+		t.Errorf("Text = %q", doc.Text)
+	}
+}
+
+func TestDecodeText(t *testing.T) {
+	latin1 := []byte("<title>Caf\xe9 cr\xe8me</title>")
+	if got := string(decodeText(latin1, "text/html; charset=ISO-8859-1")); got != "<title>Café crème</title>" {
+		t.Errorf("latin-1: %q", got)
+	}
+
+	// 日本語 in Shift-JIS, declared only by a <meta> tag.
+	sjis := []byte("<meta charset=\"shift_jis\"><title>\x93\xfa\x96\x7b\x8c\xea</title>")
+	if got := string(decodeText(sjis, "text/html")); !strings.Contains(got, "<title>日本語</title>") {
+		t.Errorf("shift-jis: %q", got)
+	}
+
+	// Undeclared but valid UTF-8 is left alone rather than read as windows-1252.
+	utf := []byte(strings.Repeat("a", 2000) + "<p>naïve</p>")
+	if got := decodeText(utf, "text/html"); !bytes.Equal(got, utf) {
+		t.Error("valid UTF-8 should not be re-decoded")
+	}
+}
+
+func TestSniffContentType(t *testing.T) {
+	cases := []struct {
+		header string
+		head   []byte
+		want   string
+	}{
+		{"text/html; charset=utf-8", nil, TypeHTML},
+		{"application/xhtml+xml", nil, TypeHTML},
+		{"", []byte("%PDF-1.7\n"), TypePDF},
+		{"application/octet-stream", []byte("\x89PNG\r\n\x1a\n"), "image/png"},
+		{"Video/MP4", nil, "video/mp4"},
+	}
+	for _, c := range cases {
+		if got := sniffContentType(c.header, c.head); got != c.want {
+			t.Errorf("sniffContentType(%q) = %q, want %q", c.header, got, c.want)
+		}
+	}
+}
+
+func TestFetchOneContentTypes(t *testing.T) {
+	pdf := buildPDF(t, `<< /Title (A Paper) >>`, "BT (First page text) Tj ET")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/latin1":
+			w.Header().Set("Content-Type", "text/html; charset=windows-1252")
+			_, _ = w.Write([]byte("<html><head><title>R\xe9sum\xe9</title></head><body><p>Caf\xe9 au lait is served with breakfast every day.</p></body></html>"))
+		case "/files/paper.pdf":
+			w.Header().Set("Content-Type", "application/pdf")
+			_, _ = w.Write(pdf)
+		case "/img/My Cat.jpg":
+			w.Header().Set("Content-Type", "image/jpeg")
+			w.Header().Set("Content-Length", "2048")
+			w.Header().Set("Last-Modified", "Wed, 21 Oct 2015 07:28:00 GMT")
+			_, _ = w.Write(bytes.Repeat([]byte{0xff}, 2048))
+		}
+	}))
+	defer srv.Close()
+
+	s := &Service{client: srv.Client()}
+	ctx := context.Background()
+
+	html := s.fetchOne(ctx, 1, srv.URL+"/latin1")
+	if html.Err != nil || html.Title != "Résumé" || html.Meta.ContentType != TypeHTML {
+		t.Errorf("html: %+v", html)
+	}
+	if !strings.Contains(html.Content, "Café au lait") {
+		t.Errorf("html content: %q", html.Content)
+	}
+
+	paper := s.fetchOne(ctx, 2, srv.URL+"/files/paper.pdf")
+	if paper.Title != "A Paper" || paper.Content != "First page text" || paper.Meta.Type != "pdf" {
+		t.Errorf("pdf: %+v", paper)
+	}
+
+	img := s.fetchOne(ctx, 3, srv.URL+"/img/My%20Cat.jpg")
+	if img.Title != "My Cat.jpg" || img.Description != "JPEG image, 2.0 KB" || img.Meta.ContentType != "image/jpeg" {
+		t.Errorf("image: %+v", img)
+	}
+	if img.Meta.Image != srv.URL+"/img/My%20Cat.jpg" || img.Meta.Modified.Year() != 2015 {
+		t.Errorf("image meta: %+v", img.Meta)
+	}
+}
@@ -1,15 +1,18 @@
 package dredge
 
 import (
+	"bufio"
 	"context"
 	"database/sql"
 	"fmt"
 	"io"
 	"math/rand/v2"
 	"net/http"
+	"slices"
 	"sync"
 	"time"
 
+	"github.com/alexzajac/the-dredger/internal/config"
 	"github.com/alexzajac/the-dredger/internal/db"
 	"github.com/alexzajac/the-dredger/internal/model"
 )
@@ -21,89 +24,160 @@ type Result struct {
 	Summary     string
 	Tags        []string
 	Comments    []string
-	Err         error
+	// Meta is everything ScrapeMetadata found on the page.
+	Meta PageMeta
+	// Content is the page's main text, as extracted by ExtractContent.
+	Content string
+	// SeedTags come from the resolver, e.g. a repository's topics.
+	SeedTags []string
+	// Details are kind-specific facts from the resolver.
+	Details model.Details
+	// ResolvedURL is where the link's URL redirected to, if anywhere else,
+	// and RedirectChain the URLs visited on the way.
+	ResolvedURL   string
+	RedirectChain []string
+	// ArchiveURL is the archived copy found for a link that capsized
+	// because its page has gone.
+	ArchiveURL string
+	Err        error
+	// Class classifies Err.
+	Class model.ErrorClass
+	// RetryAt is set when a transient failure has been queued to retry.
+	RetryAt time.Time
 }
 
+// link returns the crawled fields of r as a link for db.UpdateDredgeResult.
+func (r Result) link() model.Link {
+	l := model.Link{ID: r.LinkID, Summary: r.Summary, Tags: r.Tags, Details: r.Details}
+	l.ResolvedURL, l.RedirectChain = r.ResolvedURL, r.RedirectChain
+	r.Meta.Apply(&l)
+	l.Title, l.Description = r.Title, r.Description
+	return l
+}
+
+// maxBodyBytes bounds how much of a page is read; long articles can run
+// well past the first megabyte.
+const maxBodyBytes = 8 << 20
+
 type Service struct {
-	db      *sql.DB
-	client  *http.Client
-	ollama  *OllamaClient
-	workers int
-	results chan Result
+	db       *sql.DB
+	client   *http.Client
+	llm      Summarizer
+	prompts  *Prompts
+	tags     config.TagsConfig
+	workers  int
+	delayMin time.Duration
+	delayMax time.Duration
+	results  chan Result
+	// resolvers replace the package defaults when set.
+	resolvers []Resolver
+	// wayback, when set, finds archived copies of pages that have gone.
+	wayback *Wayback
+	// archiver, when set, keeps offline copies of saved links.
+	archiver *Archiver
+
+	// maxContentTokens is the page text budget for each prompt.
+	maxContentTokens int
+	// maxAttempts and retryBase control retries of transient failures.
+	maxAttempts int
+	retryBase   time.Duration
+	// cacheMaxAge and cacheMaxBytes bound the page cache.
+	cacheMaxAge   time.Duration
+	cacheMaxBytes int64
 }
 
-func NewService(database *sql.DB, workers int) *Service {
+func NewService(database *sql.DB, cfg config.Config) (*Service, error) {
+	prompts, err := LoadPrompts(cfg.LLM.PromptsDir)
+	if err != nil {
+		return nil, err
+	}
+	var archiver *Archiver
+	if cfg.Archive.Enabled {
+		if archiver, err = NewArchiver(database, cfg); err != nil {
+			return nil, err
+		}
+	}
+	workers := max(cfg.Dredge.Workers, 1)
 	return &Service{
 		db: database,
 		client: &http.Client{
-			Timeout: 10 * time.Second,
+			Timeout: cfg.Dredge.Timeout,
+			Transport: &cachingTransport{
+				base: newPoliteTransport(http.DefaultTransport, cfg.Dredge),
+				db:   database,
+			},
 		},
-		ollama:  NewOllamaClient("", ""),
-		workers: workers,
-		results: make(chan Result, workers*2),
-	}
+		llm:              NewSummarizer(cfg.LLM),
+		prompts:          prompts,
+		tags:             cfg.Tags,
+		workers:          workers,
+		delayMin:         cfg.Dredge.DelayMin,
+		delayMax:         cfg.Dredge.DelayMax,
+		results:          make(chan Result, workers*2),
+		maxContentTokens: cfg.LLM.MaxContentTokens,
+		maxAttempts:      max(cfg.Dredge.MaxAttempts, 1),
+		retryBase:        cfg.Dredge.RetryBase,
+		cacheMaxAge:      cfg.Dredge.CacheMaxAge,
+		cacheMaxBytes:    cfg.Dredge.CacheMaxBytes,
+		resolvers: append(slices.Clip(resolvers),
+			&GitHubResolver{APIBase: cfg.GitHub.APIBase, Token: cfg.GitHub.Token}),
+		wayback:  NewWayback(cfg),
+		archiver: archiver,
+	}, nil
 }
 
 func (s *Service) Results() <-chan Result {
 	return s.results
 }
 
-func (s *Service) Run(ctx context.Context, links []model.Link) {
-	if len(links) == 0 {
-		close(s.results)
-		return
-	}
-
-	type job struct {
-		id  int64
-		url string
-	}
-
-	jobs := make(chan job, len(links))
-	for _, l := range links {
-		jobs <- job{id: l.ID, url: l.URL}
-	}
-	close(jobs)
-
-	ollamaAvailable := s.ollama.Ping()
+// Run drains the dredge job queue: each worker claims due jobs from the
+// database until none are left or ctx is cancelled, sending one Result per
+// job attempt. Workers wait for jobs that are backing off after a transient
+// failure, and retry claiming jobs when the queue cannot be read, sending a
+// Result without a LinkID for each failure. Results is closed when every
+// worker has stopped. Jobs interrupted by cancellation go back to the
+// queue.
+func (s *Service) Run(ctx context.Context) {
+	// Jobs left running by a process that died go back to the queue.
+	_, _ = db.ReclaimDredgeJobs(s.db)
+	_, _ = db.PruneCachedPages(s.db, s.cacheMaxAge, s.cacheMaxBytes)
+	llmAvailable := s.llm.Ping()
+	vocabulary := s.vocabulary()
 
 	var wg sync.WaitGroup
 	for range s.workers {
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			for j := range jobs {
-				if ctx.Err() != nil {
-					return
+			claimFailures := 0
+			for ctx.Err() == nil {
+				job, err := db.ClaimDredgeJob(s.db)
+				if err != nil {
+					// The database may be busy, e.g. with another dredger
+					// working the same queue: report it and try again.
+					claimFailures++
+					if !s.reportClaimError(ctx, err, claimFailures) {
+						return
+					}
+					continue
 				}
-
-				// Set state to crawling
-				_ = db.UpdateDredgeState(s.db, j.id, model.DredgeCrawling, "")
-
-				delay := time.Duration(200+rand.IntN(600)) * time.Millisecond
-				time.Sleep(delay)
-
-				result := s.fetchOne(ctx, j.id, j.url)
-				if result.Err != nil {
-					_ = db.UpdateDredgeState(s.db, j.id, model.DredgeCapsized, fmt.Sprintf("crawl: %s", result.Err.Error()))
-				} else if !ollamaAvailable {
-					// Ollama not running — save crawl data, skip crunch
-					_ = db.UpdateDredgeResult(s.db, j.id, result.Title, result.Description, "", nil)
-				} else {
-					// Crunching phase: LLM summarization
-					_ = db.UpdateDredgeState(s.db, j.id, model.DredgeCrunching, "")
-					summary, tags, err := s.ollama.Summarize(ctx, result.Title, result.Description, j.url, result.Comments)
-					if err != nil {
-						// Crawl succeeded but crunch failed — still save crawl data
-						_ = db.UpdateDredgeResult(s.db, j.id, result.Title, result.Description, "", nil)
-						_ = db.UpdateDredgeState(s.db, j.id, model.DredgeCapsized, err.Error())
-						result.Err = err
-					} else {
-						result.Summary = summary
-						result.Tags = tags
-						_ = db.UpdateDredgeResult(s.db, j.id, result.Title, result.Description, summary, tags)
+				claimFailures = 0
+				if job == nil {
+					if !s.waitForRetry(ctx) {
+						return
 					}
+					continue
+				}
+
+				release := s.holdLease(job.ID)
+				result := s.process(ctx, *job, llmAvailable, vocabulary)
+				if ctx.Err() != nil {
+					release()
+					_ = db.RequeueDredgeJob(s.db, *job)
+					return
 				}
+				result = s.finish(ctx, *job, result)
+				release()
 
 				select {
 				case s.results <- result:
@@ -118,35 +192,416 @@ func (s *Service) Run(ctx context.Context, links []model.Link) {
 	close(s.results)
 }
 
+// reportClaimError sends a result for a failed claim of the next job and
+// waits before the next try, backing off with each failure in a row. It
+// reports whether to try again.
+func (s *Service) reportClaimError(ctx context.Context, err error, failures int) bool {
+	retryAt := time.Now().Add(min(time.Second<<min(failures, 6), time.Minute))
+	result := Result{Err: fmt.Errorf("claim dredge job: %w", err), Class: model.ErrorTransient, RetryAt: retryAt}
+	select {
+	case s.results <- result:
+	case <-ctx.Done():
+		return false
+	}
+	select {
+	case <-time.After(time.Until(retryAt)):
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// waitForRetry sleeps until the next queued job is due and reports
+// whether there is one to wait for.
+func (s *Service) waitForRetry(ctx context.Context) bool {
+	_, _ = db.ReclaimDredgeJobs(s.db)
+	next, ok, err := db.NextDredgeJobAt(s.db)
+	if err != nil || !ok {
+		return false
+	}
+	// Poll at least once a minute so that jobs queued meanwhile are not
+	// stuck behind a long backoff.
+	wait := min(max(time.Until(next), time.Second), time.Minute)
+	select {
+	case <-time.After(wait):
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// holdLease renews the lease on a job while it is being worked on, until
+// the returned function is called.
+func (s *Service) holdLease(jobID int64) (release func()) {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(db.JobLease / 3)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				_ = db.RenewDredgeJobLease(s.db, jobID)
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() { close(done) }
+}
+
+// finish records a processed job's outcome. Transient failures with
+// attempts left are queued again after a backoff; other failures leave the
+// link capsized.
+func (s *Service) finish(ctx context.Context, job model.DredgeJob, result Result) Result {
+	switch {
+	case result.Err == nil:
+		_ = db.CompleteDredgeJob(s.db, job.ID)
+	case result.Class == model.ErrorTransient && job.Attempts < s.maxAttempts:
+		result.RetryAt = time.Now().Add(backoff(s.retryBase, job.Attempts, retryAfter(result.Err)))
+		msg := fmt.Sprintf("%s (attempt %d of %d)", result.Err, job.Attempts, s.maxAttempts)
+		_ = db.RetryDredgeJob(s.db, job.ID, result.Err.Error(), result.RetryAt)
+		_ = db.UpdateDredgeFailure(s.db, job.LinkID, model.DredgeQueued, result.Class, msg)
+	default:
+		_ = db.FailDredgeJob(s.db, job.ID, result.Err.Error())
+		_ = db.UpdateDredgeFailure(s.db, job.LinkID, model.DredgeCapsized, result.Class, result.Err.Error())
+		result.ArchiveURL = s.findArchive(ctx, job, result.Err)
+	}
+	return result
+}
+
+// findArchive looks up the snapshot closest to when the link was saved
+// for a link that capsized because its page has gone, and records it.
+func (s *Service) findArchive(ctx context.Context, job model.DredgeJob, err error) string {
+	if s.wayback == nil || !pageGone(err) {
+		return ""
+	}
+	archiveURL, err := s.wayback.Closest(ctx, job.URL, job.DateAdded)
+	if err != nil || archiveURL == "" {
+		return ""
+	}
+	_ = db.UpdateArchiveURL(s.db, job.LinkID, archiveURL)
+	return archiveURL
+}
+
+// process crawls and crunches one link, recording each state change and
+// the crawled data on the link. Failures are classified in result.Class
+// and recorded by finish.
+func (s *Service) process(ctx context.Context, job model.DredgeJob, llmAvailable bool, vocabulary []string) Result {
+	// Set state to crawling
+	_ = db.UpdateDredgeState(s.db, job.LinkID, model.DredgeCrawling, "")
+
+	fetchCtx := ctx
+	if job.Mode == model.JobRecrunch {
+		fetchCtx = withOffline(ctx)
+	} else {
+		select {
+		case <-time.After(s.politeDelay()):
+		case <-ctx.Done():
+			return Result{LinkID: job.LinkID, Err: ctx.Err()}
+		}
+	}
+
+	result := s.fetchOne(fetchCtx, job.LinkID, job.URL)
+	if result.Err == nil && result.Content != "" {
+		_ = db.SaveLinkContent(s.db, job.LinkID, result.Content)
+	}
+
+	if result.Err != nil {
+		result.Class = ClassifyFetchError(result.Err)
+		result.Err = fmt.Errorf("crawl: %w", result.Err)
+		return result
+	}
+	if s.archiver != nil && job.Mode == model.JobFull && job.Status == model.Saved {
+		// A missing copy is not worth failing the dredge over; the
+		// archive command fills the gaps.
+		_, _ = s.archiver.Archive(ctx, model.Link{ID: job.LinkID, URL: job.URL, ResolvedURL: result.ResolvedURL})
+	}
+	tags := mergeTags(job.Tags, result.SeedTags)
+	if !llmAvailable {
+		// LLM not running or disabled — save crawl data, skip crunch
+		result.Tags = tags
+		_ = db.UpdateDredgeResult(s.db, result.link())
+		return result
+	}
+
+	// Crunching phase: LLM summarization
+	_ = db.UpdateDredgeState(s.db, job.LinkID, model.DredgeCrunching, "")
+	// Shortened links are summarised as the page they lead to.
+	summary, err := s.crunch(ctx, firstNonEmpty(result.ResolvedURL, job.URL), tags, vocabulary, result)
+	if err != nil {
+		// Crawl succeeded but crunch failed — save crawl data but
+		// keep any earlier summary and tags rather than blanking them
+		_ = db.UpdateLinkMeta(s.db, result.link())
+		result.Err = fmt.Errorf("crunch: %w", err)
+		result.Class = model.ErrorLLM
+		return result
+	}
+
+	accepted, suggested := TriageTags(summary.Tags, vocabulary, s.tags.NewTagThreshold)
+	result.Summary = summary.Text
+	result.Tags = mergeTags(result.SeedTags, accepted)
+	_ = db.UpdateDredgeResult(s.db, result.link())
+	if s.tags.Review && len(suggested) > 0 {
+		_ = db.AddSuggestedTags(s.db, job.LinkID, suggestionsFor(job.LinkID, suggested))
+	}
+	return result
+}
+
+// crunch renders the prompt for the link's kind and summarises it.
+func (s *Service) crunch(ctx context.Context, rawURL string, tags, vocabulary []string, crawled Result) (Summary, error) {
+	prompt, err := s.prompts.Render(DetectKind(rawURL), s.promptData(rawURL, tags, vocabulary, crawled))
+	if err != nil {
+		return Summary{}, err
+	}
+	return s.llm.Summarize(ctx, prompt)
+}
+
+func (s *Service) promptData(rawURL string, tags, vocabulary []string, crawled Result) PromptData {
+	pageText := Excerpt(crawled.Content, s.maxContentTokens)
+	if crawled.Content == crawled.Description {
+		// e.g. a paper's abstract, which is already the description
+		pageText = ""
+	}
+	return PromptData{
+		Title:        crawled.Title,
+		URL:          rawURL,
+		Description:  crawled.Description,
+		Comments:     crawled.Comments,
+		ExistingTags: tags,
+		Vocabulary:   vocabulary,
+		PageText:     pageText,
+	}
+}
+
+// vocabulary returns the library's top tags, or nil when unavailable.
+func (s *Service) vocabulary() []string {
+	if s.db == nil || s.tags.VocabularySize <= 0 {
+		return nil
+	}
+	tags, err := db.TopTags(s.db, s.tags.VocabularySize)
+	if err != nil {
+		return nil
+	}
+	return tags
+}
+
+func suggestionsFor(linkID int64, guesses []TagGuess) []model.SuggestedTag {
+	out := make([]model.SuggestedTag, len(guesses))
+	for i, g := range guesses {
+		out[i] = model.SuggestedTag{LinkID: linkID, Tag: g.Name, Confidence: g.Confidence}
+	}
+	return out
+}
+
+// PromptPreview is the outcome of PromptTest.
+type PromptPreview struct {
+	Kind     Kind
+	Template string
+	Prompt   string
+	Summary  Summary
+	// Accepted and Suggested show how Summary.Tags would be triaged.
+	Accepted  []string
+	Suggested []TagGuess
+	// LLMErr is set when the prompt rendered but summarising failed or the
+	// backend was unreachable.
+	LLMErr error
+}
+
+// PromptTest crawls rawURL and renders its prompt without writing to the
+// database, then summarises it if the LLM is reachable. It is meant for
+// iterating on prompt templates.
+func (s *Service) PromptTest(ctx context.Context, rawURL string) (PromptPreview, error) {
+	crawled := s.uncached().fetchOne(ctx, 0, rawURL)
+	if crawled.Err != nil {
+		return PromptPreview{}, fmt.Errorf("crawl: %w", crawled.Err)
+	}
+
+	rawURL = firstNonEmpty(crawled.ResolvedURL, rawURL)
+	kind := DetectKind(rawURL)
+	vocabulary := s.vocabulary()
+	prompt, err := s.prompts.Render(kind, s.promptData(rawURL, nil, vocabulary, crawled))
+	if err != nil {
+		return PromptPreview{}, err
+	}
+	preview := PromptPreview{Kind: kind, Template: s.prompts.Source(kind), Prompt: prompt}
+
+	if !s.llm.Ping() {
+		preview.LLMErr = fmt.Errorf("LLM backend unavailable")
+		return preview, nil
+	}
+	preview.Summary, preview.LLMErr = s.llm.Summarize(ctx, prompt)
+	preview.Accepted, preview.Suggested = TriageTags(preview.Summary.Tags, vocabulary, s.tags.NewTagThreshold)
+	return preview, nil
+}
+
+// uncached returns a copy of s whose requests bypass the page cache.
+func (s *Service) uncached() *Service {
+	client := *s.client
+	if t, ok := client.Transport.(*cachingTransport); ok {
+		client.Transport = t.base
+	}
+	u := *s
+	u.client = &client
+	return &u
+}
+
+// FetchText returns the readable text of link for a link that has none
+// stored: from its offline copy when there is one, otherwise from a fresh
+// crawl of the page. The text is stored for next time.
+func (s *Service) FetchText(ctx context.Context, link model.Link) (string, error) {
+	text := archivedText(link)
+	if text == "" {
+		crawled := s.fetchOne(ctx, link.ID, link.FinalURL())
+		if crawled.Err != nil {
+			return "", fmt.Errorf("crawl: %w", crawled.Err)
+		}
+		text = crawled.Content
+	}
+	if text == "" {
+		return "", nil
+	}
+	return text, db.SaveLinkContent(s.db, link.ID, text)
+}
+
+// politeDelay returns a random pause between delayMin and delayMax.
+func (s *Service) politeDelay() time.Duration {
+	spread := s.delayMax - s.delayMin
+	if spread <= 0 {
+		return s.delayMin
+	}
+	return s.delayMin + rand.N(spread)
+}
+
 func (s *Service) fetchOne(ctx context.Context, id int64, rawURL string) Result {
+	// Everything fetched for the link, from API calls to the redirects on
+	// the way to its page, is cached under it.
+	ctx = withCacheLink(ctx, id)
+
 	// Resolve aggregator URLs (e.g. HN comments) to article URLs
-	resolved := ResolveURL(s.client, rawURL)
+	list := s.resolvers
+	if list == nil {
+		list = resolvers
+	}
+	resolved := resolveWith(ctx, s.client, list, rawURL)
+	if resolved.Meta.Title != "" {
+		return describedResult(id, resolved)
+	}
 	scrapeURL := resolved.URL
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, scrapeURL, nil)
+	p, err := s.fetchPage(ctx, scrapeURL)
 	if err != nil {
-		return Result{LinkID: id, Err: fmt.Errorf("create request: %w", err)}
+		return Result{LinkID: id, Err: err}
 	}
-	req.Header.Set("User-Agent", "TheDredger/1.0")
 
-	resp, err := s.client.Do(req)
-	if err != nil {
-		return Result{LinkID: id, Err: fmt.Errorf("fetch %s: %w", scrapeURL, err)}
+	var finalURL string
+	var chain []string
+	if scrapeURL == rawURL && p.url != rawURL {
+		finalURL, chain = p.url, p.chain
+		// A shortener may lead somewhere a resolver describes better.
+		if again := resolveWith(ctx, s.client, list, finalURL); again.Meta.Title != "" {
+			result := describedResult(id, again)
+			result.ResolvedURL, result.RedirectChain = finalURL, chain
+			return result
+		}
 	}
-	defer func() { _ = resp.Body.Close() }()
 
-	limited := io.LimitReader(resp.Body, 1<<20) // 1MB
-	meta := ScrapeMetadata(limited)
+	meta, content := parseDocument(p.url, p.contentType, p.resp, p.body)
 
 	title := meta.Title
 	if title == "" {
 		title = rawURL
 	}
 
+	return Result{
+		LinkID:        id,
+		Title:         title,
+		Description:   meta.Description,
+		Comments:      resolved.Comments,
+		Meta:          meta,
+		Content:       content,
+		ResolvedURL:   finalURL,
+		RedirectChain: chain,
+	}
+}
+
+// describedResult is the result for a page that a resolver described
+// itself, e.g. from an API.
+func describedResult(id int64, resolved ResolveResult) Result {
 	return Result{
 		LinkID:      id,
-		Title:       title,
-		Description: meta.Description,
+		Title:       resolved.Meta.Title,
+		Description: resolved.Meta.Description,
 		Comments:    resolved.Comments,
+		Meta:        resolved.Meta,
+		Content:     resolved.Text,
+		SeedTags:    resolved.Tags,
+		Details:     resolved.Details,
+	}
+}
+
+// fetchedPage is a downloaded document.
+type fetchedPage struct {
+	url   string   // where the page was finally found
+	chain []string // every URL visited on the way, starting with the first
+	// resp supplies the headers; its body has been read and closed.
+	resp        *http.Response
+	contentType string
+	body        []byte
+}
+
+// fetchPage downloads rawURL, following HTTP redirects and the
+// meta-refresh and script redirects of interstitial pages. Images, audio
+// and video are sniffed but not downloaded.
+func (s *Service) fetchPage(ctx context.Context, rawURL string) (fetchedPage, error) {
+	var p fetchedPage
+	target := rawURL
+	for hop := 0; ; hop++ {
+		if err := s.fetchInto(ctx, target, &p); err != nil {
+			return p, err
+		}
+		if p.contentType != TypeHTML || hop >= maxRefreshHops {
+			return p, nil
+		}
+		next := refreshTarget(p.url, p.resp.Header.Get("Refresh"), p.body)
+		if next == "" || slices.Contains(p.chain, next) {
+			return p, nil
+		}
+		target = next
+	}
+}
+
+// fetchInto makes one request, recording the response and the URLs of any
+// HTTP redirects in p.
+func (s *Service) fetchInto(ctx context.Context, rawURL string, p *fetchedPage) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
 	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("fetch %s: %w", rawURL, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode >= 400 {
+		return newHTTPStatusError(rawURL, resp)
+	}
+
+	// Sniff the type first so that images and video are not downloaded.
+	br := bufio.NewReaderSize(resp.Body, sniffLen)
+	head, _ := br.Peek(sniffLen)
+	contentType := sniffContentType(resp.Header.Get("Content-Type"), head)
+
+	body := head
+	if !isMedia(contentType) {
+		body, err = io.ReadAll(io.LimitReader(br, maxBodyBytes))
+		if err != nil {
+			return fmt.Errorf("read %s: %w", rawURL, err)
+		}
+	}
+
+	p.url = resp.Request.URL.String()
+	p.chain = append(p.chain, redirectHops(resp)...)
+	p.resp, p.contentType, p.body = resp, contentType, body
+	return nil
 }
@@ -0,0 +1,41 @@
+package dredge
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestRunRetriesFailedClaims(t *testing.T) {
+	database := openTestDB(t)
+	s := &Service{
+		db:      database,
+		llm:     NoopSummarizer{},
+		workers: 1,
+		results: make(chan Result),
+	}
+	// A closed database fails every claim, as a locked one would.
+	_ = database.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go s.Run(ctx)
+
+	select {
+	case result := <-s.Results():
+		if result.Err == nil || result.LinkID != 0 || result.RetryAt.IsZero() {
+			t.Errorf("claim failure result = %+v, want an error to retry without a link", result)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("worker stopped without reporting the failed claim")
+	}
+
+	cancel()
+	select {
+	case _, ok := <-s.Results():
+		if ok {
+			t.Error("results still open after cancelling")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("worker kept running after cancelling")
+	}
+}
@@ -0,0 +1,131 @@
+package dredge
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alexzajac/the-dredger/internal/model"
+)
+
+// HTTPStatusError is returned when a page responds with a 4xx or 5xx
+// status.
+type HTTPStatusError struct {
+	URL        string
+	StatusCode int
+	// RetryAfter is the server's Retry-After hint, or zero.
+	RetryAfter time.Duration
+}
+
+func (e *HTTPStatusError) Error() string {
+	return fmt.Sprintf("%s returned %d %s", e.URL, e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// newHTTPStatusError builds the error for a failed response.
+func newHTTPStatusError(rawURL string, resp *http.Response) *HTTPStatusError {
+	return &HTTPStatusError{
+		URL:        rawURL,
+		StatusCode: resp.StatusCode,
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After"), time.Now()),
+	}
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds or as an
+// HTTP date.
+func parseRetryAfter(header string, now time.Time) time.Duration {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(header); err == nil {
+		return time.Duration(max(secs, 0)) * time.Second
+	}
+	if t, err := http.ParseTime(header); err == nil && t.After(now) {
+		return t.Sub(now)
+	}
+	return 0
+}
+
+// ClassifyFetchError decides whether a crawl failure is worth retrying.
+// Timeouts, dropped connections, DNS failures, 5xx and 429 are transient;
+// missing pages, other client errors, TLS failures and anything we do not
+// recognise are permanent.
+func ClassifyFetchError(err error) model.ErrorClass {
+	if err == nil {
+		return model.ErrorNone
+	}
+
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) {
+		switch code := statusErr.StatusCode; {
+		case code == http.StatusTooManyRequests, code == http.StatusRequestTimeout, code >= 500:
+			return model.ErrorTransient
+		default:
+			return model.ErrorPermanent
+		}
+	}
+
+	// TLS problems are checked before net.Error, which some of them
+	// also implement.
+	var (
+		certErr     *tls.CertificateVerificationError
+		unknownAuth x509.UnknownAuthorityError
+		hostErr     x509.HostnameError
+		invalidErr  x509.CertificateInvalidError
+		recordErr   tls.RecordHeaderError
+	)
+	if errors.As(err, &certErr) || errors.As(err, &unknownAuth) || errors.As(err, &hostErr) ||
+		errors.As(err, &invalidErr) || errors.As(err, &recordErr) {
+		return model.ErrorPermanent
+	}
+
+	var dnsErr *net.DNSError
+	var netErr net.Error
+	var opErr *net.OpError
+	switch {
+	case errors.Is(err, context.DeadlineExceeded),
+		errors.Is(err, io.ErrUnexpectedEOF),
+		errors.As(err, &dnsErr),
+		errors.As(err, &netErr) && netErr.Timeout(),
+		errors.As(err, &opErr):
+		return model.ErrorTransient
+	}
+	return model.ErrorPermanent
+}
+
+// retryAfter returns the Retry-After hint carried by err, if any.
+func retryAfter(err error) time.Duration {
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.RetryAfter
+	}
+	return 0
+}
+
+// maxBackoff caps the delay between retries.
+const maxBackoff = time.Hour
+
+// backoff returns how long to wait before retrying a job that has failed
+// attempts times: base doubled per attempt, capped at maxBackoff, then
+// jittered to between half and all of that so that a batch of failures
+// from one host does not retry in lockstep. A longer server hint wins.
+func backoff(base time.Duration, attempts int, hint time.Duration) time.Duration {
+	delay := base
+	for i := 1; i < attempts && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	delay = min(delay, maxBackoff)
+	if half := delay / 2; half > 0 {
+		delay = half + rand.N(half+1)
+	}
+	return max(delay, hint)
+}
@@ -0,0 +1,95 @@
+package dredge
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alexzajac/the-dredger/internal/model"
+)
+
+func TestClassifyFetchError(t *testing.T) {
+	cases := []struct {
+		name string
+		err  error
+		want model.ErrorClass
+	}{
+		{"nil", nil, model.ErrorNone},
+		{"404", &HTTPStatusError{StatusCode: 404}, model.ErrorPermanent},
+		{"410", &HTTPStatusError{StatusCode: 410}, model.ErrorPermanent},
+		{"403", &HTTPStatusError{StatusCode: 403}, model.ErrorPermanent},
+		{"429", &HTTPStatusError{StatusCode: 429}, model.ErrorTransient},
+		{"503", &HTTPStatusError{StatusCode: 503}, model.ErrorTransient},
+		{"wrapped 502", fmt.Errorf("crawl: %w", &HTTPStatusError{StatusCode: 502}), model.ErrorTransient},
+		{"timeout", fmt.Errorf("fetch: %w", context.DeadlineExceeded), model.ErrorTransient},
+		{"dns", &net.DNSError{Err: "no such host", Name: "example.invalid"}, model.ErrorTransient},
+		{"refused", &net.OpError{Op: "dial", Err: errors.New("connection refused")}, model.ErrorTransient},
+		{"tls", fmt.Errorf("fetch: %w", x509.UnknownAuthorityError{}), model.ErrorPermanent},
+		{"unknown", errors.New("unsupported protocol scheme"), model.ErrorPermanent},
+	}
+	for _, c := range cases {
+		if got := ClassifyFetchError(c.err); got != c.want {
+			t.Errorf("%s: got %v, want %v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	cases := map[string]time.Duration{
+		"":                              0,
+		"120":                           2 * time.Minute,
+		"-5":                            0,
+		"Fri, 01 Mar 2024 12:00:30 GMT": 30 * time.Second,
+		"Fri, 01 Mar 2024 11:00:00 GMT": 0,
+		"soon":                          0,
+	}
+	for header, want := range cases {
+		if got := parseRetryAfter(header, now); got != want {
+			t.Errorf("parseRetryAfter(%q) = %s, want %s", header, got, want)
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	base := 10 * time.Second
+	for attempts, ceiling := range map[int]time.Duration{1: 10 * time.Second, 2: 20 * time.Second, 3: 40 * time.Second, 20: maxBackoff} {
+		for range 20 {
+			d := backoff(base, attempts, 0)
+			if d < ceiling/2 || d > ceiling {
+				t.Fatalf("backoff(%d) = %s, want between %s and %s", attempts, d, ceiling/2, ceiling)
+			}
+		}
+	}
+	if d := backoff(base, 1, 5*time.Minute); d != 5*time.Minute {
+		t.Errorf("expected Retry-After to win, got %s", d)
+	}
+}
+
+func TestFetchOneStatusError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "90")
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	s := &Service{client: srv.Client()}
+	result := s.fetchOne(context.Background(), 1, srv.URL+"/down")
+
+	var statusErr *HTTPStatusError
+	if !errors.As(result.Err, &statusErr) {
+		t.Fatalf("expected HTTPStatusError, got %v", result.Err)
+	}
+	if statusErr.StatusCode != http.StatusServiceUnavailable || statusErr.RetryAfter != 90*time.Second {
+		t.Errorf("unexpected error %+v", statusErr)
+	}
+	if ClassifyFetchError(result.Err) != model.ErrorTransient {
+		t.Error("expected 503 to be transient")
+	}
+}
@@ -0,0 +1,279 @@
+package dredge
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// maxJSONBytes bounds the discussion JSON read by the forum resolvers.
+const maxJSONBytes = 4 << 20
+
+// getJSON fetches rawURL and decodes the JSON response into v.
+func getJSON(ctx context.Context, client *http.Client, rawURL string, v any) error {
+	return getBody(ctx, client, rawURL, "application/json", nil, func(body io.Reader) error {
+		if err := json.NewDecoder(io.LimitReader(body, maxJSONBytes)).Decode(v); err != nil {
+			return fmt.Errorf("decode %s: %w", rawURL, err)
+		}
+		return nil
+	})
+}
+
+// getBody requests rawURL from an API with the given Accept and extra
+// headers, and hands the body of a 200 response to read.
+func getBody(ctx context.Context, client *http.Client, rawURL, accept string, header http.Header, read func(io.Reader) error) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Accept", accept)
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("fetch %s: %w", rawURL, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return newHTTPStatusError(rawURL, resp)
+	}
+	return read(resp.Body)
+}
+
+// topComments returns the text of up to maxComments comments, highest
+// score first, skipping empty ones.
+func topComments(texts []string, scores []int) []string {
+	order := make([]int, len(texts))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int { return scores[b] - scores[a] })
+
+	var comments []string
+	for _, i := range order {
+		text := strings.TrimSpace(texts[i])
+		if text == "" || text == "[deleted]" || text == "[removed]" {
+			continue
+		}
+		comments = append(comments, clipComment(text))
+		if len(comments) >= maxComments {
+			break
+		}
+	}
+	return comments
+}
+
+// RedditResolver resolves Reddit link posts to the article they link,
+// using the thread's .json endpoint.
+type RedditResolver struct{}
+
+var redditThreadRe = regexp.MustCompile(`^(/r/[^/]+)?/comments/[a-z0-9]+`)
+
+func (r *RedditResolver) Match(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	host := strings.TrimPrefix(u.Hostname(), "www.")
+	switch host {
+	case "reddit.com", "old.reddit.com", "new.reddit.com", "np.reddit.com":
+		return redditThreadRe.MatchString(u.Path)
+	}
+	return false
+}
+
+type redditListing struct {
+	Data struct {
+		Children []struct {
+			Kind string `json:"kind"`
+			Data struct {
+				URL      string `json:"url"`
+				IsSelf   bool   `json:"is_self"`
+				Body     string `json:"body"`
+				Score    int    `json:"score"`
+				Stickied bool   `json:"stickied"`
+			} `json:"data"`
+		} `json:"children"`
+	} `json:"data"`
+}
+
+func (r *RedditResolver) Resolve(ctx context.Context, client *http.Client, rawURL string) (ResolveResult, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ResolveResult{}, err
+	}
+	u.Path = redditThreadRe.FindString(u.Path) + ".json"
+	u.RawQuery = "raw_json=1&sort=top&limit=50"
+	u.Fragment = ""
+
+	// The response is the post listing followed by the comment listing.
+	var listings []redditListing
+	if err := getJSON(ctx, client, u.String(), &listings); err != nil {
+		return ResolveResult{}, fmt.Errorf("fetch reddit thread: %w", err)
+	}
+	if len(listings) == 0 || len(listings[0].Data.Children) == 0 {
+		return ResolveResult{}, nil
+	}
+	post := listings[0].Data.Children[0].Data
+	if post.IsSelf || post.URL == "" {
+		return ResolveResult{}, nil
+	}
+
+	var texts []string
+	var scores []int
+	if len(listings) > 1 {
+		for _, c := range listings[1].Data.Children {
+			if c.Kind != "t1" || c.Data.Stickied {
+				continue
+			}
+			texts = append(texts, c.Data.Body)
+			scores = append(scores, c.Data.Score)
+		}
+	}
+	return ResolveResult{URL: post.URL, Resolved: true, Comments: topComments(texts, scores)}, nil
+}
+
+// LobstersResolver resolves Lobsters stories via the story's .json
+// endpoint.
+type LobstersResolver struct{}
+
+var lobstersStoryRe = regexp.MustCompile(`^/s/[a-z0-9]+`)
+
+func (l *LobstersResolver) Match(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	return u.Hostname() == "lobste.rs" && lobstersStoryRe.MatchString(u.Path)
+}
+
+type lobstersStory struct {
+	URL      string `json:"url"`
+	Comments []struct {
+		CommentPlain string `json:"comment_plain"`
+		Score        int    `json:"score"`
+		Depth        int    `json:"depth"`
+		IndentLevel  int    `json:"indent_level"` // older API; top level is 1
+		IsDeleted    bool   `json:"is_deleted"`
+	} `json:"comments"`
+}
+
+func (l *LobstersResolver) Resolve(ctx context.Context, client *http.Client, rawURL string) (ResolveResult, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ResolveResult{}, err
+	}
+	u.Path = lobstersStoryRe.FindString(u.Path) + ".json"
+	u.RawQuery, u.Fragment = "", ""
+
+	var story lobstersStory
+	if err := getJSON(ctx, client, u.String(), &story); err != nil {
+		return ResolveResult{}, fmt.Errorf("fetch lobsters story: %w", err)
+	}
+	// Text posts have no URL.
+	if story.URL == "" {
+		return ResolveResult{}, nil
+	}
+
+	var texts []string
+	var scores []int
+	for _, c := range story.Comments {
+		if c.Depth != 0 || c.IndentLevel > 1 || c.IsDeleted {
+			continue
+		}
+		texts = append(texts, c.CommentPlain)
+		scores = append(scores, c.Score)
+	}
+	return ResolveResult{URL: story.URL, Resolved: true, Comments: topComments(texts, scores)}, nil
+}
+
+// LemmyResolver resolves posts on Lemmy instances through the v3 API.
+// Lemmy runs on many hosts, so it matches well-known instances and any
+// host named lemmy.*.
+type LemmyResolver struct{}
+
+var lemmyInstances = []string{
+	"lemmy.world", "lemmy.ml", "beehaw.org", "sh.itjust.works", "programming.dev",
+	"lemm.ee", "feddit.de", "feddit.org", "lemmy.ca", "lemmy.sdf.org", "slrpnk.net",
+}
+
+var lemmyPostRe = regexp.MustCompile(`^/post/(\d+)/?$`)
+
+func (l *LemmyResolver) Match(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	host := u.Hostname()
+	return (slices.Contains(lemmyInstances, host) || strings.HasPrefix(host, "lemmy.")) &&
+		lemmyPostRe.MatchString(u.Path)
+}
+
+type lemmyPost struct {
+	PostView struct {
+		Post struct {
+			URL string `json:"url"`
+		} `json:"post"`
+	} `json:"post_view"`
+}
+
+type lemmyComments struct {
+	Comments []struct {
+		Comment struct {
+			Content string `json:"content"`
+			Path    string `json:"path"`
+			Deleted bool   `json:"deleted"`
+			Removed bool   `json:"removed"`
+		} `json:"comment"`
+		Counts struct {
+			Score int `json:"score"`
+		} `json:"counts"`
+	} `json:"comments"`
+}
+
+func (l *LemmyResolver) Resolve(ctx context.Context, client *http.Client, rawURL string) (ResolveResult, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ResolveResult{}, err
+	}
+	m := lemmyPostRe.FindStringSubmatch(u.Path)
+	if m == nil {
+		return ResolveResult{}, nil
+	}
+	api := &url.URL{Scheme: u.Scheme, Host: u.Host}
+
+	api.Path, api.RawQuery = "/api/v3/post", "id="+m[1]
+	var post lemmyPost
+	if err := getJSON(ctx, client, api.String(), &post); err != nil {
+		return ResolveResult{}, fmt.Errorf("fetch lemmy post: %w", err)
+	}
+	if post.PostView.Post.URL == "" {
+		return ResolveResult{}, nil
+	}
+	result := ResolveResult{URL: post.PostView.Post.URL, Resolved: true}
+
+	// Comments are a nicety; the article is resolved without them.
+	api.Path, api.RawQuery = "/api/v3/comment/list", "post_id="+m[1]+"&sort=Top&max_depth=1&limit=50"
+	var list lemmyComments
+	if err := getJSON(ctx, client, api.String(), &list); err == nil {
+		var texts []string
+		var scores []int
+		for _, c := range list.Comments {
+			// Top-level comments have a path of "0.<id>".
+			if c.Comment.Deleted || c.Comment.Removed || strings.Count(c.Comment.Path, ".") != 1 {
+				continue
+			}
+			texts = append(texts, c.Comment.Content)
+			scores = append(scores, c.Counts.Score)
+		}
+		result.Comments = topComments(texts, scores)
+	}
+	return result, nil
+}
@@ -0,0 +1,144 @@
+package dredge
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/alexzajac/the-dredger/internal/model"
+)
+
+// maxReadmeBytes bounds the README read for the summariser.
+const maxReadmeBytes = 256 << 10
+
+// GitHubResolver describes repository pages through the GitHub REST API,
+// which has far better metadata than the HTML page: the description,
+// topics, stats and README. The URL is not resolved elsewhere.
+type GitHubResolver struct {
+	// APIBase is the API root, e.g. "https://api.github.com".
+	APIBase string
+	// Token is sent as a bearer token when set.
+	Token string
+}
+
+// githubReserved are first path segments on github.com that are not users
+// or organisations.
+var githubReserved = []string{
+	"about", "apps", "collections", "enterprise", "events", "explore", "features",
+	"login", "marketplace", "notifications", "orgs", "pricing", "pulls", "issues",
+	"search", "settings", "sponsors", "topics", "trending", "users",
+}
+
+// githubRepo returns the owner and repository named by a github.com
+// repository URL: /owner/repo, optionally with ".git" or a trailing slash.
+// Deeper pages such as issues, pull requests and files are not the
+// repository itself, so they are left to the page scraper.
+func githubRepo(rawURL string) (owner, repo string, ok bool) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", "", false
+	}
+	if host := strings.TrimPrefix(u.Hostname(), "www."); host != "github.com" {
+		return "", "", false
+	}
+	parts := strings.Split(strings.Trim(u.Path, "/"), "/")
+	if len(parts) != 2 || parts[0] == "" || slices.Contains(githubReserved, strings.ToLower(parts[0])) {
+		return "", "", false
+	}
+	repo = strings.TrimSuffix(parts[1], ".git")
+	if repo == "" {
+		return "", "", false
+	}
+	return parts[0], repo, true
+}
+
+func (g *GitHubResolver) Match(rawURL string) bool {
+	_, _, ok := githubRepo(rawURL)
+	return ok
+}
+
+type githubRepository struct {
+	FullName    string    `json:"full_name"`
+	Description string    `json:"description"`
+	HTMLURL     string    `json:"html_url"`
+	Homepage    string    `json:"homepage"`
+	Language    string    `json:"language"`
+	Topics      []string  `json:"topics"`
+	Stars       int       `json:"stargazers_count"`
+	Forks       int       `json:"forks_count"`
+	Archived    bool      `json:"archived"`
+	CreatedAt   time.Time `json:"created_at"`
+	PushedAt    time.Time `json:"pushed_at"`
+	Owner       struct {
+		Login string `json:"login"`
+	} `json:"owner"`
+	License *struct {
+		SPDXID string `json:"spdx_id"`
+		Name   string `json:"name"`
+	} `json:"license"`
+}
+
+func (g *GitHubResolver) Resolve(ctx context.Context, client *http.Client, rawURL string) (ResolveResult, error) {
+	owner, repo, ok := githubRepo(rawURL)
+	if !ok {
+		return ResolveResult{}, nil
+	}
+	base := strings.TrimSuffix(g.APIBase, "/") + "/repos/" + url.PathEscape(owner) + "/" + url.PathEscape(repo)
+
+	var r githubRepository
+	if err := getBody(ctx, client, base, "application/vnd.github+json", g.header(), func(body io.Reader) error {
+		return json.NewDecoder(io.LimitReader(body, maxJSONBytes)).Decode(&r)
+	}); err != nil {
+		return ResolveResult{}, fmt.Errorf("fetch github repo: %w", err)
+	}
+
+	details := &model.RepoDetails{
+		FullName: r.FullName,
+		Stars:    r.Stars,
+		Forks:    r.Forks,
+		Language: r.Language,
+		Topics:   r.Topics,
+		PushedAt: r.PushedAt,
+		Archived: r.Archived,
+	}
+	if r.License != nil && r.License.SPDXID != "" && r.License.SPDXID != "NOASSERTION" {
+		details.License = r.License.SPDXID
+	}
+	result := ResolveResult{
+		Meta: PageMeta{
+			Title:       r.FullName,
+			Description: r.Description,
+			Canonical:   r.HTMLURL,
+			SiteName:    "GitHub",
+			Author:      r.Owner.Login,
+			Published:   r.CreatedAt,
+			Modified:    r.PushedAt,
+			Type:        "repository",
+		},
+		Tags:    r.Topics,
+		Details: model.Details{Repo: details},
+	}
+
+	// A repository without a README is still described by the API.
+	_ = getBody(ctx, client, base+"/readme", "application/vnd.github.raw", g.header(), func(body io.Reader) error {
+		data, err := io.ReadAll(io.LimitReader(body, maxReadmeBytes))
+		result.Text = strings.TrimSpace(string(data))
+		return err
+	})
+	return result, nil
+}
+
+// header returns the API version and authorisation headers.
+func (g *GitHubResolver) header() http.Header {
+	h := http.Header{"X-Github-Api-Version": {"2022-11-28"}}
+	if g.Token != "" {
+		h.Set("Authorization", "Bearer "+g.Token)
+	}
+	return h
+}
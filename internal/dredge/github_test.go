@@ -0,0 +1,171 @@
+package dredge
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+)
+
+const testRepoJSON = `{
+	"full_name": "octo/widget",
+	"description": "Widgets for everyone",
+	"html_url": "https://github.com/octo/widget",
+	"language": "Go",
+	"topics": ["cli", "widgets"],
+	"stargazers_count": 1234,
+	"forks_count": 56,
+	"archived": true,
+	"created_at": "2020-01-02T03:04:05Z",
+	"pushed_at": "2024-05-06T07:08:09Z",
+	"owner": {"login": "octo"},
+	"license": {"spdx_id": "MIT", "name": "MIT License"}
+}`
+
+func githubServer(t *testing.T, token string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer "+token && token != "" {
+			t.Errorf("expected bearer token, got %q", got)
+		}
+		switch r.URL.Path {
+		case "/repos/octo/widget":
+			_, _ = io.WriteString(w, testRepoJSON)
+		case "/repos/octo/widget/readme":
+			if r.Header.Get("Accept") != "application/vnd.github.raw" {
+				t.Errorf("expected raw README accept header, got %q", r.Header.Get("Accept"))
+			}
+			_, _ = io.WriteString(w, "# Widget\n\nMakes widgets.\n")
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestGitHubResolverMatch(t *testing.T) {
+	g := &GitHubResolver{}
+	cases := map[string]bool{
+		"https://github.com/octo/widget":                   true,
+		"https://www.github.com/octo/widget/":              true,
+		"https://github.com/octo/widget.git":               true,
+		"https://github.com/octo/widget?tab=readme":        true,
+		"https://github.com/octo":                          false,
+		"https://github.com/octo/.git":                     false,
+		"https://github.com/octo/widget/issues/123":        false,
+		"https://github.com/octo/widget/pull/7/files":      false,
+		"https://github.com/octo/widget/blob/main/main.go": false,
+		"https://github.com/octo/widget/tree/main":         false,
+		"https://github.com/topics/go":                     false,
+		"https://github.com/features/actions":              false,
+		"https://gist.github.com/octo/abc":                 false,
+		"https://example.com/octo/widget":                  false,
+	}
+	for rawURL, want := range cases {
+		if got := g.Match(rawURL); got != want {
+			t.Errorf("Match(%q) = %v, want %v", rawURL, got, want)
+		}
+	}
+}
+
+func TestGitHubResolver(t *testing.T) {
+	srv := githubServer(t, "secret")
+	g := &GitHubResolver{APIBase: srv.URL, Token: "secret"}
+
+	result, err := g.Resolve(context.Background(), srv.Client(), "https://github.com/octo/widget.git")
+	if err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+	if result.Resolved {
+		t.Error("repository pages should not be resolved elsewhere")
+	}
+	if result.Meta.Title != "octo/widget" || result.Meta.Description != "Widgets for everyone" {
+		t.Errorf("unexpected meta %+v", result.Meta)
+	}
+	if !slices.Equal(result.Tags, []string{"cli", "widgets"}) {
+		t.Errorf("expected topics as tags, got %v", result.Tags)
+	}
+	if result.Text != "# Widget\n\nMakes widgets." {
+		t.Errorf("unexpected README text %q", result.Text)
+	}
+
+	repo := result.Details.Repo
+	if repo == nil {
+		t.Fatal("expected repo details")
+	}
+	if repo.Stars != 1234 || repo.Forks != 56 || repo.Language != "Go" || repo.License != "MIT" || !repo.Archived {
+		t.Errorf("unexpected details %+v", repo)
+	}
+	if want := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC); !repo.PushedAt.Equal(want) {
+		t.Errorf("pushed at = %s, want %s", repo.PushedAt, want)
+	}
+}
+
+func TestGitHubResolverMissingRepo(t *testing.T) {
+	srv := githubServer(t, "")
+	g := &GitHubResolver{APIBase: srv.URL}
+	if _, err := g.Resolve(context.Background(), srv.Client(), "https://github.com/octo/gone"); err == nil {
+		t.Error("expected an error for a missing repository")
+	}
+}
+
+func TestFetchOneUsesGitHubAPI(t *testing.T) {
+	srv := githubServer(t, "")
+	s := &Service{
+		client:    srv.Client(),
+		resolvers: []Resolver{&GitHubResolver{APIBase: srv.URL}},
+	}
+	result := s.fetchOne(context.Background(), 1, "https://github.com/octo/widget")
+	if result.Err != nil {
+		t.Fatalf("fetch: %v", result.Err)
+	}
+	if result.Title != "octo/widget" || result.Content != "# Widget\n\nMakes widgets." {
+		t.Errorf("unexpected result %q %q", result.Title, result.Content)
+	}
+	if !slices.Equal(result.SeedTags, []string{"cli", "widgets"}) || result.Details.Repo == nil {
+		t.Errorf("expected topics and details, got %v %+v", result.SeedTags, result.Details)
+	}
+	if link := result.link(); link.Details.Repo == nil || link.PageType != "repository" {
+		t.Errorf("expected details on the link, got %+v", link)
+	}
+}
+
+func TestFetchOneScrapesGitHubIssues(t *testing.T) {
+	api := githubServer(t, "")
+	page := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		_, _ = io.WriteString(w, `<html><head><title>Crash on start · Issue #123</title></head><body><p>It crashes.</p></body></html>`)
+	}))
+	defer page.Close()
+
+	// Point the issue URL at the page server while keeping its github.com path.
+	transport := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		if r.URL.Host == "github.com" {
+			r = r.Clone(r.Context())
+			r.URL.Scheme, r.URL.Host = "http", page.Listener.Addr().String()
+		}
+		return http.DefaultTransport.RoundTrip(r)
+	})
+	s := &Service{
+		client:    &http.Client{Transport: transport},
+		resolvers: []Resolver{&GitHubResolver{APIBase: api.URL}},
+	}
+	result := s.fetchOne(context.Background(), 1, "https://github.com/octo/widget/issues/123")
+	if result.Err != nil {
+		t.Fatalf("fetch: %v", result.Err)
+	}
+	if result.Title != "Crash on start · Issue #123" || result.Details.Repo != nil {
+		t.Errorf("expected the issue page scraped, got %q %+v", result.Title, result.Details)
+	}
+}
+
+func TestMergeTags(t *testing.T) {
+	got := mergeTags([]string{"go", "CLI"}, []string{"cli", "tui", ""}, nil)
+	if want := []string{"go", "CLI", "tui"}; !slices.Equal(got, want) {
+		t.Errorf("mergeTags = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,191 @@
+package dredge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// TranscriptFunc fetches the captions or transcript of a media page, given
+// the page's HTML. It returns "" when there is none.
+type TranscriptFunc func(ctx context.Context, client *http.Client, page []byte) (string, error)
+
+// OEmbedProvider is a video or audio site with an oEmbed endpoint.
+type OEmbedProvider struct {
+	Name     string
+	Hosts    []string
+	Endpoint string
+	// Transcript, when set, supplies text to summarise instead of the
+	// description.
+	Transcript TranscriptFunc
+}
+
+var oembedProviders = []OEmbedProvider{
+	{
+		Name:       "YouTube",
+		Hosts:      []string{"youtube.com", "m.youtube.com", "youtu.be"},
+		Endpoint:   "https://www.youtube.com/oembed",
+		Transcript: youtubeTranscript,
+	},
+	{Name: "Vimeo", Hosts: []string{"vimeo.com", "player.vimeo.com"}, Endpoint: "https://vimeo.com/api/oembed.json"},
+	{Name: "Spotify", Hosts: []string{"open.spotify.com"}, Endpoint: "https://open.spotify.com/oembed"},
+	{Name: "SoundCloud", Hosts: []string{"soundcloud.com"}, Endpoint: "https://soundcloud.com/oembed"},
+}
+
+// MediaResolver describes videos and podcast episodes through their
+// site's oEmbed endpoint, which gives a clean title, channel and
+// thumbnail. The page itself is still fetched for its duration and
+// description, and for captions where the provider has a transcript hook.
+type MediaResolver struct {
+	// Providers replace the built-in providers when set.
+	Providers []OEmbedProvider
+}
+
+func (m *MediaResolver) provider(rawURL string) (OEmbedProvider, bool) {
+	u, err := url.Parse(rawURL)
+	if err != nil || strings.Trim(u.Path, "/") == "" {
+		return OEmbedProvider{}, false
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	providers := m.Providers
+	if providers == nil {
+		providers = oembedProviders
+	}
+	for _, p := range providers {
+		if slices.Contains(p.Hosts, host) {
+			return p, true
+		}
+	}
+	return OEmbedProvider{}, false
+}
+
+func (m *MediaResolver) Match(rawURL string) bool {
+	_, ok := m.provider(rawURL)
+	return ok
+}
+
+type oembedResponse struct {
+	Title        string `json:"title"`
+	AuthorName   string `json:"author_name"`
+	ProviderName string `json:"provider_name"`
+	ThumbnailURL string `json:"thumbnail_url"`
+	// Description and Duration (in seconds) are Vimeo extensions.
+	Description string `json:"description"`
+	Duration    int    `json:"duration"`
+}
+
+func (m *MediaResolver) Resolve(ctx context.Context, client *http.Client, rawURL string) (ResolveResult, error) {
+	p, ok := m.provider(rawURL)
+	if !ok {
+		return ResolveResult{}, nil
+	}
+	var embed oembedResponse
+	endpoint := p.Endpoint + "?format=json&url=" + url.QueryEscape(rawURL)
+	if err := getJSON(ctx, client, endpoint, &embed); err != nil {
+		return ResolveResult{}, fmt.Errorf("fetch %s oembed: %w", p.Name, err)
+	}
+	if embed.Title == "" {
+		return ResolveResult{}, fmt.Errorf("%s oembed: no title", p.Name)
+	}
+
+	// The page adds what oEmbed leaves out; without it the embed still
+	// describes the video.
+	var page []byte
+	_ = getBody(ctx, client, rawURL, "text/html", nil, func(body io.Reader) error {
+		var err error
+		page, err = io.ReadAll(io.LimitReader(body, maxBodyBytes))
+		return err
+	})
+	meta := ScrapeMetadata(bytes.NewReader(page))
+
+	typ := meta.Type
+	if typ == "" || strings.HasPrefix(typ, "video.") || typ == "website" {
+		typ = "video"
+	}
+	result := ResolveResult{
+		Meta: PageMeta{
+			Title:       embed.Title,
+			Description: firstNonEmpty(embed.Description, meta.Description),
+			Canonical:   meta.Canonical,
+			SiteName:    firstNonEmpty(embed.ProviderName, p.Name),
+			Author:      firstNonEmpty(embed.AuthorName, meta.Author),
+			Published:   meta.Published,
+			Image:       firstNonEmpty(embed.ThumbnailURL, meta.Image),
+			Type:        typ,
+			Language:    meta.Language,
+			Duration:    meta.Duration,
+		},
+	}
+	if embed.Duration > 0 {
+		result.Meta.Duration = time.Duration(embed.Duration) * time.Second
+	}
+
+	if p.Transcript != nil && len(page) > 0 {
+		result.Text, _ = p.Transcript(ctx, client, page)
+	}
+	if result.Text == "" {
+		result.Text = result.Meta.Description
+	}
+	return result, nil
+}
+
+type captionTrack struct {
+	BaseURL      string `json:"baseUrl"`
+	LanguageCode string `json:"languageCode"`
+	Kind         string `json:"kind"` // "asr" for automatic captions
+}
+
+// rank orders tracks from most to least preferred.
+func (t captionTrack) rank() int {
+	r := 0
+	if !strings.HasPrefix(t.LanguageCode, "en") {
+		r += 2
+	}
+	if t.Kind == "asr" {
+		r++
+	}
+	return r
+}
+
+// youtubeTranscript fetches a video's captions from the caption tracks
+// listed in the watch page's player response, preferring English captions
+// written by a person over automatic ones.
+func youtubeTranscript(ctx context.Context, client *http.Client, page []byte) (string, error) {
+	const marker = `"captionTracks":`
+	i := bytes.Index(page, []byte(marker))
+	if i < 0 {
+		return "", nil
+	}
+	var tracks []captionTrack
+	if err := json.NewDecoder(bytes.NewReader(page[i+len(marker):])).Decode(&tracks); err != nil || len(tracks) == 0 {
+		return "", err
+	}
+	best := slices.MinFunc(tracks, func(a, b captionTrack) int { return a.rank() - b.rank() })
+
+	var transcript struct {
+		Texts []string `xml:"text"`
+	}
+	err := getBody(ctx, client, best.BaseURL, "text/xml", nil, func(body io.Reader) error {
+		return xml.NewDecoder(io.LimitReader(body, maxBodyBytes)).Decode(&transcript)
+	})
+	if err != nil {
+		return "", fmt.Errorf("fetch captions: %w", err)
+	}
+	lines := make([]string, 0, len(transcript.Texts))
+	for _, t := range transcript.Texts {
+		// Caption text is HTML-escaped inside the XML.
+		if t = collapseSpace(html.UnescapeString(t)); t != "" {
+			lines = append(lines, t)
+		}
+	}
+	return strings.Join(lines, " "), nil
+}
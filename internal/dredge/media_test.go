@@ -0,0 +1,132 @@
+package dredge
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const testWatchPage = `<html lang="en"><head>
+<meta name="description" content="A talk about Go.">
+<meta itemprop="duration" content="PT12M30S">
+<meta property="og:type" content="video.other">
+</head><body><script>var ytInitialPlayerResponse = {"captions":{"playerCaptionsTracklistRenderer":{"captionTracks":[
+{"baseUrl":"https://www.youtube.com/api/timedtext?v=abc&lang=de","languageCode":"de"},
+{"baseUrl":"https://www.youtube.com/api/timedtext?v=abc&lang=en&kind=asr","languageCode":"en","kind":"asr"},
+{"baseUrl":"https://www.youtube.com/api/timedtext?v=abc&lang=en","languageCode":"en"}
+]}}};</script></body></html>`
+
+const testCaptions = `<?xml version="1.0" encoding="utf-8" ?><transcript>
+<text start="0" dur="2">Hello and welcome.</text>
+<text start="2" dur="3">Today we&amp;#39;re talking
+about Go.</text>
+</transcript>`
+
+// mediaClient answers every request from handler, whatever the host.
+func mediaClient(handler http.HandlerFunc) *http.Client {
+	return &http.Client{Transport: roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		rec := httptest.NewRecorder()
+		handler(rec, r)
+		return rec.Result(), nil
+	})}
+}
+
+func TestMediaResolverMatch(t *testing.T) {
+	m := &MediaResolver{}
+	cases := map[string]bool{
+		"https://www.youtube.com/watch?v=abc":      true,
+		"https://youtu.be/abc":                     true,
+		"https://vimeo.com/123456":                 true,
+		"https://open.spotify.com/episode/xyz":     true,
+		"https://soundcloud.com/artist/track":      true,
+		"https://www.youtube.com/":                 false,
+		"https://example.com/watch?v=abc":          false,
+		"https://spotify.com/episode/not-the-host": false,
+	}
+	for rawURL, want := range cases {
+		if got := m.Match(rawURL); got != want {
+			t.Errorf("Match(%q) = %v, want %v", rawURL, got, want)
+		}
+	}
+}
+
+func TestMediaResolverYouTube(t *testing.T) {
+	client := mediaClient(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/oembed":
+			if r.URL.Query().Get("url") != "https://www.youtube.com/watch?v=abc" {
+				t.Errorf("unexpected oembed url %q", r.URL.Query().Get("url"))
+			}
+			_, _ = io.WriteString(w, `{"title":"Go Talk","author_name":"GopherCon","provider_name":"YouTube","thumbnail_url":"https://i.ytimg.com/vi/abc/hqdefault.jpg"}`)
+		case r.URL.Path == "/watch":
+			_, _ = io.WriteString(w, testWatchPage)
+		case r.URL.Path == "/api/timedtext" && r.URL.Query().Get("lang") == "en" && r.URL.Query().Get("kind") == "":
+			_, _ = io.WriteString(w, testCaptions)
+		default:
+			t.Errorf("unexpected request %s", r.URL)
+			http.NotFound(w, r)
+		}
+	})
+
+	result, err := (&MediaResolver{}).Resolve(context.Background(), client, "https://www.youtube.com/watch?v=abc")
+	if err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+	if result.Meta.Title != "Go Talk" || result.Meta.Author != "GopherCon" || result.Meta.Image == "" {
+		t.Errorf("unexpected meta %+v", result.Meta)
+	}
+	if result.Meta.Duration != 12*time.Minute+30*time.Second {
+		t.Errorf("duration = %s", result.Meta.Duration)
+	}
+	if result.Meta.Type != "video" || result.Meta.Description != "A talk about Go." {
+		t.Errorf("unexpected type or description %+v", result.Meta)
+	}
+	if want := "Hello and welcome. Today we're talking about Go."; result.Text != want {
+		t.Errorf("transcript = %q, want %q", result.Text, want)
+	}
+}
+
+func TestMediaResolverFallsBackToDescription(t *testing.T) {
+	client := mediaClient(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/oembed.json" {
+			_, _ = io.WriteString(w, `{"title":"Short Film","author_name":"Studio","description":"A film about boats.","duration":95}`)
+			return
+		}
+		http.NotFound(w, r)
+	})
+
+	result, err := (&MediaResolver{}).Resolve(context.Background(), client, "https://vimeo.com/123456")
+	if err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+	if result.Meta.Duration != 95*time.Second {
+		t.Errorf("duration = %s", result.Meta.Duration)
+	}
+	if result.Text != "A film about boats." || result.Meta.SiteName != "Vimeo" {
+		t.Errorf("expected the description as text, got %q (%+v)", result.Text, result.Meta)
+	}
+}
+
+func TestParseMediaDuration(t *testing.T) {
+	cases := map[string]time.Duration{
+		"PT4M13S":  4*time.Minute + 13*time.Second,
+		"PT1H":     time.Hour,
+		"P1DT2H":   26 * time.Hour,
+		"pt90s":    90 * time.Second,
+		"PT1.5S":   1500 * time.Millisecond,
+		"253":      253 * time.Second,
+		"":         0,
+		"soon":     0,
+		"PT":       0,
+		"-5":       0,
+		"PT10M30S": 10*time.Minute + 30*time.Second,
+	}
+	for in, want := range cases {
+		if got := parseMediaDuration(in); got != want {
+			t.Errorf("parseMediaDuration(%q) = %s, want %s", in, got, want)
+		}
+	}
+}
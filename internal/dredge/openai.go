@@ -0,0 +1,144 @@
+package dredge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// OpenAIClient summarises via an OpenAI-compatible /v1/chat/completions
+// endpoint, as served by llama.cpp server, LM Studio, vLLM and LocalAI.
+type OpenAIClient struct {
+	baseURL string
+	model   string
+	apiKey  string
+	client  *http.Client
+}
+
+// NewOpenAIClient accepts the server root with or without a trailing /v1.
+func NewOpenAIClient(baseURL, model, apiKey string, timeout time.Duration) *OpenAIClient {
+	if baseURL == "" {
+		baseURL = "http://localhost:8080"
+	}
+	if timeout <= 0 {
+		timeout = 60 * time.Second
+	}
+	baseURL = strings.TrimSuffix(strings.TrimRight(baseURL, "/"), "/v1")
+	return &OpenAIClient{
+		baseURL: baseURL,
+		model:   model,
+		apiKey:  apiKey,
+		client: &http.Client{
+			Timeout: timeout,
+		},
+	}
+}
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatRequest struct {
+	Model          string         `json:"model,omitempty"`
+	Messages       []chatMessage  `json:"messages"`
+	Stream         bool           `json:"stream"`
+	ResponseFormat responseFormat `json:"response_format"`
+}
+
+type responseFormat struct {
+	Type       string     `json:"type"`
+	JSONSchema jsonSchema `json:"json_schema"`
+}
+
+type jsonSchema struct {
+	Name   string          `json:"name"`
+	Schema json.RawMessage `json:"schema"`
+}
+
+type chatResponse struct {
+	Choices []struct {
+		Message chatMessage `json:"message"`
+	} `json:"choices"`
+}
+
+func (o *OpenAIClient) newRequest(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, o.baseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if o.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+o.apiKey)
+	}
+	return req, nil
+}
+
+// Ping checks if the server answers /v1/models with a short timeout.
+func (o *OpenAIClient) Ping() bool {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	req, err := o.newRequest(ctx, http.MethodGet, "/v1/models", nil)
+	if err != nil {
+		return false
+	}
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return false
+	}
+	_ = resp.Body.Close()
+	return resp.StatusCode == http.StatusOK
+}
+
+func (o *OpenAIClient) Summarize(ctx context.Context, prompt string) (Summary, error) {
+	return summarize(ctx, o, prompt)
+}
+
+func (o *OpenAIClient) complete(ctx context.Context, prompt string) (string, error) {
+	reqBody := chatRequest{
+		Model:    o.model,
+		Messages: []chatMessage{{Role: "user", Content: prompt}},
+		Stream:   false,
+		ResponseFormat: responseFormat{
+			Type:       "json_schema",
+			JSONSchema: jsonSchema{Name: "bookmark_summary", Schema: summarySchema},
+		},
+	}
+
+	bodyBytes, err := json.Marshal(reqBody)
+	if err != nil {
+		return "", fmt.Errorf("marshal chat request: %w", err)
+	}
+
+	req, err := o.newRequest(ctx, http.MethodPost, "/v1/chat/completions", bytes.NewReader(bodyBytes))
+	if err != nil {
+		return "", fmt.Errorf("create chat request: %w", err)
+	}
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("chat request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return "", fmt.Errorf("chat completion returned status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var chatResp chatResponse
+	if err := json.NewDecoder(resp.Body).Decode(&chatResp); err != nil {
+		return "", fmt.Errorf("decode chat response: %w", err)
+	}
+	if len(chatResp.Choices) == 0 {
+		return "", fmt.Errorf("chat completion returned no choices")
+	}
+	return chatResp.Choices[0].Message.Content, nil
+}
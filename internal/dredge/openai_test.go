@@ -0,0 +1,92 @@
+package dredge
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alexzajac/the-dredger/internal/config"
+)
+
+func TestOpenAIClientSummarize(t *testing.T) {
+	var gotAuth, gotModel string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v1/models":
+			fmt.Fprint(w, `{"data":[]}`)
+		case "/v1/chat/completions":
+			gotAuth = r.Header.Get("Authorization")
+			var req chatRequest
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				t.Errorf("decode request: %v", err)
+			}
+			gotModel = req.Model
+			if req.ResponseFormat.Type != "json_schema" {
+				t.Errorf("got response_format %q", req.ResponseFormat.Type)
+			}
+			fmt.Fprint(w, `{"choices":[{"message":{"role":"assistant","content":"{\"summary\": \"A fast Go web server.\", \"tags\": [{\"name\": \"go\", \"confidence\": 0.9}, {\"name\": \"http\", \"confidence\": 0.7}]}"}}]}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	// A trailing /v1 is accepted, as LM Studio documents it that way.
+	c := NewOpenAIClient(srv.URL+"/v1", "qwen2.5", "secret", time.Second)
+	if !c.Ping() {
+		t.Fatal("expected Ping() = true")
+	}
+
+	got, err := c.Summarize(context.Background(), "Summarize https://example.com")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Text != "A fast Go web server." {
+		t.Errorf("got summary %q", got.Text)
+	}
+	if len(got.Tags) != 2 || got.Tags[0].Name != "go" || got.Tags[0].Confidence != 0.9 {
+		t.Errorf("got tags %v", got.Tags)
+	}
+	if gotAuth != "Bearer secret" {
+		t.Errorf("got Authorization %q", gotAuth)
+	}
+	if gotModel != "qwen2.5" {
+		t.Errorf("got model %q", gotModel)
+	}
+}
+
+func TestOpenAIClientErrorStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "model not loaded", http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+
+	c := NewOpenAIClient(srv.URL, "", "", time.Second)
+	if c.Ping() {
+		t.Error("expected Ping() = false")
+	}
+	if _, err := c.Summarize(context.Background(), "prompt"); err == nil {
+		t.Error("expected error for 503 response")
+	}
+}
+
+func TestNewSummarizer(t *testing.T) {
+	cases := map[string]any{
+		config.ProviderOllama: &OllamaClient{},
+		config.ProviderOpenAI: &OpenAIClient{},
+		config.ProviderNone:   NoopSummarizer{},
+	}
+	for provider, want := range cases {
+		got := NewSummarizer(config.LLMConfig{Provider: provider})
+		if fmt.Sprintf("%T", got) != fmt.Sprintf("%T", want) {
+			t.Errorf("provider %q: got %T, want %T", provider, got, want)
+		}
+	}
+	if (NoopSummarizer{}).Ping() {
+		t.Error("NoopSummarizer should report unavailable so crunch is skipped")
+	}
+}
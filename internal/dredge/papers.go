@@ -0,0 +1,245 @@
+package dredge
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"html"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/alexzajac/the-dredger/internal/model"
+)
+
+const (
+	defaultArxivAPI    = "https://export.arxiv.org/api/query"
+	defaultCrossrefAPI = "https://api.crossref.org"
+)
+
+// PaperResolver describes academic papers from their registries instead
+// of their landing pages: arXiv papers through the arXiv Atom API and
+// anything with a DOI through Crossref. arXiv PDF links are normalised to
+// the abstract page, and the abstract is what gets summarised.
+type PaperResolver struct {
+	// ArxivAPI and CrossrefAPI override the public endpoints.
+	ArxivAPI    string
+	CrossrefAPI string
+}
+
+var (
+	// arxivIDRe matches new-style (2401.01234v2) and old-style
+	// (hep-th/9901001v1) identifiers.
+	arxivIDRe   = regexp.MustCompile(`^/(?:abs|pdf)/((?:\d{4}\.\d{4,5}|[a-z-]+(?:\.[A-Z]{2})?/\d{7})(?:v\d+)?)(?:\.pdf)?/?$`)
+	arxivVerRe  = regexp.MustCompile(`v\d+$`)
+	arxivDOIRe  = regexp.MustCompile(`(?i)^10\.48550/arxiv\.(.+)$`)
+	doiPathRe   = regexp.MustCompile(`^/(10\.\d{4,9}/\S+)$`)
+	publisherRe = regexp.MustCompile(`/(?:doi|article|chapter)/(?:abs/|full/|pdf/|epdf/)?(10\.\d{4,9}/[^?#]+)`)
+)
+
+// paperID identifies the paper named by rawURL by its arXiv ID (without
+// version) or its DOI.
+func paperID(rawURL string) (arxivID, doi string) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", ""
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	path, _ := url.PathUnescape(u.EscapedPath())
+
+	switch {
+	case host == "arxiv.org" || host == "export.arxiv.org":
+		if m := arxivIDRe.FindStringSubmatch(path); m != nil {
+			return arxivVerRe.ReplaceAllString(m[1], ""), ""
+		}
+		return "", ""
+	case host == "doi.org" || host == "dx.doi.org":
+		if m := doiPathRe.FindStringSubmatch(path); m != nil {
+			doi = m[1]
+		}
+	case host == "nature.com" && strings.HasPrefix(path, "/articles/"):
+		doi = "10.1038/" + strings.TrimPrefix(path, "/articles/")
+	default:
+		if m := publisherRe.FindStringSubmatch(path); m != nil {
+			doi = m[1]
+		}
+	}
+	doi = strings.TrimSuffix(strings.TrimSuffix(doi, "/"), ".pdf")
+	// arXiv registers DOIs for its papers; its own API describes them best.
+	if m := arxivDOIRe.FindStringSubmatch(doi); m != nil {
+		return m[1], ""
+	}
+	return "", doi
+}
+
+func (p *PaperResolver) Match(rawURL string) bool {
+	arxivID, doi := paperID(rawURL)
+	return arxivID != "" || doi != ""
+}
+
+func (p *PaperResolver) Resolve(ctx context.Context, client *http.Client, rawURL string) (ResolveResult, error) {
+	arxivID, doi := paperID(rawURL)
+	switch {
+	case arxivID != "":
+		return p.resolveArxiv(ctx, client, arxivID)
+	case doi != "":
+		return p.resolveDOI(ctx, client, doi)
+	}
+	return ResolveResult{}, nil
+}
+
+type arxivFeed struct {
+	Entries []struct {
+		Title     string    `xml:"title"`
+		Summary   string    `xml:"summary"`
+		Published time.Time `xml:"published"`
+		Updated   time.Time `xml:"updated"`
+		Authors   []struct {
+			Name string `xml:"name"`
+		} `xml:"author"`
+		Categories []struct {
+			Term string `xml:"term,attr"`
+		} `xml:"category"`
+		DOI        string `xml:"http://arxiv.org/schemas/atom doi"`
+		JournalRef string `xml:"http://arxiv.org/schemas/atom journal_ref"`
+	} `xml:"entry"`
+}
+
+func (p *PaperResolver) resolveArxiv(ctx context.Context, client *http.Client, id string) (ResolveResult, error) {
+	api := p.ArxivAPI
+	if api == "" {
+		api = defaultArxivAPI
+	}
+	var feed arxivFeed
+	err := getBody(ctx, client, api+"?id_list="+url.QueryEscape(id), "application/atom+xml", nil, func(body io.Reader) error {
+		return xml.NewDecoder(io.LimitReader(body, maxJSONBytes)).Decode(&feed)
+	})
+	if err != nil {
+		return ResolveResult{}, fmt.Errorf("fetch arxiv entry: %w", err)
+	}
+	// Unknown IDs come back as a single entry titled "Error".
+	if len(feed.Entries) == 0 || feed.Entries[0].Title == "Error" {
+		return ResolveResult{}, fmt.Errorf("arxiv %s: no such paper", id)
+	}
+	e := feed.Entries[0]
+
+	details := &model.PaperDetails{
+		Year:    e.Published.Year(),
+		Venue:   collapseSpace(e.JournalRef),
+		ArxivID: id,
+		DOI:     e.DOI,
+	}
+	for _, a := range e.Authors {
+		details.Authors = append(details.Authors, collapseSpace(a.Name))
+	}
+	for _, c := range e.Categories {
+		details.Categories = append(details.Categories, c.Term)
+	}
+	abstract := collapseSpace(e.Summary)
+	return ResolveResult{
+		Meta: PageMeta{
+			Title:       collapseSpace(e.Title),
+			Description: abstract,
+			Canonical:   "https://arxiv.org/abs/" + id,
+			SiteName:    "arXiv",
+			Author:      shortAuthors(details.Authors),
+			Published:   e.Published,
+			Modified:    e.Updated,
+			Type:        "paper",
+		},
+		Text:    abstract,
+		Details: model.Details{Paper: details},
+	}, nil
+}
+
+type crossrefWork struct {
+	Message struct {
+		Title    []string `json:"title"`
+		Abstract string   `json:"abstract"`
+		Author   []struct {
+			Given  string `json:"given"`
+			Family string `json:"family"`
+			Name   string `json:"name"`
+		} `json:"author"`
+		ContainerTitle []string `json:"container-title"`
+		Publisher      string   `json:"publisher"`
+		Subject        []string `json:"subject"`
+		Issued         struct {
+			DateParts [][]int `json:"date-parts"`
+		} `json:"issued"`
+	} `json:"message"`
+}
+
+func (p *PaperResolver) resolveDOI(ctx context.Context, client *http.Client, doi string) (ResolveResult, error) {
+	api := p.CrossrefAPI
+	if api == "" {
+		api = defaultCrossrefAPI
+	}
+	var work crossrefWork
+	err := getBody(ctx, client, strings.TrimSuffix(api, "/")+"/works/"+url.PathEscape(doi), "application/json", nil, func(body io.Reader) error {
+		return json.NewDecoder(io.LimitReader(body, maxJSONBytes)).Decode(&work)
+	})
+	if err != nil {
+		return ResolveResult{}, fmt.Errorf("fetch crossref work: %w", err)
+	}
+	w := work.Message
+	if len(w.Title) == 0 {
+		return ResolveResult{}, fmt.Errorf("crossref %s: no title", doi)
+	}
+
+	details := &model.PaperDetails{DOI: doi, Categories: w.Subject}
+	for _, a := range w.Author {
+		name := strings.TrimSpace(a.Given + " " + a.Family)
+		if name == "" {
+			name = a.Name
+		}
+		if name != "" {
+			details.Authors = append(details.Authors, name)
+		}
+	}
+	if len(w.ContainerTitle) > 0 {
+		details.Venue = html.UnescapeString(w.ContainerTitle[0])
+	}
+	var published time.Time
+	if parts := w.Issued.DateParts; len(parts) > 0 && len(parts[0]) > 0 {
+		date := append(slices.Clone(parts[0]), 1, 1) // month and day may be missing
+		details.Year = date[0]
+		published = time.Date(date[0], time.Month(date[1]), date[2], 0, 0, 0, 0, time.UTC)
+	}
+	abstract := stripMarkup(w.Abstract)
+	return ResolveResult{
+		Meta: PageMeta{
+			Title:       collapseSpace(w.Title[0]),
+			Description: abstract,
+			Canonical:   "https://doi.org/" + doi,
+			SiteName:    w.Publisher,
+			Author:      shortAuthors(details.Authors),
+			Published:   published,
+			Type:        "paper",
+		},
+		Text:    abstract,
+		Details: model.Details{Paper: details},
+	}, nil
+}
+
+// shortAuthors lists up to three authors, abbreviating longer lists with
+// "et al.".
+func shortAuthors(authors []string) string {
+	if len(authors) > 3 {
+		return strings.Join(authors[:3], ", ") + " et al."
+	}
+	return strings.Join(authors, ", ")
+}
+
+var markupRe = regexp.MustCompile(`<[^>]*>`)
+
+// stripMarkup turns a JATS or HTML fragment, as Crossref abstracts are,
+// into plain text.
+func stripMarkup(s string) string {
+	return strings.TrimPrefix(collapseSpace(html.UnescapeString(markupRe.ReplaceAllString(s, " "))), "Abstract ")
+}
@@ -0,0 +1,108 @@
+package dredge
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestPaperID(t *testing.T) {
+	cases := []struct {
+		url, arxivID, doi string
+	}{
+		{"https://arxiv.org/abs/1706.03762", "1706.03762", ""},
+		{"https://arxiv.org/abs/1706.03762v7", "1706.03762", ""},
+		{"https://arxiv.org/pdf/1706.03762v7.pdf", "1706.03762", ""},
+		{"https://arxiv.org/pdf/2401.01234", "2401.01234", ""},
+		{"http://export.arxiv.org/abs/hep-th/9901001v1", "hep-th/9901001", ""},
+		{"https://doi.org/10.48550/arXiv.1706.03762", "1706.03762", ""},
+		{"https://doi.org/10.1145/3292500.3330701", "", "10.1145/3292500.3330701"},
+		{"https://dx.doi.org/10.1000/xyz123", "", "10.1000/xyz123"},
+		{"https://dl.acm.org/doi/10.1145/3292500.3330701", "", "10.1145/3292500.3330701"},
+		{"https://dl.acm.org/doi/pdf/10.1145/3292500.3330701", "", "10.1145/3292500.3330701"},
+		{"https://onlinelibrary.wiley.com/doi/full/10.1002/asi.24750", "", "10.1002/asi.24750"},
+		{"https://link.springer.com/article/10.1007/s10994-021-05946-3", "", "10.1007/s10994-021-05946-3"},
+		{"https://www.nature.com/articles/s41586-020-2649-2", "", "10.1038/s41586-020-2649-2"},
+		{"https://arxiv.org/list/cs.LG/recent", "", ""},
+		{"https://example.com/doi/about", "", ""},
+	}
+	for _, c := range cases {
+		arxivID, doi := paperID(c.url)
+		if arxivID != c.arxivID || doi != c.doi {
+			t.Errorf("paperID(%q) = %q, %q; want %q, %q", c.url, arxivID, doi, c.arxivID, c.doi)
+		}
+	}
+}
+
+func TestPaperResolverArxiv(t *testing.T) {
+	srv := fixtureServer(t, map[string]string{"/api/query": "arxiv_entry.xml"})
+	p := &PaperResolver{ArxivAPI: srv.URL + "/api/query"}
+
+	result, err := p.Resolve(context.Background(), srv.Client(), "https://arxiv.org/pdf/1706.03762v7")
+	if err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+	if result.Meta.Title != "Attention Is All You Need" {
+		t.Errorf("title = %q", result.Meta.Title)
+	}
+	if result.Meta.Canonical != "https://arxiv.org/abs/1706.03762" {
+		t.Errorf("expected PDF normalised to the abstract page, got %q", result.Meta.Canonical)
+	}
+	if result.Meta.Author != "Ashish Vaswani, Noam Shazeer, Niki Parmar et al." {
+		t.Errorf("author = %q", result.Meta.Author)
+	}
+	if want := "The dominant sequence transduction models are based on complex recurrent or convolutional"; result.Text[:len(want)] != want {
+		t.Errorf("unexpected abstract %q", result.Text)
+	}
+	if !result.Meta.Published.Equal(time.Date(2017, 6, 12, 17, 57, 34, 0, time.UTC)) {
+		t.Errorf("published = %s", result.Meta.Published)
+	}
+
+	paper := result.Details.Paper
+	if paper == nil {
+		t.Fatal("expected paper details")
+	}
+	if paper.Year != 2017 || len(paper.Authors) != 4 || paper.ArxivID != "1706.03762" ||
+		!slices.Equal(paper.Categories, []string{"cs.CL", "cs.LG"}) || paper.Venue == "" {
+		t.Errorf("unexpected details %+v", paper)
+	}
+}
+
+func TestPaperResolverCrossref(t *testing.T) {
+	srv := fixtureServer(t, map[string]string{"/works/10.1145/3292500.3330701": "crossref_work.json"})
+	p := &PaperResolver{CrossrefAPI: srv.URL}
+
+	result, err := p.Resolve(context.Background(), srv.Client(), "https://dl.acm.org/doi/10.1145/3292500.3330701")
+	if err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+	if result.Meta.Title != "Optuna: A Next-generation Hyperparameter Optimization Framework" {
+		t.Errorf("title = %q", result.Meta.Title)
+	}
+	if want := "The purpose of this study is to introduce new design-criteria for next-generation hyperparameter optimization software & its implementation."; result.Text != want {
+		t.Errorf("abstract = %q", result.Text)
+	}
+	if result.Meta.Canonical != "https://doi.org/10.1145/3292500.3330701" || result.Meta.SiteName != "ACM" {
+		t.Errorf("unexpected meta %+v", result.Meta)
+	}
+
+	paper := result.Details.Paper
+	if paper == nil {
+		t.Fatal("expected paper details")
+	}
+	if paper.Year != 2019 || !slices.Equal(paper.Authors, []string{"Takuya Akiba", "Shotaro Sano", "Preferred Networks"}) {
+		t.Errorf("unexpected details %+v", paper)
+	}
+	if paper.Venue != "Proceedings of the 25th ACM SIGKDD International Conference on Knowledge Discovery & Data Mining" {
+		t.Errorf("venue = %q", paper.Venue)
+	}
+}
+
+func TestPaperResolverUnknown(t *testing.T) {
+	srv := fixtureServer(t, nil)
+	p := &PaperResolver{CrossrefAPI: srv.URL}
+	if _, err := p.Resolve(context.Background(), srv.Client(), "https://doi.org/10.1000/missing"); err == nil {
+		t.Error("expected an error for an unknown DOI")
+	}
+}
@@ -0,0 +1,335 @@
+package dredge
+
+import (
+	"bytes"
+	"compress/zlib"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// maxPDFText caps the text kept from a PDF's first page.
+const maxPDFText = 20000
+
+// PDFDoc is what ParsePDF can recover from a PDF without a full parser.
+type PDFDoc struct {
+	Title    string
+	Author   string
+	Created  time.Time
+	Modified time.Time
+	// Text is the text of the first content stream that draws any, which
+	// for most documents is the first page.
+	Text string
+}
+
+var (
+	pdfInfoRefRe = regexp.MustCompile(`/Info\s+(\d+)\s+(\d+)\s+R`)
+	pdfInfoRe    = regexp.MustCompile(`/(Title|Author|CreationDate|ModDate)\s*(\(|<[0-9A-Fa-f\s]*>)`)
+	pdfStreamRe  = regexp.MustCompile(`stream\r?\n`)
+)
+
+// ParsePDF reads the document information dictionary and the text of the
+// first page from a PDF. It understands uncompressed and Flate-compressed
+// content streams with literal strings, which covers most PDFs produced by
+// LaTeX and word processors; anything else yields an empty PDFDoc field
+// rather than an error.
+func ParsePDF(data []byte) PDFDoc {
+	var doc PDFDoc
+	info := pdfInfoDict(data)
+	for _, m := range pdfInfoRe.FindAllSubmatchIndex(info, -1) {
+		key := string(info[m[2]:m[3]])
+		value := pdfString(info[m[4]:])
+		switch key {
+		case "Title":
+			if doc.Title == "" {
+				doc.Title = value
+			}
+		case "Author":
+			if doc.Author == "" {
+				doc.Author = value
+			}
+		case "CreationDate":
+			if doc.Created.IsZero() {
+				doc.Created = parsePDFDate(value)
+			}
+		case "ModDate":
+			if doc.Modified.IsZero() {
+				doc.Modified = parsePDFDate(value)
+			}
+		}
+	}
+
+	// Encrypted documents have encrypted strings and streams.
+	if bytes.Contains(data, []byte("/Encrypt")) {
+		return doc
+	}
+
+	for _, m := range pdfStreamRe.FindAllIndex(data, -1) {
+		start := m[1]
+		end := bytes.Index(data[start:], []byte("endstream"))
+		if end < 0 {
+			break
+		}
+		dict := streamDict(data[:m[0]])
+		if bytes.Contains(dict, []byte("/Subtype/Image")) || bytes.Contains(dict, []byte("/Subtype /Image")) {
+			continue
+		}
+		raw := data[start : start+end]
+		if bytes.Contains(dict, []byte("/FlateDecode")) {
+			r, err := zlib.NewReader(bytes.NewReader(raw))
+			if err != nil {
+				continue
+			}
+			raw, err = io.ReadAll(io.LimitReader(r, maxBodyBytes))
+			_ = r.Close()
+			if err != nil && len(raw) == 0 {
+				continue
+			}
+		} else if bytes.Contains(dict, []byte("/Filter")) {
+			continue
+		}
+		if text := contentStreamText(raw); text != "" {
+			if len(text) > maxPDFText {
+				text = strings.ToValidUTF8(text[:maxPDFText], "")
+			}
+			doc.Text = text
+			break
+		}
+	}
+	return doc
+}
+
+// pdfInfoDict returns the object the trailer names as /Info, so that
+// bookmark titles elsewhere in the file are not mistaken for the document
+// title. Without a trailer it falls back to the whole file.
+func pdfInfoDict(data []byte) []byte {
+	m := pdfInfoRefRe.FindSubmatch(data)
+	if m == nil {
+		return data
+	}
+	objRe := regexp.MustCompile(`(?:^|\s)` + string(m[1]) + `\s+` + string(m[2]) + `\s+obj\b`)
+	loc := objRe.FindIndex(data)
+	if loc == nil {
+		return data
+	}
+	obj := data[loc[1]:]
+	if end := bytes.Index(obj, []byte("endobj")); end >= 0 {
+		obj = obj[:end]
+	}
+	return obj
+}
+
+// streamDict returns the dictionary preceding a "stream" keyword.
+func streamDict(before []byte) []byte {
+	i := bytes.LastIndex(before, []byte("obj"))
+	if i < 0 {
+		i = max(len(before)-512, 0)
+	}
+	return before[i:]
+}
+
+// contentStreamText pulls the shown strings out of a page content stream,
+// starting a new line on text positioning operators.
+func contentStreamText(stream []byte) string {
+	if !bytes.Contains(stream, []byte("BT")) {
+		return ""
+	}
+	var b strings.Builder
+	var pending []string
+	for i := 0; i < len(stream); i++ {
+		switch c := stream[i]; c {
+		case '(':
+			s, n := pdfLiteral(stream[i:])
+			pending = append(pending, decodePDFText([]byte(s)))
+			i += n - 1
+		case '%':
+			for i < len(stream) && stream[i] != '\n' && stream[i] != '\r' {
+				i++
+			}
+		default:
+			if !isPDFRegular(c) {
+				continue
+			}
+			j := i
+			for j < len(stream) && isPDFRegular(stream[j]) {
+				j++
+			}
+			switch op := string(stream[i:j]); op {
+			case "Tj", "TJ", "'", "\"":
+				if op == "'" || op == "\"" {
+					b.WriteByte('\n')
+				}
+				for _, s := range pending {
+					b.WriteString(s)
+				}
+				pending = pending[:0]
+			case "T*", "Td", "TD", "ET":
+				if b.Len() > 0 && !strings.HasSuffix(b.String(), "\n") {
+					b.WriteByte('\n')
+				}
+				pending = pending[:0]
+			}
+			i = j - 1
+		}
+	}
+	text := strings.TrimSpace(b.String())
+	if !mostlyPrintable(text) {
+		return ""
+	}
+	return text
+}
+
+func isPDFRegular(c byte) bool {
+	switch c {
+	case ' ', '\t', '\r', '\n', '\f', 0, '(', ')', '<', '>', '[', ']', '{', '}', '/', '%':
+		return false
+	}
+	return true
+}
+
+// pdfString decodes the literal or hex string at the start of data.
+func pdfString(data []byte) string {
+	if len(data) == 0 {
+		return ""
+	}
+	var raw []byte
+	if data[0] == '(' {
+		s, _ := pdfLiteral(data)
+		raw = []byte(s)
+	} else {
+		end := bytes.IndexByte(data, '>')
+		if end < 0 {
+			return ""
+		}
+		hex := strings.Join(strings.Fields(string(data[1:end])), "")
+		if len(hex)%2 == 1 {
+			hex += "0"
+		}
+		for k := 0; k+1 < len(hex); k += 2 {
+			v, err := strconv.ParseUint(hex[k:k+2], 16, 8)
+			if err != nil {
+				return ""
+			}
+			raw = append(raw, byte(v))
+		}
+	}
+	return strings.TrimSpace(decodePDFText(raw))
+}
+
+// pdfLiteral decodes a (...) string with its escapes and nested
+// parentheses, returning the raw bytes and how many input bytes it used.
+func pdfLiteral(data []byte) (string, int) {
+	var out []byte
+	depth := 0
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		switch c {
+		case '(':
+			if depth > 0 {
+				out = append(out, c)
+			}
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return string(out), i + 1
+			}
+			out = append(out, c)
+		case '\\':
+			i++
+			if i >= len(data) {
+				break
+			}
+			switch e := data[i]; e {
+			case 'n':
+				out = append(out, '\n')
+			case 'r':
+				out = append(out, '\r')
+			case 't':
+				out = append(out, '\t')
+			case 'b', 'f':
+			case '\r', '\n':
+				// Line continuation.
+				if e == '\r' && i+1 < len(data) && data[i+1] == '\n' {
+					i++
+				}
+			default:
+				if e >= '0' && e <= '7' {
+					j := i
+					for j < len(data) && j < i+3 && data[j] >= '0' && data[j] <= '7' {
+						j++
+					}
+					v, _ := strconv.ParseUint(string(data[i:j]), 8, 8)
+					out = append(out, byte(v))
+					i = j - 1
+				} else {
+					out = append(out, e)
+				}
+			}
+		default:
+			out = append(out, c)
+		}
+	}
+	return string(out), len(data)
+}
+
+// decodePDFText handles UTF-16BE strings (marked by a BOM) and treats
+// anything else as Latin-1, a close approximation of PDFDocEncoding.
+func decodePDFText(raw []byte) string {
+	if len(raw) >= 2 && raw[0] == 0xFE && raw[1] == 0xFF {
+		units := make([]uint16, 0, len(raw)/2)
+		for k := 2; k+1 < len(raw); k += 2 {
+			units = append(units, uint16(raw[k])<<8|uint16(raw[k+1]))
+		}
+		return string(utf16.Decode(units))
+	}
+	runes := make([]rune, len(raw))
+	for k, c := range raw {
+		runes[k] = rune(c)
+	}
+	return string(runes)
+}
+
+// parsePDFDate parses dates such as "D:20230115120000Z" or
+// "D:20230115120000+01'00'".
+func parsePDFDate(s string) time.Time {
+	s = strings.TrimPrefix(s, "D:")
+	digits := 0
+	for digits < len(s) && digits < 14 && s[digits] >= '0' && s[digits] <= '9' {
+		digits++
+	}
+	layout := "20060102150405"
+	if digits < 4 {
+		return time.Time{}
+	}
+	t, err := time.Parse(layout[:digits], s[:digits])
+	if err != nil {
+		return time.Time{}
+	}
+	rest := strings.ReplaceAll(s[digits:], "'", "")
+	if len(rest) == 5 && (rest[0] == '+' || rest[0] == '-') {
+		if zoned, err := time.Parse(layout[:digits]+"-0700", s[:digits]+rest); err == nil {
+			return zoned
+		}
+	}
+	return t
+}
+
+// mostlyPrintable rejects text from fonts with custom encodings, which
+// comes out as control characters.
+func mostlyPrintable(s string) bool {
+	if s == "" {
+		return false
+	}
+	var printable, total int
+	for _, r := range s {
+		total++
+		if r == '\n' || r == '\t' || (r >= ' ' && r != 0x7f && (r < 0x80 || r >= 0xa0)) {
+			printable++
+		}
+	}
+	return printable*10 >= total*9
+}
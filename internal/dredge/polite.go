@@ -0,0 +1,318 @@
+package dredge
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/alexzajac/the-dredger/internal/config"
+)
+
+// ErrDisallowed is returned for pages that robots.txt asks us not to crawl.
+var ErrDisallowed = errors.New("disallowed by robots.txt")
+
+// robotsTTL is how long a host's robots.txt is trusted before refetching.
+const robotsTTL = 24 * time.Hour
+
+// politeTransport wraps a RoundTripper so that the crawler behaves on
+// shared hosts: every request carries our User-Agent, requests to one host
+// are rate limited by a token bucket and capped in concurrency, and, when
+// enabled, robots.txt rules and Crawl-delay are honoured.
+type politeTransport struct {
+	base      http.RoundTripper
+	userAgent string
+	rate      float64 // requests per second per host
+	burst     int     // also the per-host concurrency cap
+	robots    bool
+
+	mu    sync.Mutex
+	hosts map[string]*hostLimiter
+}
+
+func newPoliteTransport(base http.RoundTripper, cfg config.DredgeConfig) *politeTransport {
+	return &politeTransport{
+		base:      base,
+		userAgent: cfg.UserAgent,
+		rate:      cfg.HostRate,
+		burst:     max(cfg.HostConcurrency, 1),
+		robots:    cfg.Robots,
+		hosts:     make(map[string]*hostLimiter),
+	}
+}
+
+// hostLimiter is the per-host state: a token bucket, a semaphore of
+// in-flight requests and the cached robots.txt.
+type hostLimiter struct {
+	slots chan struct{}
+
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+
+	robotsMu  sync.Mutex
+	rules     *robotsRules
+	fetchedAt time.Time
+}
+
+func (t *politeTransport) host(name string) *hostLimiter {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	h, ok := t.hosts[name]
+	if !ok {
+		h = &hostLimiter{
+			slots:  make(chan struct{}, t.burst),
+			rate:   t.rate,
+			burst:  float64(t.burst),
+			tokens: float64(t.burst),
+			last:   time.Now(),
+		}
+		t.hosts[name] = h
+	}
+	return h
+}
+
+// reserve takes a token from the bucket and returns how long the caller
+// must wait before using it.
+func (h *hostLimiter) reserve(now time.Time) time.Duration {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.tokens = min(h.burst, h.tokens+now.Sub(h.last).Seconds()*h.rate)
+	h.last = now
+	h.tokens--
+	if h.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-h.tokens / h.rate * float64(time.Second))
+}
+
+// slowDown lowers the host's rate to one request per delay, without
+// bursts, when that is slower than the configured rate.
+func (h *hostLimiter) slowDown(delay time.Duration) {
+	if delay <= 0 {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if r := 1 / delay.Seconds(); r < h.rate {
+		h.rate = r
+		h.burst = 1
+		h.tokens = min(h.tokens, 1)
+	}
+}
+
+func (t *politeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	h := t.host(req.URL.Host)
+
+	if t.robots && req.URL.Path != "/robots.txt" {
+		rules := t.robotsFor(ctx, h, req)
+		if !rules.allowed(req.URL.RequestURI()) {
+			return nil, fmt.Errorf("%s: %w", req.URL, ErrDisallowed)
+		}
+	}
+
+	select {
+	case h.slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	release := sync.OnceFunc(func() { <-h.slots })
+
+	if wait := h.reserve(time.Now()); wait > 0 {
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			release()
+			return nil, ctx.Err()
+		}
+	}
+
+	req = req.Clone(ctx)
+	if req.Header.Get("User-Agent") == "" {
+		req.Header.Set("User-Agent", t.userAgent)
+	}
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	// The slot is held until the body is closed, so a slow download
+	// counts against the host's concurrency.
+	resp.Body = &releaseOnClose{ReadCloser: resp.Body, release: release}
+	return resp, nil
+}
+
+type releaseOnClose struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b *releaseOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.release()
+	return err
+}
+
+// robotsFor returns the host's robots.txt rules, fetching them when they
+// are missing or stale. A robots.txt that cannot be fetched allows
+// everything.
+func (t *politeTransport) robotsFor(ctx context.Context, h *hostLimiter, page *http.Request) *robotsRules {
+	h.robotsMu.Lock()
+	defer h.robotsMu.Unlock()
+	if h.rules != nil && time.Since(h.fetchedAt) < robotsTTL {
+		return h.rules
+	}
+
+	rules := &robotsRules{}
+	robotsURL := page.URL.Scheme + "://" + page.URL.Host + "/robots.txt"
+	if req, err := http.NewRequestWithContext(ctx, http.MethodGet, robotsURL, nil); err == nil {
+		// Redirects are only followed to another robots.txt: any other
+		// page on this host would need these rules, which are still being
+		// fetched under robotsMu.
+		client := &http.Client{
+			Transport: t,
+			CheckRedirect: func(req *http.Request, via []*http.Request) error {
+				if req.URL.Path != "/robots.txt" || len(via) >= 5 {
+					return http.ErrUseLastResponse
+				}
+				return nil
+			},
+		}
+		if resp, err := client.Do(req); err == nil {
+			if resp.StatusCode == http.StatusOK {
+				*rules = parseRobots(io.LimitReader(resp.Body, 512<<10), t.userAgent)
+			}
+			_ = resp.Body.Close()
+		}
+	}
+	h.slowDown(rules.crawlDelay)
+	h.rules, h.fetchedAt = rules, time.Now()
+	return rules
+}
+
+// robotsRules are the robots.txt directives that apply to our user agent.
+type robotsRules struct {
+	rules      []robotsRule
+	crawlDelay time.Duration
+}
+
+type robotsRule struct {
+	allow   bool
+	pattern string
+}
+
+// allowed reports whether path may be crawled: the longest matching rule
+// wins, Allow breaking ties, and no match means allowed.
+func (r *robotsRules) allowed(path string) bool {
+	best, allow := -1, true
+	for _, rule := range r.rules {
+		if !robotsMatch(rule.pattern, path) {
+			continue
+		}
+		if n := len(rule.pattern); n > best || (n == best && rule.allow) {
+			best, allow = n, rule.allow
+		}
+	}
+	return allow
+}
+
+// robotsMatch matches a robots.txt path pattern, which may use * for any
+// run of characters and a trailing $ to anchor the end.
+func robotsMatch(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	parts := strings.Split(strings.TrimSuffix(pattern, "$"), "*")
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	pos := len(parts[0])
+	if len(parts) == 1 {
+		return !anchored || pos == len(path)
+	}
+	for _, part := range parts[1 : len(parts)-1] {
+		i := strings.Index(path[pos:], part)
+		if i < 0 {
+			return false
+		}
+		pos += i + len(part)
+	}
+	last := parts[len(parts)-1]
+	if anchored {
+		return len(path)-len(last) >= pos && strings.HasSuffix(path, last)
+	}
+	return strings.Contains(path[pos:], last)
+}
+
+// parseRobots reads the group of a robots.txt that applies to userAgent:
+// the first group naming our product token, otherwise the "*" groups.
+func parseRobots(r io.Reader, userAgent string) robotsRules {
+	product := strings.ToLower(userAgent)
+	if i := strings.IndexAny(product, "/ "); i >= 0 {
+		product = product[:i]
+	}
+
+	type group struct {
+		agents []string
+		robotsRules
+	}
+	var groups []*group
+	var cur *group
+	inAgents := false
+
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line, _, _ := strings.Cut(sc.Text(), "#")
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		if key == "user-agent" {
+			if !inAgents {
+				cur = &group{}
+				groups = append(groups, cur)
+				inAgents = true
+			}
+			cur.agents = append(cur.agents, strings.ToLower(value))
+			continue
+		}
+		inAgents = false
+		if cur == nil {
+			continue
+		}
+		switch key {
+		case "allow", "disallow":
+			if value != "" {
+				cur.rules = append(cur.rules, robotsRule{allow: key == "allow", pattern: value})
+			}
+		case "crawl-delay":
+			if secs, err := strconv.ParseFloat(value, 64); err == nil && secs > 0 {
+				cur.crawlDelay = time.Duration(secs * float64(time.Second))
+			}
+		}
+	}
+
+	var wildcard robotsRules
+	for _, g := range groups {
+		for _, agent := range g.agents {
+			switch {
+			case agent == "*":
+				wildcard.rules = append(wildcard.rules, g.rules...)
+				wildcard.crawlDelay = max(wildcard.crawlDelay, g.crawlDelay)
+			case agent != "" && strings.Contains(product, agent):
+				return g.robotsRules
+			}
+		}
+	}
+	return wildcard
+}
@@ -0,0 +1,208 @@
+package dredge
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alexzajac/the-dredger/internal/config"
+)
+
+const testRobots = `# comment
+User-agent: Googlebot
+Disallow: /
+
+User-agent: TheDredger
+User-agent: OtherBot
+Disallow: /private/
+Allow: /private/open
+Disallow: /*.pdf$
+Crawl-delay: 2
+
+User-agent: *
+Disallow: /
+`
+
+func TestParseRobots(t *testing.T) {
+	rules := parseRobots(strings.NewReader(testRobots), "TheDredger/1.0 (+https://example.com)")
+	if rules.crawlDelay != 2*time.Second {
+		t.Errorf("crawl delay = %s, want 2s", rules.crawlDelay)
+	}
+	cases := map[string]bool{
+		"/":                   true,
+		"/blog/post":          true,
+		"/private/":           false,
+		"/private/secret":     false,
+		"/private/open":       true,
+		"/private/open/more":  true,
+		"/paper.pdf":          false,
+		"/paper.pdf?download": true,
+	}
+	for path, want := range cases {
+		if got := rules.allowed(path); got != want {
+			t.Errorf("allowed(%q) = %v, want %v", path, got, want)
+		}
+	}
+
+	// Agents without their own group fall back to "*".
+	other := parseRobots(strings.NewReader(testRobots), "SomeCrawler/2.0")
+	if other.allowed("/blog/post") {
+		t.Error("expected the * group to disallow everything")
+	}
+}
+
+func TestRobotsMatch(t *testing.T) {
+	cases := []struct {
+		pattern, path string
+		want          bool
+	}{
+		{"/a", "/abc", true},
+		{"/a$", "/abc", false},
+		{"/a$", "/a", true},
+		{"/*/edit", "/wiki/page/edit", true},
+		{"/*/edit", "/edit", false},
+		{"/*.php$", "/index.php", true},
+		{"/*.php$", "/index.php5", false},
+		{"*", "/anything", true},
+	}
+	for _, c := range cases {
+		if got := robotsMatch(c.pattern, c.path); got != c.want {
+			t.Errorf("robotsMatch(%q, %q) = %v, want %v", c.pattern, c.path, got, c.want)
+		}
+	}
+}
+
+func testDredgeConfig() config.DredgeConfig {
+	cfg := config.Default().Dredge
+	cfg.UserAgent = "TheDredger/test"
+	cfg.HostRate = 1000
+	cfg.HostConcurrency = 2
+	return cfg
+}
+
+func TestPoliteTransportConcurrencyAndUserAgent(t *testing.T) {
+	var inFlight, peak atomic.Int32
+	var agents sync.Map
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		agents.Store(r.UserAgent(), true)
+		n := inFlight.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		inFlight.Add(-1)
+		_, _ = io.WriteString(w, "ok")
+	}))
+	defer srv.Close()
+
+	client := &http.Client{Transport: newPoliteTransport(srv.Client().Transport, testDredgeConfig())}
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Go(func() {
+			resp, err := client.Get(srv.URL)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			_, _ = io.Copy(io.Discard, resp.Body)
+			_ = resp.Body.Close()
+		})
+	}
+	wg.Wait()
+
+	if p := peak.Load(); p > 2 {
+		t.Errorf("expected at most 2 concurrent requests, saw %d", p)
+	}
+	if _, ok := agents.Load("TheDredger/test"); !ok {
+		t.Error("expected configured User-Agent on requests")
+	}
+}
+
+func TestPoliteTransportRate(t *testing.T) {
+	h := &hostLimiter{rate: 10, burst: 2, tokens: 2, last: time.Now()}
+	now := h.last
+	if d := h.reserve(now); d != 0 {
+		t.Fatalf("first request should not wait, got %s", d)
+	}
+	if d := h.reserve(now); d != 0 {
+		t.Fatalf("burst request should not wait, got %s", d)
+	}
+	if d := h.reserve(now); d != 100*time.Millisecond {
+		t.Errorf("third request should wait one token, got %s", d)
+	}
+
+	h.slowDown(2 * time.Second)
+	if h.rate != 0.5 || h.burst != 1 {
+		t.Errorf("expected Crawl-delay to lower rate, got rate %g burst %g", h.rate, h.burst)
+	}
+}
+
+func TestPoliteTransportRobots(t *testing.T) {
+	var robotsFetches atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			robotsFetches.Add(1)
+			_, _ = io.WriteString(w, "User-agent: *\nDisallow: /private\n")
+			return
+		}
+		_, _ = io.WriteString(w, "ok")
+	}))
+	defer srv.Close()
+
+	cfg := testDredgeConfig()
+	cfg.Robots = true
+	client := &http.Client{Transport: newPoliteTransport(srv.Client().Transport, cfg)}
+
+	resp, err := client.Get(srv.URL + "/public")
+	if err != nil {
+		t.Fatalf("public page: %v", err)
+	}
+	_ = resp.Body.Close()
+
+	req, _ := http.NewRequestWithContext(context.Background(), http.MethodGet, srv.URL+"/private/page", nil)
+	_, err = client.Do(req)
+	if !errors.Is(err, ErrDisallowed) {
+		t.Fatalf("expected ErrDisallowed, got %v", err)
+	}
+	if n := robotsFetches.Load(); n != 1 {
+		t.Errorf("expected robots.txt to be fetched once and cached, got %d", n)
+	}
+}
+
+func TestPoliteTransportRobotsRedirect(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			http.Redirect(w, r, "/", http.StatusFound)
+		default:
+			_, _ = io.WriteString(w, "ok")
+		}
+	}))
+	defer srv.Close()
+
+	cfg := testDredgeConfig()
+	cfg.Robots = true
+	client := &http.Client{Transport: newPoliteTransport(srv.Client().Transport, cfg)}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/page", nil)
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("page behind a redirecting robots.txt: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected the page to be allowed, got %d", resp.StatusCode)
+	}
+}
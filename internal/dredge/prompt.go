@@ -0,0 +1,260 @@
+package dredge
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Kind classifies a link so that a matching prompt template can be used.
+type Kind string
+
+const (
+	KindDefault    Kind = "default"
+	KindGitHub     Kind = "github"
+	KindPaper      Kind = "paper"
+	KindVideo      Kind = "video"
+	KindDiscussion Kind = "discussion"
+)
+
+// Kinds lists every kind that has its own template.
+var Kinds = []Kind{KindDefault, KindGitHub, KindPaper, KindVideo, KindDiscussion}
+
+//go:embed prompts/*.tmpl
+var builtinPrompts embed.FS
+
+const commonTemplate = "_common.tmpl"
+
+// PromptData is the crawled page data available to prompt templates.
+type PromptData struct {
+	Title        string
+	URL          string
+	Description  string
+	Comments     []string
+	ExistingTags []string
+	// Vocabulary is the library's most used tags, which the model should
+	// prefer over inventing new ones.
+	Vocabulary []string
+	PageText   string
+}
+
+// Domain returns the host of the link, without a leading "www.".
+func (d PromptData) Domain() string {
+	u, err := url.Parse(d.URL)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(u.Hostname(), "www.")
+}
+
+// DetectKind picks the template kind for a saved URL.
+func DetectKind(rawURL string) Kind {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return KindDefault
+	}
+	host := strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")
+	path := strings.ToLower(u.Path)
+
+	switch {
+	case (&GitHubResolver{}).Match(rawURL):
+		return KindGitHub
+	case host == "arxiv.org", host == "doi.org", host == "dx.doi.org", host == "openreview.net",
+		strings.HasSuffix(path, ".pdf"), (&PaperResolver{}).Match(rawURL):
+		return KindPaper
+	case (&MediaResolver{}).Match(rawURL):
+		return KindVideo
+	case host == "news.ycombinator.com" && path == "/item",
+		strings.HasSuffix(host, "reddit.com") && strings.Contains(path, "/comments/"),
+		host == "lobste.rs" && strings.HasPrefix(path, "/s/"),
+		(&LemmyResolver{}).Match(rawURL):
+		return KindDiscussion
+	}
+	return KindDefault
+}
+
+// Prompts renders summarisation prompts from text/template files. Each
+// kind uses, in order: <dir>/<kind>.tmpl, <dir>/default.tmpl, the built-in
+// template for the kind, then the built-in default.
+type Prompts struct {
+	templates map[Kind]*template.Template
+	sources   map[Kind]string
+}
+
+var promptFuncs = template.FuncMap{
+	"join":     strings.Join,
+	"truncate": truncateWords,
+}
+
+// truncateWords shortens s to at most n characters, cutting at the last
+// word boundary when there is one in the second half, and marks the cut
+// with "..." within those n.
+func truncateWords(n int, s string) string {
+	if utf8.RuneCountInString(s) <= n {
+		return s
+	}
+	const ellipsis = "..."
+	if n <= len(ellipsis) {
+		return firstRunes(s, max(n, 0))
+	}
+	cut := firstRunes(s, n-len(ellipsis))
+	if i := strings.LastIndexFunc(cut, unicode.IsSpace); i > len(cut)/2 {
+		cut = cut[:i]
+	}
+	return strings.TrimRightFunc(cut, unicode.IsSpace) + ellipsis
+}
+
+// firstRunes returns the first n runes of s.
+func firstRunes(s string, n int) string {
+	for i := range s {
+		if n == 0 {
+			return s[:i]
+		}
+		n--
+	}
+	return s
+}
+
+// LoadPrompts parses the built-in templates and any overrides in dir. A
+// missing dir is not an error.
+func LoadPrompts(dir string) (*Prompts, error) {
+	common, err := template.New(commonTemplate).Funcs(promptFuncs).ParseFS(builtinPrompts, "prompts/"+commonTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("parse built-in prompts: %w", err)
+	}
+	if dir != "" {
+		// A user _common.tmpl may redefine the shared "page" and "comments" blocks.
+		path := filepath.Join(dir, commonTemplate)
+		if data, err := os.ReadFile(path); err == nil {
+			if _, err := common.Parse(string(data)); err != nil {
+				return nil, fmt.Errorf("parse prompt %s: %w", path, err)
+			}
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("read prompt %s: %w", path, err)
+		}
+	}
+
+	p := &Prompts{
+		templates: make(map[Kind]*template.Template),
+		sources:   make(map[Kind]string),
+	}
+
+	userDefault, userDefaultPath, err := parseUserTemplate(common, dir, KindDefault)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, kind := range Kinds {
+		tmpl, path := userDefault, userDefaultPath
+		if kind != KindDefault {
+			tmpl, path, err = parseUserTemplate(common, dir, kind)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if tmpl == nil && userDefault != nil {
+			tmpl, path = userDefault, userDefaultPath
+		}
+		if tmpl == nil {
+			tmpl, path, err = parseBuiltinTemplate(common, kind)
+			if err != nil {
+				return nil, err
+			}
+		}
+		p.templates[kind] = tmpl
+		p.sources[kind] = path
+	}
+	return p, nil
+}
+
+func parseUserTemplate(common *template.Template, dir string, kind Kind) (*template.Template, string, error) {
+	if dir == "" {
+		return nil, "", nil
+	}
+	path := filepath.Join(dir, string(kind)+".tmpl")
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, "", nil
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("read prompt %s: %w", path, err)
+	}
+	tmpl, err := template.Must(common.Clone()).New(string(kind)).Parse(string(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("parse prompt %s: %w", path, err)
+	}
+	return tmpl, path, nil
+}
+
+func parseBuiltinTemplate(common *template.Template, kind Kind) (*template.Template, string, error) {
+	name := "prompts/" + string(kind) + ".tmpl"
+	data, err := builtinPrompts.ReadFile(name)
+	if err != nil {
+		name = "prompts/" + string(KindDefault) + ".tmpl"
+		data, err = builtinPrompts.ReadFile(name)
+		if err != nil {
+			return nil, "", err
+		}
+	}
+	tmpl, err := template.Must(common.Clone()).New(string(kind)).Parse(string(data))
+	if err != nil {
+		return nil, "", fmt.Errorf("parse built-in prompt %s: %w", name, err)
+	}
+	return tmpl, "built-in " + filepath.Base(name), nil
+}
+
+// Render executes the template for kind and appends the JSON response
+// instructions, which templates cannot change.
+func (p *Prompts) Render(kind Kind, data PromptData) (string, error) {
+	tmpl, ok := p.templates[kind]
+	if !ok {
+		tmpl = p.templates[KindDefault]
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("render %s prompt: %w", kind, err)
+	}
+	return strings.TrimSpace(buf.String()) + "\n\n" + jsonInstructions, nil
+}
+
+// Source describes where the template for kind was loaded from.
+func (p *Prompts) Source(kind Kind) string {
+	return p.sources[kind]
+}
+
+// WriteBuiltinPrompts copies the built-in templates into dir for editing,
+// skipping files that already exist. It returns the paths written.
+func WriteBuiltinPrompts(dir string) ([]string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("create prompts dir: %w", err)
+	}
+	entries, err := builtinPrompts.ReadDir("prompts")
+	if err != nil {
+		return nil, err
+	}
+	var written []string
+	for _, e := range entries {
+		dest := filepath.Join(dir, e.Name())
+		if _, err := os.Stat(dest); err == nil {
+			continue
+		}
+		data, err := builtinPrompts.ReadFile("prompts/" + e.Name())
+		if err != nil {
+			return written, err
+		}
+		if err := os.WriteFile(dest, data, 0o644); err != nil {
+			return written, fmt.Errorf("write %s: %w", dest, err)
+		}
+		written = append(written, dest)
+	}
+	return written, nil
+}
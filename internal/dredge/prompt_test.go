@@ -0,0 +1,139 @@
+package dredge
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestDetectKind(t *testing.T) {
+	cases := map[string]Kind{
+		"https://github.com/charmbracelet/bubbletea":        KindGitHub,
+		"https://github.com/charmbracelet":                  KindDefault,
+		"https://github.com/charmbracelet/bubbletea/pull/1": KindDefault,
+		"https://arxiv.org/abs/1706.03762":                  KindPaper,
+		"https://example.com/files/paper.PDF":               KindPaper,
+		"https://www.youtube.com/watch?v=dQw4w9WgXcQ":       KindVideo,
+		"https://youtu.be/dQw4w9WgXcQ":                      KindVideo,
+		"https://news.ycombinator.com/item?id=1":            KindDiscussion,
+		"https://old.reddit.com/r/golang/comments/abc/post": KindDiscussion,
+		"https://lobste.rs/s/abc123/title":                  KindDiscussion,
+		"https://lemmy.world/post/123":                      KindDiscussion,
+		"https://example.com/blog/post":                     KindDefault,
+	}
+	for u, want := range cases {
+		if got := DetectKind(u); got != want {
+			t.Errorf("DetectKind(%q) = %q, want %q", u, got, want)
+		}
+	}
+}
+
+func TestTruncateWords(t *testing.T) {
+	cases := []struct {
+		n        int
+		in, want string
+	}{
+		{10, "short", "short"},
+		{12, "the quick brown fox", "the quick..."},
+		{8, "naïveté über", "naïve..."},
+		{6, "日本語のテキスト", "日本語..."},
+		{3, "日本語のテキスト", "日本語"},
+		{4, "abcdefgh", "a..."},
+		{0, "abc", ""},
+	}
+	for _, c := range cases {
+		got := truncateWords(c.n, c.in)
+		if got != c.want || !utf8.ValidString(got) || utf8.RuneCountInString(got) > c.n {
+			t.Errorf("truncateWords(%d, %q) = %q, want %q", c.n, c.in, got, c.want)
+		}
+	}
+}
+
+func TestPromptsBuiltin(t *testing.T) {
+	p, err := LoadPrompts(filepath.Join(t.TempDir(), "missing"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	got, err := p.Render(KindDiscussion, PromptData{
+		Title:    "Cool Article",
+		URL:      "https://news.ycombinator.com/item?id=1",
+		Comments: []string{"Great read."},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, want := range []string{"Title: Cool Article", "(news.ycombinator.com)", "- Great read.", jsonInstructions} {
+		if !strings.Contains(got, want) {
+			t.Errorf("prompt missing %q:\n%s", want, got)
+		}
+	}
+	if src := p.Source(KindGitHub); src != "built-in github.tmpl" {
+		t.Errorf("Source(github) = %q", src)
+	}
+}
+
+func TestPromptsUserOverride(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("default.tmpl", `House style for {{.Domain}}: {{join .ExistingTags "|"}}`)
+	write("paper.tmpl", `Paper: {{.Title}}{{template "comments" .}}`)
+
+	p, err := LoadPrompts(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// github has no user file, so the user default wins over the built-in.
+	got, err := p.Render(KindGitHub, PromptData{URL: "https://www.github.com/a/b", ExistingTags: []string{"go", "cli"}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(got, "House style for github.com: go|cli") {
+		t.Errorf("got %q", got)
+	}
+
+	got, err = p.Render(KindPaper, PromptData{Title: "Attention"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !strings.HasPrefix(got, "Paper: Attention") {
+		t.Errorf("got %q", got)
+	}
+	if p.Source(KindPaper) != filepath.Join(dir, "paper.tmpl") {
+		t.Errorf("Source(paper) = %q", p.Source(KindPaper))
+	}
+}
+
+func TestPromptsBadTemplate(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "video.tmpl"), []byte("{{.Title"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadPrompts(dir); err == nil {
+		t.Error("expected parse error")
+	}
+}
+
+func TestWriteBuiltinPrompts(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "prompts")
+	written, err := WriteBuiltinPrompts(dir)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(written) != len(Kinds)+1 { // plus _common.tmpl
+		t.Errorf("wrote %d files, want %d", len(written), len(Kinds)+1)
+	}
+	if _, err := LoadPrompts(dir); err != nil {
+		t.Errorf("written templates should load: %v", err)
+	}
+	again, err := WriteBuiltinPrompts(dir)
+	if err != nil || len(again) != 0 {
+		t.Errorf("second write should skip existing files, got %v, %v", again, err)
+	}
+}
@@ -0,0 +1,124 @@
+package dredge
+
+import (
+	"bytes"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// maxRefreshHops bounds the meta-refresh and script redirects followed
+// for one link; HTTP redirects are bounded by the client.
+const maxRefreshHops = 5
+
+// maxScriptRedirectBytes is the largest page whose scripts are searched
+// for a location change. Interstitials are tiny; real pages often mention
+// location in scripts that never run on load.
+const maxScriptRedirectBytes = 16 << 10
+
+// maxRefreshDelay is the longest meta refresh still treated as a redirect
+// rather than, say, a live page that reloads itself.
+const maxRefreshDelay = 10
+
+var scriptLocationRe = regexp.MustCompile(
+	`(?:(?:window|document|top|self)\.)?location(?:\.href)?\s*=\s*["']([^"']+)["']|location\.(?:replace|assign)\(\s*["']([^"']+)["']\s*\)`)
+
+// redirectHops returns the URLs the client visited to produce resp,
+// oldest first, including the URL resp came from.
+func redirectHops(resp *http.Response) []string {
+	var hops []string
+	for req := resp.Request; req != nil; {
+		hops = append(hops, req.URL.String())
+		if req.Response == nil {
+			break
+		}
+		req = req.Response.Request
+	}
+	for i, j := 0, len(hops)-1; i < j; i, j = i+1, j-1 {
+		hops[i], hops[j] = hops[j], hops[i]
+	}
+	return hops
+}
+
+// refreshTarget returns where an HTML page sends the browser on load,
+// through a Refresh header, a <meta http-equiv=refresh> tag or, for small
+// pages, a script assigning location. It returns "" when the page stays.
+func refreshTarget(pageURL, refreshHeader string, body []byte) string {
+	target := parseRefresh(refreshHeader)
+	if target == "" {
+		target = scanRefresh(body)
+	}
+	if target == "" {
+		return ""
+	}
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return ""
+	}
+	u, err := base.Parse(strings.TrimSpace(target))
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return ""
+	}
+	u.Fragment = ""
+	if next := u.String(); next != pageURL {
+		return next
+	}
+	return ""
+}
+
+// scanRefresh looks for a meta refresh, then a script redirect.
+func scanRefresh(body []byte) string {
+	var scripts []string
+	z := html.NewTokenizer(bytes.NewReader(body))
+	inScript := false
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			if len(body) <= maxScriptRedirectBytes {
+				for _, s := range scripts {
+					if m := scriptLocationRe.FindStringSubmatch(s); m != nil {
+						return m[1] + m[2]
+					}
+				}
+			}
+			return ""
+		case html.StartTagToken, html.SelfClosingTagToken:
+			tn, hasAttr := z.TagName()
+			switch string(tn) {
+			case "meta":
+				attrs := tagAttrs(z, hasAttr)
+				if strings.EqualFold(attrs["http-equiv"], "refresh") {
+					if target := parseRefresh(attrs["content"]); target != "" {
+						return target
+					}
+				}
+			case "script":
+				inScript = true
+			}
+		case html.TextToken:
+			if inScript {
+				scripts = append(scripts, string(z.Text()))
+			}
+		case html.EndTagToken:
+			inScript = false
+		}
+	}
+}
+
+// parseRefresh reads a refresh value such as "0; url=https://example.com"
+// and returns its URL when the delay is short enough to be a redirect.
+func parseRefresh(content string) string {
+	delay, rest, _ := strings.Cut(content, ";")
+	if secs, err := strconv.ParseFloat(strings.TrimSpace(delay), 64); err != nil || secs > maxRefreshDelay {
+		return ""
+	}
+	rest = strings.TrimSpace(rest)
+	if len(rest) >= 4 && strings.EqualFold(rest[:4], "url=") {
+		rest = strings.TrimSpace(rest[4:])
+	}
+	return strings.Trim(rest, `"'`)
+}
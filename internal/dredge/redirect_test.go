@@ -0,0 +1,73 @@
+package dredge
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestRefreshTarget(t *testing.T) {
+	const page = "https://sho.rt/abc"
+	cases := []struct {
+		name, header, body, want string
+	}{
+		{"meta refresh", "", `<meta http-equiv="refresh" content="0; url=https://example.com/post">`, "https://example.com/post"},
+		{"quoted relative", "", `<meta http-equiv="Refresh" content="1;URL='/next'">`, "https://sho.rt/next"},
+		{"header", "0; url=https://example.com/h", `<p>Redirecting</p>`, "https://example.com/h"},
+		{"script", "", `<script>window.location.href = "https://example.com/js";</script>`, "https://example.com/js"},
+		{"location.replace", "", `<script>location.replace('https://example.com/r')</script>`, "https://example.com/r"},
+		{"slow refresh", "", `<meta http-equiv="refresh" content="300">`, ""},
+		{"self", "", `<meta http-equiv="refresh" content="0; url=https://sho.rt/abc#top">`, ""},
+		{"not http", "", `<meta http-equiv="refresh" content="0; url=javascript:alert(1)">`, ""},
+		{"no redirect", "", `<p>Just a page</p><script>var x = 1;</script>`, ""},
+		{"script on a large page", "", `<script>location.href = "https://example.com/js";</script>` + strings.Repeat("<p>text</p>", 4096), ""},
+	}
+	for _, c := range cases {
+		if got := refreshTarget(page, c.header, []byte(c.body)); got != c.want {
+			t.Errorf("%s: refreshTarget = %q, want %q", c.name, got, c.want)
+		}
+	}
+}
+
+func TestFetchOneRecordsRedirects(t *testing.T) {
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/short":
+			http.Redirect(w, r, "/interstitial", http.StatusMovedPermanently)
+		case "/interstitial":
+			_, _ = io.WriteString(w, `<html><head><meta http-equiv="refresh" content="0; url=`+srv.URL+`/article"></head></html>`)
+		case "/article":
+			_, _ = io.WriteString(w, `<html><head><title>The Article</title></head><body><p>Text.</p></body></html>`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	s := &Service{client: srv.Client(), resolvers: []Resolver{}}
+	result := s.fetchOne(context.Background(), 1, srv.URL+"/short")
+	if result.Err != nil {
+		t.Fatalf("fetch: %v", result.Err)
+	}
+	if result.Title != "The Article" {
+		t.Errorf("title = %q", result.Title)
+	}
+	if result.ResolvedURL != srv.URL+"/article" {
+		t.Errorf("resolved URL = %q", result.ResolvedURL)
+	}
+	want := []string{srv.URL + "/short", srv.URL + "/interstitial", srv.URL + "/article"}
+	if !slices.Equal(result.RedirectChain, want) {
+		t.Errorf("chain = %v, want %v", result.RedirectChain, want)
+	}
+
+	// A link that does not redirect records nothing.
+	result = s.fetchOne(context.Background(), 2, srv.URL+"/article")
+	if result.ResolvedURL != "" || result.RedirectChain != nil {
+		t.Errorf("expected no redirect, got %q %v", result.ResolvedURL, result.RedirectChain)
+	}
+}
@@ -1,12 +1,14 @@
 package dredge
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"strings"
 
+	"github.com/alexzajac/the-dredger/internal/model"
 	"golang.org/x/net/html"
 )
 
@@ -15,27 +17,43 @@ type ResolveResult struct {
 	URL      string   // resolved article URL (or original if not resolved)
 	Resolved bool     // whether URL was resolved to a different target
 	Comments []string // extracted community comments (if any)
+
+	// Resolvers backed by an API may also describe the page directly.
+	Meta    PageMeta      // overrides what the page's HTML declares
+	Tags    []string      // tags to seed the link with
+	Text    string        // readable text to summarise instead of the page
+	Details model.Details // kind-specific facts for the card
 }
 
 // Resolver detects aggregator URLs and resolves them to the underlying article URL.
 type Resolver interface {
 	Match(rawURL string) bool
-	Resolve(client *http.Client, rawURL string) (ResolveResult, error)
+	Resolve(ctx context.Context, client *http.Client, rawURL string) (ResolveResult, error)
 }
 
 const tagSpan = "span"
 
-var resolvers = []Resolver{&HNResolver{}}
+var resolvers = []Resolver{&HNResolver{}, &RedditResolver{}, &LobstersResolver{}, &LemmyResolver{}, &PaperResolver{}, &MediaResolver{}}
 
 // ResolveURL runs the URL through registered resolvers. If none match or
 // resolution fails, it returns the original URL unchanged.
-func ResolveURL(client *http.Client, rawURL string) ResolveResult {
-	for _, r := range resolvers {
+func ResolveURL(ctx context.Context, client *http.Client, rawURL string) ResolveResult {
+	return resolveWith(ctx, client, resolvers, rawURL)
+}
+
+// resolveWith runs rawURL through the first matching resolver in list.
+// A result that is not Resolved keeps the original URL but may still
+// carry metadata.
+func resolveWith(ctx context.Context, client *http.Client, list []Resolver, rawURL string) ResolveResult {
+	for _, r := range list {
 		if r.Match(rawURL) {
-			result, err := r.Resolve(client, rawURL)
-			if err != nil || !result.Resolved {
+			result, err := r.Resolve(ctx, client, rawURL)
+			if err != nil {
 				return ResolveResult{URL: rawURL}
 			}
+			if !result.Resolved {
+				result.URL = rawURL
+			}
 			return result
 		}
 	}
@@ -53,8 +71,12 @@ func (h *HNResolver) Match(rawURL string) bool {
 	return u.Host == "news.ycombinator.com" && u.Path == "/item" && u.Query().Get("id") != ""
 }
 
-func (h *HNResolver) Resolve(client *http.Client, rawURL string) (ResolveResult, error) {
-	resp, err := client.Get(rawURL)
+func (h *HNResolver) Resolve(ctx context.Context, client *http.Client, rawURL string) (ResolveResult, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return ResolveResult{}, fmt.Errorf("create HN request: %w", err)
+	}
+	resp, err := client.Do(req)
 	if err != nil {
 		return ResolveResult{}, fmt.Errorf("fetch HN page: %w", err)
 	}
@@ -79,11 +101,19 @@ func (h *HNResolver) Resolve(client *http.Client, rawURL string) (ResolveResult,
 	}, nil
 }
 
-const maxHNComments = 10
+const maxComments = 10
 const maxCommentLen = 500
 
+// clipComment trims a comment to maxCommentLen bytes.
+func clipComment(text string) string {
+	if len(text) > maxCommentLen {
+		return strings.ToValidUTF8(text[:maxCommentLen], "") + "..."
+	}
+	return text
+}
+
 // extractHNComments parses HN HTML for top-level comment text from
-// <span class="commtext"> elements. Returns up to maxHNComments comments,
+// <span class="commtext"> elements. Returns up to maxComments comments,
 // each truncated to maxCommentLen characters.
 func extractHNComments(body io.Reader) []string {
 	z := html.NewTokenizer(body)
@@ -116,11 +146,8 @@ func extractHNComments(body io.Reader) []string {
 			if string(tn) == tagSpan && inComment {
 				text := strings.TrimSpace(buf.String())
 				if text != "" {
-					if len(text) > maxCommentLen {
-						text = text[:maxCommentLen] + "..."
-					}
-					comments = append(comments, text)
-					if len(comments) >= maxHNComments {
+					comments = append(comments, clipComment(text))
+					if len(comments) >= maxComments {
 						return comments
 					}
 				}
@@ -1,9 +1,12 @@
 package dredge
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 )
@@ -71,7 +74,7 @@ func TestExtractHNArticleURL_AskHN(t *testing.T) {
 
 func TestResolveURL_NonAggregator(t *testing.T) {
 	u := "https://example.com/some-page"
-	result := ResolveURL(http.DefaultClient, u)
+	result := ResolveURL(context.Background(), http.DefaultClient, u)
 	if result.Resolved {
 		t.Error("expected Resolved=false for non-aggregator URL")
 	}
@@ -89,7 +92,7 @@ func TestHNResolverResolve(t *testing.T) {
 	hnURL := srv.URL + "/item?id=12345"
 
 	r := &HNResolver{}
-	result, err := r.Resolve(srv.Client(), hnURL)
+	result, err := r.Resolve(context.Background(), srv.Client(), hnURL)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
@@ -155,7 +158,138 @@ func TestExtractHNComments_MaxLimit(t *testing.T) {
 	b.WriteString("</body></html>")
 
 	comments := extractHNComments(strings.NewReader(b.String()))
-	if len(comments) != maxHNComments {
-		t.Errorf("got %d comments, want %d", len(comments), maxHNComments)
+	if len(comments) != maxComments {
+		t.Errorf("got %d comments, want %d", len(comments), maxComments)
+	}
+}
+
+// fixtureServer serves files from testdata by request path.
+func fixtureServer(t *testing.T, files map[string]string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name, ok := files[r.URL.Path]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		data, err := os.ReadFile(filepath.Join("testdata", name))
+		if err != nil {
+			t.Errorf("read fixture: %v", err)
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(data)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestForumResolverMatch(t *testing.T) {
+	cases := []struct {
+		r       Resolver
+		matches []string
+		noMatch []string
+	}{
+		{
+			&RedditResolver{},
+			[]string{
+				"https://www.reddit.com/r/golang/comments/18abcde/go_122_loop_variable/",
+				"https://old.reddit.com/r/golang/comments/18abcde/",
+				"https://reddit.com/comments/18abcde",
+			},
+			[]string{"https://www.reddit.com/r/golang/", "https://example.com/r/golang/comments/18abcde/"},
+		},
+		{
+			&LobstersResolver{},
+			[]string{"https://lobste.rs/s/abc123/writing_sqlite_clone", "https://lobste.rs/s/abc123"},
+			[]string{"https://lobste.rs/", "https://lobste.rs/t/go"},
+		},
+		{
+			&LemmyResolver{},
+			[]string{"https://programming.dev/post/4242", "https://lemmy.example.org/post/7"},
+			[]string{"https://programming.dev/c/rust", "https://example.com/post/4242"},
+		},
+	}
+	for _, c := range cases {
+		for _, u := range c.matches {
+			if !c.r.Match(u) {
+				t.Errorf("%T: expected Match(%q) = true", c.r, u)
+			}
+		}
+		for _, u := range c.noMatch {
+			if c.r.Match(u) {
+				t.Errorf("%T: expected Match(%q) = false", c.r, u)
+			}
+		}
+	}
+}
+
+func TestRedditResolverResolve(t *testing.T) {
+	srv := fixtureServer(t, map[string]string{
+		"/r/golang/comments/18abcde.json": "reddit_thread.json",
+		"/r/golang/comments/18fghij.json": "reddit_self.json",
+	})
+
+	r := &RedditResolver{}
+	result, err := r.Resolve(context.Background(), srv.Client(), srv.URL+"/r/golang/comments/18abcde/go_122_loop_variable/?share=1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.Resolved || result.URL != "https://go.dev/blog/loopvar-preview?utm_source=reddit&x=1" {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	want := []string{
+		"The GOEXPERIMENT flag made it easy to test before upgrading.",
+		"Finally. This bit me twice with goroutines in a loop.",
+	}
+	if strings.Join(result.Comments, "|") != strings.Join(want, "|") {
+		t.Errorf("got comments %q, want %q", result.Comments, want)
+	}
+
+	self, err := r.Resolve(context.Background(), srv.Client(), srv.URL+"/r/golang/comments/18fghij/what_are_you_working_on/")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if self.Resolved {
+		t.Error("expected self post to stay unresolved")
+	}
+}
+
+func TestLobstersResolverResolve(t *testing.T) {
+	srv := fixtureServer(t, map[string]string{"/s/abc123.json": "lobsters_story.json"})
+
+	result, err := (&LobstersResolver{}).Resolve(context.Background(), srv.Client(), srv.URL+"/s/abc123/writing_sqlite_clone")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.Resolved || result.URL != "https://cstack.github.io/db_tutorial/" {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	want := []string{
+		"It stops before transactions, sadly.",
+		"Great series, the B-tree part is the best explanation I have read.",
+	}
+	if strings.Join(result.Comments, "|") != strings.Join(want, "|") {
+		t.Errorf("got comments %q, want %q", result.Comments, want)
+	}
+}
+
+func TestLemmyResolverResolve(t *testing.T) {
+	srv := fixtureServer(t, map[string]string{
+		"/api/v3/post":         "lemmy_post.json",
+		"/api/v3/comment/list": "lemmy_comments.json",
+	})
+
+	result, err := (&LemmyResolver{}).Resolve(context.Background(), srv.Client(), srv.URL+"/post/4242")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !result.Resolved || result.URL != "https://blog.rust-lang.org/2023/12/28/Rust-1.75.0.html" {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	want := []string{"Still no dyn support though.", "This removes so much boxing boilerplate."}
+	if strings.Join(result.Comments, "|") != strings.Join(want, "|") {
+		t.Errorf("got comments %q, want %q", result.Comments, want)
 	}
 }
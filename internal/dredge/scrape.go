@@ -1,72 +1,368 @@
 package dredge
 
 import (
+	"encoding/json"
 	"io"
+	"net/url"
+	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/alexzajac/the-dredger/internal/model"
 	"golang.org/x/net/html"
 )
 
+// PageMeta is the metadata a page declares about itself through <title>,
+// <meta> tags (including Open Graph and Twitter cards), <link
+// rel=canonical> and schema.org JSON-LD.
 type PageMeta struct {
 	Title       string
 	Description string
+	Canonical   string
+	SiteName    string
+	Author      string
+	Published   time.Time
+	Modified    time.Time
+	Image       string
+	// Type is og:type, or the JSON-LD @type when there is none.
+	Type     string
+	Language string
+	// ContentType is the response's media type, e.g. "text/html".
+	ContentType string
+	// Duration is the running time of a video or audio page.
+	Duration time.Duration
+}
+
+// Apply copies the scraped metadata onto link, leaving fields the page did
+// not declare untouched.
+func (m PageMeta) Apply(link *model.Link) {
+	set := func(dst *string, v string) {
+		if v != "" {
+			*dst = v
+		}
+	}
+	set(&link.Title, m.Title)
+	set(&link.Description, m.Description)
+	set(&link.CanonicalURL, m.Canonical)
+	set(&link.SiteName, m.SiteName)
+	set(&link.Author, m.Author)
+	set(&link.ImageURL, m.Image)
+	set(&link.PageType, m.Type)
+	set(&link.Language, m.Language)
+	set(&link.ContentType, m.ContentType)
+	if !m.Published.IsZero() {
+		link.PublishedAt = m.Published
+	}
+	if !m.Modified.IsZero() {
+		link.ModifiedAt = m.Modified
+	}
+	if m.Duration > 0 {
+		link.MediaDuration = m.Duration
+	}
+}
+
+// resolveRefs makes relative canonical and image URLs absolute.
+func (m *PageMeta) resolveRefs(pageURL string) {
+	base, err := url.Parse(pageURL)
+	if err != nil {
+		return
+	}
+	for _, ref := range []*string{&m.Canonical, &m.Image} {
+		if *ref == "" {
+			continue
+		}
+		if u, err := base.Parse(*ref); err == nil && (u.Scheme == "http" || u.Scheme == "https") {
+			*ref = u.String()
+		} else {
+			*ref = ""
+		}
+	}
 }
 
 func ScrapeMetadata(body io.Reader) PageMeta {
-	var meta PageMeta
+	var (
+		title, canonical, lang string
+		metas                  = make(map[string]string)
+		ldScripts              []string
+		inTitle, inLD          bool
+	)
 	z := html.NewTokenizer(body)
-	var inTitle bool
 
+scan:
 	for {
 		tt := z.Next()
 		switch tt {
 		case html.ErrorToken:
-			return meta
-		case html.StartTagToken:
+			break scan
+		case html.StartTagToken, html.SelfClosingTagToken:
 			tn, hasAttr := z.TagName()
-			if string(tn) == "title" {
-				inTitle = true
-			}
-			if string(tn) == "meta" && hasAttr {
-				var name, property, content string
-				for {
-					key, val, more := z.TagAttr()
-					k := strings.ToLower(string(key))
-					if k == "name" {
-						name = strings.ToLower(string(val))
-					}
-					if k == "property" {
-						property = strings.ToLower(string(val))
-					}
-					if k == "content" {
-						content = string(val)
-					}
-					if !more {
-						break
-					}
+			attrs := tagAttrs(z, hasAttr)
+			switch string(tn) {
+			case "title":
+				inTitle = tt == html.StartTagToken
+			case "html":
+				lang = attrs["lang"]
+			case "link":
+				if hasToken(attrs["rel"], "canonical") && canonical == "" {
+					canonical = attrs["href"]
 				}
-				if content != "" {
-					if name == "description" && meta.Description == "" {
-						meta.Description = content
-					}
-					if property == "og:description" && meta.Description == "" {
-						meta.Description = content
-					}
-					if property == "og:title" && meta.Title == "" {
-						meta.Title = content
+			case "script":
+				inLD = tt == html.StartTagToken && strings.EqualFold(attrs["type"], "application/ld+json")
+			case "meta":
+				content := strings.TrimSpace(attrs["content"])
+				if content == "" {
+					continue
+				}
+				for _, k := range []string{"name", "property", "itemprop"} {
+					key := strings.ToLower(attrs[k])
+					if _, seen := metas[key]; key != "" && !seen {
+						metas[key] = content
 					}
 				}
 			}
 		case html.TextToken:
 			if inTitle {
-				meta.Title = strings.TrimSpace(string(z.Text()))
+				title = strings.TrimSpace(string(z.Text()))
 				inTitle = false
 			}
+			if inLD {
+				ldScripts = append(ldScripts, string(z.Text()))
+				inLD = false
+			}
 		case html.EndTagToken:
 			tn, _ := z.TagName()
-			if string(tn) == "title" {
+			switch string(tn) {
+			case "title":
 				inTitle = false
+			case "script":
+				inLD = false
+			}
+		}
+	}
+
+	ld := parseJSONLD(ldScripts)
+	articleAuthor := metas["article:author"]
+	if strings.HasPrefix(articleAuthor, "http") {
+		// Facebook's article:author is usually a profile URL, not a name.
+		articleAuthor = ""
+	}
+
+	return PageMeta{
+		Title:       firstNonEmpty(title, metas["og:title"], metas["twitter:title"], ld.title),
+		Description: firstNonEmpty(metas["description"], metas["og:description"], metas["twitter:description"], ld.description),
+		Canonical:   firstNonEmpty(canonical, metas["og:url"], ld.url),
+		SiteName:    firstNonEmpty(metas["og:site_name"], metas["application-name"], ld.publisher),
+		Author:      firstNonEmpty(metas["author"], ld.author, articleAuthor, metas["twitter:creator"]),
+		Published:   parseMetaTime(firstNonEmpty(metas["article:published_time"], metas["datepublished"], ld.published, metas["date"])),
+		Modified:    parseMetaTime(firstNonEmpty(metas["article:modified_time"], metas["og:updated_time"], metas["datemodified"], ld.modified)),
+		Image:       firstNonEmpty(metas["og:image"], metas["og:image:url"], metas["twitter:image"], metas["twitter:image:src"], ld.image),
+		Type:        firstNonEmpty(metas["og:type"], ld.typ),
+		Language:    firstNonEmpty(lang, ld.language, strings.ReplaceAll(metas["og:locale"], "_", "-")),
+		Duration: parseMediaDuration(firstNonEmpty(metas["duration"], metas["og:video:duration"], metas["video:duration"],
+			metas["music:duration"], ld.duration)),
+	}
+}
+
+func tagAttrs(z *html.Tokenizer, hasAttr bool) map[string]string {
+	attrs := make(map[string]string)
+	for hasAttr {
+		var key, val []byte
+		key, val, hasAttr = z.TagAttr()
+		attrs[strings.ToLower(string(key))] = string(val)
+	}
+	return attrs
+}
+
+func hasToken(list, token string) bool {
+	for _, f := range strings.Fields(list) {
+		if strings.EqualFold(f, token) {
+			return true
+		}
+	}
+	return false
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+var metaTimeLayouts = []string{
+	time.RFC3339,
+	"2006-01-02T15:04:05Z0700",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+}
+
+func parseMetaTime(s string) time.Time {
+	for _, layout := range metaTimeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+var isoDurationRe = regexp.MustCompile(`^P(?:(\d+)D)?(?:T(?:(\d+)H)?(?:(\d+)M)?(?:(\d+(?:\.\d+)?)S)?)?$`)
+
+// parseMediaDuration reads a running time given as an ISO 8601 duration
+// ("PT1H2M3S", as schema.org uses) or a number of seconds (as Open Graph
+// uses).
+func parseMediaDuration(s string) time.Duration {
+	if s == "" {
+		return 0
+	}
+	if secs, err := strconv.ParseFloat(s, 64); err == nil && secs > 0 {
+		return time.Duration(secs * float64(time.Second))
+	}
+	m := isoDurationRe.FindStringSubmatch(strings.ToUpper(s))
+	if m == nil {
+		return 0
+	}
+	var d time.Duration
+	for i, unit := range []time.Duration{24 * time.Hour, time.Hour, time.Minute, time.Second} {
+		if n, err := strconv.ParseFloat(m[i+1], 64); err == nil {
+			d += time.Duration(n * float64(unit))
+		}
+	}
+	return d
+}
+
+// ldTypes are the schema.org types whose fields we read, mapped to the
+// page type we record for them.
+var ldTypes = map[string]string{
+	"Article":             "article",
+	"NewsArticle":         "article",
+	"BlogPosting":         "article",
+	"TechArticle":         "article",
+	"ScholarlyArticle":    "article",
+	"Report":              "article",
+	"SoftwareSourceCode":  "software",
+	"SoftwareApplication": "software",
+	"VideoObject":         "video",
+	"PodcastEpisode":      "podcast",
+	"AudioObject":         "audio",
+}
+
+type jsonLD struct {
+	typ, title, description, url, author, publisher string
+	published, modified, image, language, duration  string
+}
+
+// parseJSONLD returns the fields of the first supported schema.org object
+// found in the page's ld+json scripts, looking inside arrays and @graph.
+func parseJSONLD(scripts []string) jsonLD {
+	for _, script := range scripts {
+		var doc any
+		if err := json.Unmarshal([]byte(strings.TrimSpace(script)), &doc); err != nil {
+			continue
+		}
+		if obj := findLDObject(doc); obj != nil {
+			ld := jsonLD{
+				typ:         ldTypes[ldType(obj)],
+				title:       firstNonEmpty(ldString(obj["headline"]), ldString(obj["name"])),
+				description: ldString(obj["description"]),
+				url:         ldString(obj["url"]),
+				author:      ldNames(obj["author"]),
+				publisher:   ldNames(obj["publisher"]),
+				published:   firstNonEmpty(ldString(obj["datePublished"]), ldString(obj["uploadDate"]), ldString(obj["dateCreated"])),
+				modified:    ldString(obj["dateModified"]),
+				image:       firstNonEmpty(ldURL(obj["image"]), ldURL(obj["thumbnailUrl"])),
+				language:    ldString(obj["inLanguage"]),
+				duration:    ldString(obj["duration"]),
+			}
+			// On an article, timeRequired is its reading time rather than a
+			// running time.
+			if ld.duration == "" && (ld.typ == "video" || ld.typ == "podcast" || ld.typ == "audio") {
+				ld.duration = ldString(obj["timeRequired"])
+			}
+			return ld
+		}
+	}
+	return jsonLD{}
+}
+
+func findLDObject(v any) map[string]any {
+	switch v := v.(type) {
+	case []any:
+		for _, item := range v {
+			if obj := findLDObject(item); obj != nil {
+				return obj
+			}
+		}
+	case map[string]any:
+		if _, ok := ldTypes[ldType(v)]; ok {
+			return v
+		}
+		if graph, ok := v["@graph"]; ok {
+			return findLDObject(graph)
+		}
+	}
+	return nil
+}
+
+// ldType returns the first recognised @type, which may be a string or a
+// list of strings.
+func ldType(obj map[string]any) string {
+	switch t := obj["@type"].(type) {
+	case string:
+		return t
+	case []any:
+		for _, item := range t {
+			if s, ok := item.(string); ok {
+				if _, known := ldTypes[s]; known {
+					return s
+				}
+			}
+		}
+	}
+	return ""
+}
+
+func ldString(v any) string {
+	s, _ := v.(string)
+	return strings.TrimSpace(s)
+}
+
+// ldNames flattens a Person/Organization, or a list of them, to names.
+func ldNames(v any) string {
+	switch v := v.(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case map[string]any:
+		return ldString(v["name"])
+	case []any:
+		var names []string
+		for _, item := range v {
+			if n := ldNames(item); n != "" {
+				names = append(names, n)
+			}
+		}
+		return strings.Join(names, ", ")
+	}
+	return ""
+}
+
+// ldURL reads an image that may be a URL, an ImageObject, or a list.
+func ldURL(v any) string {
+	switch v := v.(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case map[string]any:
+		return ldString(v["url"])
+	case []any:
+		for _, item := range v {
+			if u := ldURL(item); u != "" {
+				return u
 			}
 		}
 	}
+	return ""
 }
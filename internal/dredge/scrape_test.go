@@ -0,0 +1,111 @@
+package dredge
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestScrapeMetadataTags(t *testing.T) {
+	page := `<!DOCTYPE html><html lang="en-GB"><head>
+<title> Page Title </title>
+<link rel="alternate stylesheet" href="/x.css">
+<link rel="Canonical" href="/posts/hello">
+<meta name="description" content="Plain description">
+<meta property="og:description" content="OG description">
+<meta property="og:site_name" content="The Blog">
+<meta property="og:type" content="article">
+<meta property="og:image" content="/img/cover.png">
+<meta name="author" content="Grace Hopper">
+<meta property="article:published_time" content="2024-05-01T10:00:00+02:00">
+<meta property="article:modified_time" content="2024-05-02">
+</head><body></body></html>`
+
+	m := ScrapeMetadata(strings.NewReader(page))
+	m.resolveRefs("https://blog.example.com/p?id=1")
+
+	want := PageMeta{
+		Title:       "Page Title",
+		Description: "Plain description",
+		Canonical:   "https://blog.example.com/posts/hello",
+		SiteName:    "The Blog",
+		Author:      "Grace Hopper",
+		Published:   time.Date(2024, 5, 1, 8, 0, 0, 0, time.UTC),
+		Modified:    time.Date(2024, 5, 2, 0, 0, 0, 0, time.UTC),
+		Image:       "https://blog.example.com/img/cover.png",
+		Type:        "article",
+		Language:    "en-GB",
+	}
+	if !m.Published.Equal(want.Published) || !m.Modified.Equal(want.Modified) {
+		t.Errorf("dates = %v, %v", m.Published, m.Modified)
+	}
+	m.Published, m.Modified = want.Published, want.Modified
+	if m != want {
+		t.Errorf("got  %+v\nwant %+v", m, want)
+	}
+}
+
+func TestScrapeMetadataJSONLD(t *testing.T) {
+	page := `<html><head>
+<meta name="twitter:title" content="Twitter title">
+<meta property="og:locale" content="fr_FR">
+<script type="application/ld+json">{"@context":"https://schema.org","@graph":[
+  {"@type":"WebSite","name":"Not this"},
+  {"@type":["NewsArticle"],"headline":"Ignored, twitter:title wins",
+   "author":[{"@type":"Person","name":"Ada"},{"@type":"Person","name":"Charles"}],
+   "publisher":{"@type":"Organization","name":"Gazette"},
+   "datePublished":"2023-01-15","image":{"@type":"ImageObject","url":"https://cdn.example.com/a.jpg"},
+   "url":"https://example.com/story"}
+]}</script>
+<script type="application/ld+json">{not json</script>
+</head></html>`
+
+	m := ScrapeMetadata(strings.NewReader(page))
+	if m.Title != "Twitter title" {
+		t.Errorf("Title = %q", m.Title)
+	}
+	if m.Author != "Ada, Charles" || m.SiteName != "Gazette" || m.Type != "article" {
+		t.Errorf("author/site/type = %q, %q, %q", m.Author, m.SiteName, m.Type)
+	}
+	if m.Canonical != "https://example.com/story" || m.Image != "https://cdn.example.com/a.jpg" {
+		t.Errorf("canonical/image = %q, %q", m.Canonical, m.Image)
+	}
+	if m.Published.Format("2006-01-02") != "2023-01-15" {
+		t.Errorf("Published = %v", m.Published)
+	}
+	if m.Language != "fr-FR" {
+		t.Errorf("Language = %q", m.Language)
+	}
+}
+
+func TestScrapeMetadataSoftwareAndVideo(t *testing.T) {
+	software := ScrapeMetadata(strings.NewReader(`<script type="application/ld+json">
+{"@type":"SoftwareSourceCode","name":"dredger","description":"A bookmark triage tool","author":"alex","dateCreated":"2022-02-02"}
+</script>`))
+	if software.Title != "dredger" || software.Description != "A bookmark triage tool" ||
+		software.Author != "alex" || software.Type != "software" || software.Published.IsZero() {
+		t.Errorf("software: %+v", software)
+	}
+
+	video := ScrapeMetadata(strings.NewReader(`<meta property="article:author" content="https://facebook.com/someone">
+<script type="application/ld+json">[{"@type":"VideoObject","name":"Talk","uploadDate":"2021-06-01T12:00:00Z","thumbnailUrl":["https://i.example.com/t.jpg"]}]</script>`))
+	if video.Title != "Talk" || video.Type != "video" || video.Image != "https://i.example.com/t.jpg" || video.Author != "" {
+		t.Errorf("video: %+v", video)
+	}
+}
+
+func TestScrapeMetadataTimeRequired(t *testing.T) {
+	article := ScrapeMetadata(strings.NewReader(`<script type="application/ld+json">
+{"@type":"BlogPosting","headline":"Long read","timeRequired":"PT12M"}
+</script>`))
+	if article.Duration != 0 {
+		t.Errorf("an article's reading time was taken as a running time: %s", article.Duration)
+	}
+
+	episode := ScrapeMetadata(strings.NewReader(`<script type="application/ld+json">
+{"@type":"PodcastEpisode","name":"Episode 1","timeRequired":"PT45M"}
+</script>`))
+	if episode.Duration != 45*time.Minute {
+		t.Errorf("episode duration = %s, want 45m", episode.Duration)
+	}
+}
@@ -0,0 +1,193 @@
+package dredge
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/alexzajac/the-dredger/internal/config"
+)
+
+// Summary is what a Summarizer produces for one link.
+type Summary struct {
+	Text string
+	Tags []TagGuess
+}
+
+// TagGuess is a proposed tag with the model's confidence from 0 to 1.
+type TagGuess struct {
+	Name       string  `json:"name"`
+	Confidence float64 `json:"confidence"`
+}
+
+// UnmarshalJSON also accepts a bare string, which small models sometimes
+// emit despite the schema. Such tags get zero confidence.
+func (g *TagGuess) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err == nil {
+		*g = TagGuess{Name: name}
+		return nil
+	}
+	type plain TagGuess
+	return json.Unmarshal(data, (*plain)(g))
+}
+
+// Summarizer turns crawled page data into a short summary and tags.
+type Summarizer interface {
+	// Ping reports whether the backend is reachable. The crunch phase is
+	// skipped entirely when it returns false.
+	Ping() bool
+	// Summarize sends a rendered prompt and parses the structured reply.
+	Summarize(ctx context.Context, prompt string) (Summary, error)
+}
+
+// NewSummarizer builds the backend selected by llm.provider.
+func NewSummarizer(cfg config.LLMConfig) Summarizer {
+	switch cfg.Provider {
+	case config.ProviderOpenAI:
+		return NewOpenAIClient(cfg.Endpoint, cfg.Model, cfg.APIKey, cfg.Timeout)
+	case config.ProviderNone:
+		return NoopSummarizer{}
+	default:
+		return NewOllamaClient(cfg.Endpoint, cfg.Model, cfg.Timeout)
+	}
+}
+
+// NoopSummarizer disables the crunch phase: links are crawled for
+// metadata but never sent to an LLM.
+type NoopSummarizer struct{}
+
+func (NoopSummarizer) Ping() bool { return false }
+
+func (NoopSummarizer) Summarize(context.Context, string) (Summary, error) {
+	return Summary{}, nil
+}
+
+// ErrMalformedResponse is returned when the LLM's output could not be
+// parsed or validated, even after a repair attempt.
+var ErrMalformedResponse = errors.New("malformed LLM response")
+
+// completer is the transport a backend provides: send a prompt, constrained
+// to summarySchema, and return the raw model output.
+type completer interface {
+	complete(ctx context.Context, prompt string) (string, error)
+}
+
+const maxTags = 8
+
+const jsonInstructions = `Respond with only a JSON object, no other text. Give each tag a confidence from 0 to 1 that it describes the page:
+{"summary": "<your summary>", "tags": [{"name": "<tag1>", "confidence": 0.9}, {"name": "<tag2>", "confidence": 0.6}]}`
+
+// summarySchema is the JSON schema the backends pass to the model so that
+// constrained decoding produces a llmResponse.
+var summarySchema = json.RawMessage(`{
+	"type": "object",
+	"properties": {
+		"summary": {"type": "string"},
+		"tags": {
+			"type": "array",
+			"items": {
+				"type": "object",
+				"properties": {
+					"name": {"type": "string"},
+					"confidence": {"type": "number"}
+				},
+				"required": ["name", "confidence"]
+			}
+		}
+	},
+	"required": ["summary", "tags"]
+}`)
+
+// llmResponse is the typed shape the model is asked to produce.
+type llmResponse struct {
+	Summary string     `json:"summary"`
+	Tags    []TagGuess `json:"tags"`
+}
+
+// summarize runs prompt through c and parses the result. Malformed output
+// gets one repair pass that shows the model its mistake.
+func summarize(ctx context.Context, c completer, prompt string) (Summary, error) {
+	raw, err := c.complete(ctx, prompt)
+	if err != nil {
+		return Summary{}, err
+	}
+	resp, parseErr := parseLLMResponse(raw)
+	if parseErr != nil {
+		raw, err = c.complete(ctx, repairPrompt(prompt, raw, parseErr))
+		if err != nil {
+			return Summary{}, err
+		}
+		resp, parseErr = parseLLMResponse(raw)
+		if parseErr != nil {
+			return Summary{}, fmt.Errorf("%w: %v", ErrMalformedResponse, parseErr)
+		}
+	}
+	return Summary{Text: resp.Summary, Tags: resp.Tags}, nil
+}
+
+func repairPrompt(prompt, bad string, parseErr error) string {
+	if len(bad) > 1000 {
+		bad = bad[:1000] + "..."
+	}
+	return fmt.Sprintf(`%s
+
+Your previous reply could not be used (%v):
+%s
+
+%s`, prompt, parseErr, bad, jsonInstructions)
+}
+
+// parseLLMResponse extracts and validates the JSON object in raw. It
+// tolerates code fences and chatter around the object.
+func parseLLMResponse(raw string) (llmResponse, error) {
+	start := strings.IndexByte(raw, '{')
+	end := strings.LastIndexByte(raw, '}')
+	if start == -1 || end < start {
+		return llmResponse{}, errors.New("no JSON object in output")
+	}
+
+	var resp llmResponse
+	if err := json.Unmarshal([]byte(raw[start:end+1]), &resp); err != nil {
+		return llmResponse{}, fmt.Errorf("invalid JSON: %w", err)
+	}
+	if err := resp.normalize(); err != nil {
+		return llmResponse{}, err
+	}
+	return resp, nil
+}
+
+// normalize trims and lowercases tags, drops blanks and duplicates, and
+// rejects responses missing a summary or any tags.
+func (r *llmResponse) normalize() error {
+	r.Summary = strings.TrimSpace(r.Summary)
+	if r.Summary == "" {
+		return errors.New("summary is empty")
+	}
+
+	seen := make(map[string]bool, len(r.Tags))
+	var tags []TagGuess
+	for _, t := range r.Tags {
+		t.Name = normalizeTag(t.Name)
+		if t.Name == "" || seen[t.Name] {
+			continue
+		}
+		seen[t.Name] = true
+		t.Confidence = min(max(t.Confidence, 0), 1)
+		tags = append(tags, t)
+	}
+	if len(tags) == 0 {
+		return errors.New("no tags")
+	}
+	if len(tags) > maxTags {
+		tags = tags[:maxTags]
+	}
+	r.Tags = tags
+	return nil
+}
+
+func normalizeTag(t string) string {
+	return strings.ToLower(strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(t), "#")))
+}
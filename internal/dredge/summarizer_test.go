@@ -0,0 +1,107 @@
+package dredge
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParseLLMResponse(t *testing.T) {
+	cases := map[string]string{
+		"plain":    `{"summary": "A guide to Go.", "tags": [{"name": "Go", "confidence": 0.9}, {"name": "#tutorial", "confidence": 1.4}, {"name": "go"}, {"name": " "}]}`,
+		"fenced":   "```json\n{\"summary\": \"A guide to Go.\", \"tags\": [\"go\", \"tutorial\"]}\n```",
+		"preamble": "Sure! Here is the JSON:\n{\"summary\": \"A guide to Go.\", \"tags\": [\"go\", \"tutorial\"]}",
+	}
+	for name, raw := range cases {
+		resp, err := parseLLMResponse(raw)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", name, err)
+			continue
+		}
+		if resp.Summary != "A guide to Go." {
+			t.Errorf("%s: got summary %q", name, resp.Summary)
+		}
+		if len(resp.Tags) != 2 || resp.Tags[0].Name != "go" || resp.Tags[1].Name != "tutorial" {
+			t.Errorf("%s: got tags %v, want [go tutorial]", name, resp.Tags)
+		}
+		if resp.Tags[1].Confidence > 1 {
+			t.Errorf("%s: confidence should be clamped, got %v", name, resp.Tags[1].Confidence)
+		}
+	}
+}
+
+func TestParseLLMResponseInvalid(t *testing.T) {
+	cases := map[string]string{
+		"legacy format": "**Summary:** A guide.\nTAGS: go",
+		"broken json":   `{"summary": "A guide", "tags": [}`,
+		"empty summary": `{"summary": "  ", "tags": ["go"]}`,
+		"no tags":       `{"summary": "A guide.", "tags": []}`,
+	}
+	for name, raw := range cases {
+		if _, err := parseLLMResponse(raw); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+	}
+}
+
+type fakeCompleter struct {
+	replies []string
+	prompts []string
+}
+
+func (f *fakeCompleter) complete(_ context.Context, prompt string) (string, error) {
+	f.prompts = append(f.prompts, prompt)
+	reply := f.replies[0]
+	f.replies = f.replies[1:]
+	return reply, nil
+}
+
+func TestSummarizeRepairsOnce(t *testing.T) {
+	f := &fakeCompleter{replies: []string{
+		"SUMMARY: oops\nTAGS: go",
+		`{"summary": "Fixed.", "tags": ["go"]}`,
+	}}
+	got, err := summarize(context.Background(), f, "prompt")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.Text != "Fixed." {
+		t.Errorf("got %q, want %q", got.Text, "Fixed.")
+	}
+	if len(f.prompts) != 2 || !strings.Contains(f.prompts[1], "SUMMARY: oops") {
+		t.Errorf("repair prompt should quote the bad output, got %q", f.prompts)
+	}
+}
+
+func TestSummarizeGivesUpAfterRepair(t *testing.T) {
+	f := &fakeCompleter{replies: []string{"nope", `{"summary": "", "tags": ["go"]}`}}
+	_, err := summarize(context.Background(), f, "prompt")
+	if !errors.Is(err, ErrMalformedResponse) {
+		t.Fatalf("got %v, want ErrMalformedResponse", err)
+	}
+	if !strings.Contains(err.Error(), "summary is empty") {
+		t.Errorf("error should say why: %v", err)
+	}
+}
+
+func TestTriageTags(t *testing.T) {
+	guesses := []TagGuess{
+		{Name: "golang", Confidence: 0.3},
+		{Name: "concurrency", Confidence: 0.95},
+		{Name: "rust-lang", Confidence: 0.5},
+	}
+
+	accepted, suggested := TriageTags(guesses, []string{"Golang", "databases"}, 0.8)
+	if strings.Join(accepted, ",") != "golang,concurrency" {
+		t.Errorf("accepted = %v, want [golang concurrency]", accepted)
+	}
+	if len(suggested) != 1 || suggested[0].Name != "rust-lang" {
+		t.Errorf("suggested = %v, want [rust-lang]", suggested)
+	}
+
+	accepted, suggested = TriageTags(guesses, nil, 0.8)
+	if len(accepted) != 3 || len(suggested) != 0 {
+		t.Errorf("empty vocabulary should accept everything, got %v / %v", accepted, suggested)
+	}
+}
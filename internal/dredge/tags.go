@@ -0,0 +1,38 @@
+package dredge
+
+// TriageTags splits the model's tag guesses into tags to apply now and
+// tags to queue for review. Tags already in the vocabulary are always
+// accepted; new tags need at least threshold confidence. With an empty
+// vocabulary (a fresh library) every tag is accepted so one can form.
+func TriageTags(guesses []TagGuess, vocabulary []string, threshold float64) (accepted []string, suggested []TagGuess) {
+	known := make(map[string]bool, len(vocabulary))
+	for _, v := range vocabulary {
+		known[normalizeTag(v)] = true
+	}
+
+	for _, g := range guesses {
+		switch {
+		case len(known) == 0, known[normalizeTag(g.Name)], g.Confidence >= threshold:
+			accepted = append(accepted, g.Name)
+		default:
+			suggested = append(suggested, g)
+		}
+	}
+	return accepted, suggested
+}
+
+// mergeTags returns the tags of each list in order, dropping repeats.
+func mergeTags(lists ...[]string) []string {
+	var merged []string
+	seen := make(map[string]bool)
+	for _, list := range lists {
+		for _, t := range list {
+			if t == "" || seen[normalizeTag(t)] {
+				continue
+			}
+			seen[normalizeTag(t)] = true
+			merged = append(merged, t)
+		}
+	}
+	return merged
+}
@@ -0,0 +1,111 @@
+package dredge
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/alexzajac/the-dredger/internal/config"
+)
+
+// waybackTimestamp is the Wayback Machine's snapshot time format.
+const waybackTimestamp = "20060102150405"
+
+// Wayback finds and requests Internet Archive snapshots of pages.
+type Wayback struct {
+	// Endpoint is the availability API and SaveEndpoint the Save Page Now
+	// prefix.
+	Endpoint     string
+	SaveEndpoint string
+	// SaveOnKeep asks for a fresh snapshot of each link kept in focus
+	// mode.
+	SaveOnKeep bool
+
+	client *http.Client
+}
+
+// NewWayback returns the configured Wayback client, or nil when Wayback
+// lookups are disabled.
+func NewWayback(cfg config.Config) *Wayback {
+	if !cfg.Wayback.Enabled {
+		return nil
+	}
+	return &Wayback{
+		Endpoint:     cfg.Wayback.Endpoint,
+		SaveEndpoint: cfg.Wayback.SaveEndpoint,
+		SaveOnKeep:   cfg.Wayback.SaveOnKeep,
+		client: &http.Client{
+			Timeout:   cfg.Dredge.Timeout,
+			Transport: newPoliteTransport(http.DefaultTransport, cfg.Dredge),
+		},
+	}
+}
+
+type waybackAvailability struct {
+	ArchivedSnapshots struct {
+		Closest struct {
+			Available bool   `json:"available"`
+			URL       string `json:"url"`
+			Timestamp string `json:"timestamp"`
+			Status    string `json:"status"`
+		} `json:"closest"`
+	} `json:"archived_snapshots"`
+}
+
+// Closest returns the snapshot of rawURL taken nearest to at, or the
+// latest one when at is zero. It returns "" when the page was never
+// archived.
+func (w *Wayback) Closest(ctx context.Context, rawURL string, at time.Time) (string, error) {
+	q := url.Values{"url": {rawURL}}
+	if !at.IsZero() {
+		q.Set("timestamp", at.UTC().Format(waybackTimestamp))
+	}
+	var avail waybackAvailability
+	if err := getJSON(ctx, w.client, w.Endpoint+"?"+q.Encode(), &avail); err != nil {
+		return "", fmt.Errorf("wayback lookup: %w", err)
+	}
+	closest := avail.ArchivedSnapshots.Closest
+	// A snapshot of an error page is no use as a copy.
+	if !closest.Available || closest.URL == "" || (closest.Status != "" && !strings.HasPrefix(closest.Status, "2")) {
+		return "", nil
+	}
+	// The API still hands out http:// snapshot links.
+	if rest, ok := strings.CutPrefix(closest.URL, "http://web.archive.org/"); ok {
+		return "https://web.archive.org/" + rest, nil
+	}
+	return closest.URL, nil
+}
+
+// Save asks the Wayback Machine to take a snapshot of rawURL now.
+func (w *Wayback) Save(ctx context.Context, rawURL string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, w.SaveEndpoint+rawURL, nil)
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request snapshot: %w", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("request snapshot: %w", newHTTPStatusError(rawURL, resp))
+	}
+	return nil
+}
+
+// pageGone reports whether a dredge failure means the page itself has
+// gone, rather than the request going wrong: a 404 or 410, or a host
+// that no longer resolves.
+func pageGone(err error) bool {
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode == http.StatusNotFound || statusErr.StatusCode == http.StatusGone
+	}
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr) && dnsErr.IsNotFound
+}
@@ -0,0 +1,120 @@
+package dredge
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/alexzajac/the-dredger/internal/db"
+	"github.com/alexzajac/the-dredger/internal/model"
+)
+
+// waybackServer fakes the availability API, answering with a snapshot for
+// every URL except those in missing, and records Save Page Now requests.
+func waybackServer(t *testing.T, missing string, saved *[]string) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/wayback/available":
+			page, ts := r.URL.Query().Get("url"), r.URL.Query().Get("timestamp")
+			if page == missing {
+				_, _ = fmt.Fprint(w, `{"url":"`+page+`","archived_snapshots":{}}`)
+				return
+			}
+			_, _ = fmt.Fprintf(w, `{"archived_snapshots":{"closest":{"available":true,"status":"200",
+				"url":"http://web.archive.org/web/%s/%s","timestamp":"%s"}}}`, ts, page, ts)
+		case len(r.URL.Path) > len("/save/"):
+			*saved = append(*saved, r.URL.Path[len("/save/"):])
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestWaybackClosest(t *testing.T) {
+	var saved []string
+	srv := waybackServer(t, "https://example.com/never", &saved)
+	w := &Wayback{Endpoint: srv.URL + "/wayback/available", SaveEndpoint: srv.URL + "/save/", client: srv.Client()}
+	ctx := context.Background()
+
+	added := time.Date(2019, 3, 4, 5, 6, 7, 0, time.UTC)
+	got, err := w.Closest(ctx, "https://example.com/post", added)
+	if err != nil {
+		t.Fatalf("closest: %v", err)
+	}
+	if want := "https://web.archive.org/web/20190304050607/https://example.com/post"; got != want {
+		t.Errorf("closest = %q, want %q", got, want)
+	}
+
+	if got, err := w.Closest(ctx, "https://example.com/never", time.Time{}); err != nil || got != "" {
+		t.Errorf("unarchived page: %q, %v", got, err)
+	}
+
+	if err := w.Save(ctx, "https://example.com/post"); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	if len(saved) != 1 || saved[0] != "https://example.com/post" {
+		t.Errorf("saved = %v", saved)
+	}
+}
+
+func TestPageGone(t *testing.T) {
+	cases := map[string]struct {
+		err  error
+		gone bool
+	}{
+		"404":       {&HTTPStatusError{StatusCode: 404}, true},
+		"410":       {fmt.Errorf("crawl: %w", &HTTPStatusError{StatusCode: 410}), true},
+		"503":       {&HTTPStatusError{StatusCode: 503}, false},
+		"no host":   {&net.DNSError{Err: "no such host", IsNotFound: true}, true},
+		"dns flake": {&net.DNSError{Err: "server misbehaving", IsTemporary: true}, false},
+	}
+	for name, c := range cases {
+		if got := pageGone(c.err); got != c.gone {
+			t.Errorf("%s: pageGone = %v, want %v", name, got, c.gone)
+		}
+	}
+}
+
+func TestFinishFindsArchiveForMissingPage(t *testing.T) {
+	var saved []string
+	srv := waybackServer(t, "", &saved)
+	database := openTestDB(t)
+	pageURL := srv.URL + "/gone"
+	id, _ := db.InsertLink(database, model.Link{URL: pageURL, Status: model.Saved})
+	if _, err := db.EnqueueDredgeJobs(database, []int64{id}); err != nil {
+		t.Fatalf("enqueue: %v", err)
+	}
+	job, _ := db.ClaimDredgeJob(database)
+	if job == nil {
+		t.Fatal("expected a job")
+	}
+
+	s := &Service{
+		db:          database,
+		client:      srv.Client(),
+		llm:         NoopSummarizer{},
+		maxAttempts: 1,
+		resolvers:   []Resolver{},
+		wayback:     &Wayback{Endpoint: srv.URL + "/wayback/available", client: srv.Client()},
+	}
+	ctx := context.Background()
+	result := s.finish(ctx, *job, s.process(ctx, *job, false, nil))
+	if result.Err == nil || result.ArchiveURL == "" {
+		t.Fatalf("expected a capsized result with an archive, got %+v", result)
+	}
+
+	links, _ := db.GetLinks(database)
+	if len(links) != 1 || links[0].ArchiveURL != result.ArchiveURL {
+		t.Fatalf("archive url not stored: %+v", links)
+	}
+	if got := links[0].OpenURL(); got != result.ArchiveURL {
+		t.Errorf("OpenURL = %q, want the archive", got)
+	}
+}
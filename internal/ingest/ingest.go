@@ -38,8 +38,27 @@ func BulkInsert(db *sql.DB, urls []string) (inserted, skipped int, err error) {
 	}
 	defer func() { _ = stmt.Close() }()
 
+	// A URL that an existing link declared as its canonical URL, or
+	// redirected to, is the same page under another address: bump that link
+	// instead of adding a copy.
+	canonStmt, err := tx.Prepare(`UPDATE links SET date_added = CURRENT_TIMESTAMP
+		WHERE (canonical_url = ? OR resolved_url = ?) AND url != ?`)
+	if err != nil {
+		return 0, 0, fmt.Errorf("prepare canonical check: %w", err)
+	}
+	defer func() { _ = canonStmt.Close() }()
+
 	for _, u := range urls {
-		res, err := stmt.Exec(u)
+		res, err := canonStmt.Exec(u, u, u)
+		if err != nil {
+			return inserted, skipped, fmt.Errorf("check canonical url %q: %w", u, err)
+		}
+		if n, _ := res.RowsAffected(); n > 0 {
+			skipped++
+			continue
+		}
+
+		res, err = stmt.Exec(u)
 		if err != nil {
 			return inserted, skipped, fmt.Errorf("insert url %q: %w", u, err)
 		}
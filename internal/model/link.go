@@ -1,6 +1,10 @@
 package model
 
-import "time"
+import (
+	"net/url"
+	"path/filepath"
+	"time"
+)
 
 type Status int
 
@@ -18,6 +22,23 @@ const (
 	DredgeCrunching
 	DredgeComplete
 	DredgeCapsized
+	// DredgeQueued marks a link waiting in the dredge job queue. It comes
+	// last so that stored values of the other states keep their meaning.
+	DredgeQueued
+)
+
+// ErrorClass says why a dredge failed, which decides whether it is retried.
+type ErrorClass int
+
+const (
+	ErrorNone ErrorClass = iota
+	// ErrorTransient failures (timeouts, 5xx, 429, DNS) are retried with
+	// backoff.
+	ErrorTransient
+	// ErrorPermanent failures (404, 410, TLS) are not retried.
+	ErrorPermanent
+	// ErrorLLM means the page was crawled but could not be summarised.
+	ErrorLLM
 )
 
 type Link struct {
@@ -31,7 +52,205 @@ type Link struct {
 	Enriched    bool
 	DredgeState DredgeState
 	DredgeError string
-	DateAdded   time.Time
+	// DredgeErrorClass classifies DredgeError.
+	DredgeErrorClass ErrorClass
+	DateAdded        time.Time
+
+	// Page metadata gathered while dredging.
+	CanonicalURL string
+	SiteName     string
+	Author       string
+	PublishedAt  time.Time
+	ModifiedAt   time.Time
+	ImageURL     string
+	PageType     string
+	Language     string
+	ContentType  string
+	// ResolvedURL is where URL ended up after redirects, when that is
+	// somewhere else; RedirectChain lists every hop from URL to it.
+	ResolvedURL   string
+	RedirectChain []string
+	// MediaDuration is the running time of a video or podcast.
+	MediaDuration time.Duration
+	// WordCount is the length of the page's article text and ReadingTime
+	// an estimate of how long it takes to read, recorded when the text is
+	// fetched.
+	WordCount   int
+	ReadingTime time.Duration
+	// Details holds kind-specific facts from a resolver, such as
+	// repository stats.
+	Details Details
+
+	// Link health, as last seen by the dead-link checker. CheckStatus is
+	// the HTTP status, or zero when the request failed with CheckError.
+	CheckStatus   int
+	CheckError    string
+	CheckedAt     time.Time
+	CheckFailures int
+	// Dead is set once the page is gone (404 or 410) or has failed too
+	// many checks in a row.
+	Dead bool
+	// ArchiveURL is the Wayback Machine snapshot found for a link whose
+	// page has gone.
+	ArchiveURL string
+	// ArchivePath is the local offline copy of the page, taken at
+	// ArchivedAt.
+	ArchivePath string
+	ArchivedAt  time.Time
+	// ReadAt is when the link was last read, ReadCount how many times it
+	// has been, and Progress the furthest through its text (0 to 1) the
+	// reader view has got. ReaderPosition is where the reader view was
+	// last left.
+	ReadAt         time.Time
+	ReadCount      int
+	Progress       float64
+	ReaderPosition float64
+}
+
+// Unread reports whether the link has never been read, or was marked
+// unread since.
+func (l Link) Unread() bool {
+	return l.ReadAt.IsZero()
+}
+
+// FinalURL is the address the link actually leads to: the resolved URL
+// when the link redirects, otherwise the saved URL.
+func (l Link) FinalURL() string {
+	if l.ResolvedURL != "" {
+		return l.ResolvedURL
+	}
+	return l.URL
+}
+
+// OpenURL is the address to open for reading: when the page is dead or
+// could not be dredged, the local copy or else the Wayback Machine's if
+// there is one, otherwise the saved URL.
+func (l Link) OpenURL() string {
+	if l.Dead || l.DredgeState == DredgeCapsized {
+		if l.ArchivePath != "" {
+			return l.LocalArchiveURL()
+		}
+		if l.ArchiveURL != "" {
+			return l.ArchiveURL
+		}
+	}
+	return l.URL
+}
+
+// LocalArchiveURL is the file:// URL of the local copy, or "" if there is
+// none.
+func (l Link) LocalArchiveURL() string {
+	if l.ArchivePath == "" {
+		return ""
+	}
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(l.ArchivePath)}).String()
+}
+
+// Details is structured information about a link that only some kinds of
+// link have. It is stored as JSON.
+type Details struct {
+	Repo  *RepoDetails  `json:"repo,omitempty"`
+	Paper *PaperDetails `json:"paper,omitempty"`
+}
+
+// IsZero reports whether no details are set.
+func (d Details) IsZero() bool {
+	return d.Repo == nil && d.Paper == nil
+}
+
+// RepoDetails are a source repository's stats.
+type RepoDetails struct {
+	FullName string    `json:"full_name"`
+	Stars    int       `json:"stars"`
+	Forks    int       `json:"forks"`
+	Language string    `json:"language,omitempty"`
+	Topics   []string  `json:"topics,omitempty"`
+	License  string    `json:"license,omitempty"`
+	PushedAt time.Time `json:"pushed_at,omitzero"`
+	Archived bool      `json:"archived,omitempty"`
+}
+
+// PaperDetails describe an academic paper.
+type PaperDetails struct {
+	Authors    []string `json:"authors,omitempty"`
+	Year       int      `json:"year,omitempty"`
+	Venue      string   `json:"venue,omitempty"`
+	Categories []string `json:"categories,omitempty"`
+	ArxivID    string   `json:"arxiv_id,omitempty"`
+	DOI        string   `json:"doi,omitempty"`
+}
+
+// SuggestedTag is an LLM-proposed tag outside the existing vocabulary
+// that is waiting for approval in the tag-review screen.
+type SuggestedTag struct {
+	ID         int64
+	LinkID     int64
+	LinkTitle  string
+	LinkURL    string
+	Tag        string
+	Confidence float64
+}
+
+// JobState is the lifecycle of a dredge job.
+type JobState int
+
+const (
+	JobQueued JobState = iota
+	JobRunning
+	JobDone
+	JobFailed
+)
+
+// JobMode says how much of the dredge a job redoes.
+type JobMode int
+
+const (
+	// JobFull crawls the page (revalidating any cached copy) and crunches it.
+	JobFull JobMode = iota
+	// JobRecrunch re-runs extraction and the LLM over cached pages only,
+	// without touching the network.
+	JobRecrunch
+)
+
+// DredgeJob is a queued request to dredge one link. URL, Tags, Status and
+// DateAdded are read from the link when the job is claimed.
+type DredgeJob struct {
+	ID        int64
+	LinkID    int64
+	URL       string
+	Tags      []string
+	Status    Status
+	DateAdded time.Time
+	State     JobState
+	Mode      JobMode
+	Attempts  int
+	NextRunAt time.Time
+	LastError string
+}
+
+// CachedPage is a fetched response body kept for conditional requests and
+// offline re-crunching.
+type CachedPage struct {
+	URL string
+	// LinkID is the link a page being saved was fetched for, or zero. A
+	// page can belong to several links.
+	LinkID int64
+	// Status is 200, or a redirect status with the redirect's Location,
+	// so that redirects can be followed offline.
+	Status       int
+	Location     string
+	ContentType  string
+	ETag         string
+	LastModified string
+	Body         []byte
+	FetchedAt    time.Time
+}
+
+// LinkContent is the readable text extracted from a link's page.
+type LinkContent struct {
+	LinkID    int64
+	Text      string
+	FetchedAt time.Time
 }
 
 func (s Status) String() string {
@@ -66,6 +285,21 @@ func (d DredgeState) String() string {
 		return "Complete"
 	case DredgeCapsized:
 		return "Capsized"
+	case DredgeQueued:
+		return "Queued"
+	default:
+		return ""
+	}
+}
+
+func (c ErrorClass) String() string {
+	switch c {
+	case ErrorTransient:
+		return "transient"
+	case ErrorPermanent:
+		return "permanent"
+	case ErrorLLM:
+		return "LLM"
 	default:
 		return ""
 	}
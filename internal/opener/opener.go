@@ -0,0 +1,183 @@
+// Package opener hands links to a browser: a configured command, or the
+// platform's own opener.
+package opener
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/alexzajac/the-dredger/internal/config"
+)
+
+// ErrNoBrowser means there is nothing to open links with, as in an SSH
+// session without a display.
+var ErrNoBrowser = errors.New("no browser available")
+
+// startupWait is how long Open waits for an opener to fail. xdg-open and
+// friends exit at once when they cannot find a handler; a browser that is
+// still running after this is assumed to be fine.
+const startupWait = 2 * time.Second
+
+// maxStderr bounds how much of a failed opener's output is read for its
+// error message.
+const maxStderr = 4 << 10
+
+// Opener opens URLs with open.command, or the platform's opener.
+type Opener struct {
+	command  string
+	terminal bool
+
+	goos     string
+	getenv   func(string) string
+	lookPath func(string) (string, error)
+}
+
+func New(cfg config.OpenConfig) *Opener {
+	return &Opener{
+		command:  strings.TrimSpace(cfg.Command),
+		terminal: cfg.Terminal,
+		goos:     runtime.GOOS,
+		getenv:   os.Getenv,
+		lookPath: exec.LookPath,
+	}
+}
+
+// InTerminal reports whether the command runs in the terminal and so
+// needs it handed over, rather than being started in the background.
+func (o *Opener) InTerminal() bool {
+	return o.terminal && o.command != ""
+}
+
+// Command returns the command that opens rawURL, or ErrNoBrowser when
+// there is none.
+func (o *Opener) Command(rawURL string) (*exec.Cmd, error) {
+	args, err := o.args(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	return exec.Command(args[0], args[1:]...), nil
+}
+
+func (o *Opener) args(rawURL string) ([]string, error) {
+	if o.command != "" {
+		args, err := splitArgs(o.command)
+		if err != nil {
+			return nil, fmt.Errorf("open.command: %w", err)
+		}
+		substituted := false
+		for i, a := range args {
+			if strings.Contains(a, "{url}") {
+				args[i] = strings.ReplaceAll(a, "{url}", rawURL)
+				substituted = true
+			}
+		}
+		if !substituted {
+			args = append(args, rawURL)
+		}
+		return args, nil
+	}
+
+	// A browser started for a remote session would open on a screen
+	// nobody is looking at, if anywhere.
+	hasDisplay := o.getenv("DISPLAY") != "" || o.getenv("WAYLAND_DISPLAY") != ""
+	if (o.getenv("SSH_CONNECTION") != "" || o.getenv("SSH_TTY") != "") && !hasDisplay {
+		return nil, ErrNoBrowser
+	}
+	switch o.goos {
+	case "darwin":
+		return []string{"open", rawURL}, nil
+	case "windows":
+		return []string{"rundll32", "url.dll,FileProtocolHandler", rawURL}, nil
+	}
+	if !hasDisplay {
+		return nil, ErrNoBrowser
+	}
+	if _, err := o.lookPath("xdg-open"); err != nil {
+		return nil, fmt.Errorf("%w: xdg-open not found", ErrNoBrowser)
+	}
+	return []string{"xdg-open", rawURL}, nil
+}
+
+// Open starts the opener for rawURL in the background and reports it
+// failing to start or exiting with an error soon after.
+func (o *Opener) Open(rawURL string) error {
+	cmd, err := o.Command(rawURL)
+	if err != nil {
+		return err
+	}
+	// Stderr goes to a file rather than a pipe: a browser started by the
+	// opener inherits it, and Wait would not return until the browser,
+	// rather than the opener, had exited.
+	stderr, err := os.CreateTemp("", "dredger-open-*")
+	if err != nil {
+		return fmt.Errorf("open %s: %w", rawURL, err)
+	}
+	defer func() {
+		_ = stderr.Close()
+		_ = os.Remove(stderr.Name())
+	}()
+	cmd.Stderr = stderr
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("open %s: %w", rawURL, err)
+	}
+	// The goroutine outlives a slow start, but only until the process it
+	// reaps exits.
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+	select {
+	case err := <-done:
+		if err != nil {
+			out, _ := io.ReadAll(io.NewSectionReader(stderr, 0, maxStderr))
+			if msg, _, _ := strings.Cut(strings.TrimSpace(string(out)), "\n"); msg != "" {
+				return fmt.Errorf("%s: %w: %s", cmd.Args[0], err, msg)
+			}
+			return fmt.Errorf("%s: %w", cmd.Args[0], err)
+		}
+	case <-time.After(startupWait):
+	}
+	return nil
+}
+
+// splitArgs splits a command line into arguments the way a shell would
+// for simple cases: on spaces, with single or double quotes grouping.
+func splitArgs(s string) ([]string, error) {
+	var args []string
+	var cur strings.Builder
+	var quote rune
+	inArg := false
+	for _, r := range s {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			cur.WriteRune(r)
+		case r == '\'' || r == '"':
+			quote, inArg = r, true
+		case r == ' ' || r == '\t':
+			if inArg {
+				args = append(args, cur.String())
+				cur.Reset()
+				inArg = false
+			}
+		default:
+			cur.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unclosed %c quote", quote)
+	}
+	if inArg {
+		args = append(args, cur.String())
+	}
+	if len(args) == 0 {
+		return nil, errors.New("empty command")
+	}
+	return args, nil
+}
@@ -0,0 +1,88 @@
+package opener
+
+import (
+	"errors"
+	"os/exec"
+	"slices"
+	"testing"
+	"time"
+)
+
+func testOpener(command, goos string, env map[string]string, path ...string) *Opener {
+	return &Opener{
+		command: command,
+		goos:    goos,
+		getenv:  func(k string) string { return env[k] },
+		lookPath: func(name string) (string, error) {
+			if slices.Contains(path, name) {
+				return "/usr/bin/" + name, nil
+			}
+			return "", exec.ErrNotFound
+		},
+	}
+}
+
+func TestArgs(t *testing.T) {
+	const u = "https://example.com/a?b=c"
+	x11 := map[string]string{"DISPLAY": ":0"}
+	ssh := map[string]string{"SSH_CONNECTION": "10.0.0.2 5000 10.0.0.1 22"}
+	cases := map[string]struct {
+		o    *Opener
+		want []string
+		err  error
+	}{
+		"mac":             {o: testOpener("", "darwin", nil), want: []string{"open", u}},
+		"linux":           {o: testOpener("", "linux", x11, "xdg-open"), want: []string{"xdg-open", u}},
+		"linux headless":  {o: testOpener("", "linux", nil, "xdg-open"), err: ErrNoBrowser},
+		"no xdg-open":     {o: testOpener("", "linux", x11), err: ErrNoBrowser},
+		"ssh":             {o: testOpener("", "darwin", ssh), err: ErrNoBrowser},
+		"template":        {o: testOpener("firefox --new-tab {url}", "linux", nil), want: []string{"firefox", "--new-tab", u}},
+		"appended":        {o: testOpener("lynx", "linux", ssh), want: []string{"lynx", u}},
+		"quoted":          {o: testOpener(`"/opt/My Browser/run" --url='{url}'`, "linux", nil), want: []string{"/opt/My Browser/run", "--url=" + u}},
+		"unclosed quote":  {o: testOpener(`firefox "{url}`, "linux", nil), err: errors.New("unclosed")},
+		"windows default": {o: testOpener("", "windows", nil), want: []string{"rundll32", "url.dll,FileProtocolHandler", u}},
+	}
+	for name, c := range cases {
+		got, err := c.o.args(u)
+		switch {
+		case c.err == ErrNoBrowser && !errors.Is(err, ErrNoBrowser):
+			t.Errorf("%s: err = %v, want ErrNoBrowser", name, err)
+		case c.err != nil && err == nil:
+			t.Errorf("%s: expected an error, got %q", name, got)
+		case c.err == nil && (err != nil || !slices.Equal(got, c.want)):
+			t.Errorf("%s: args = %q, %v; want %q", name, got, err, c.want)
+		}
+	}
+}
+
+func TestOpenReportsFailure(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no sh")
+	}
+	o := testOpener(`sh -c 'echo no handler for $0 >&2; exit 3' {url}`, "linux", nil)
+	err := o.Open("https://example.com")
+	if err == nil || err.Error() != "sh: exit status 3: no handler for https://example.com" {
+		t.Errorf("err = %v", err)
+	}
+
+	if err := testOpener("true", "linux", nil).Open("https://example.com"); err != nil {
+		t.Errorf("a command that succeeds: %v", err)
+	}
+}
+
+func TestOpenDoesNotWaitForBrowser(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("no sh")
+	}
+	// The opener fails at once but leaves a child holding its stderr, as an
+	// opener that started a browser would.
+	o := testOpener(`sh -c 'sleep 5 >/dev/null & echo no handler >&2; exit 4'`, "linux", nil)
+	start := time.Now()
+	err := o.Open("https://example.com")
+	if err == nil || err.Error() != "sh: exit status 4: no handler" {
+		t.Errorf("err = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed >= startupWait {
+		t.Errorf("Open waited %s for the opener's child", elapsed)
+	}
+}
@@ -4,15 +4,18 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"slices"
 
 	"charm.land/bubbles/v2/list"
 	"charm.land/bubbles/v2/progress"
 	"charm.land/bubbles/v2/spinner"
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
+	"github.com/alexzajac/the-dredger/internal/config"
 	"github.com/alexzajac/the-dredger/internal/db"
 	"github.com/alexzajac/the-dredger/internal/dredge"
 	"github.com/alexzajac/the-dredger/internal/model"
+	"github.com/alexzajac/the-dredger/internal/opener"
 )
 
 const keyCtrlC = "ctrl+c"
@@ -22,9 +25,12 @@ var docStyle = lipgloss.NewStyle().Margin(1, 2)
 type appMode int
 
 const (
-	modeList  appMode = 0
-	modeFocus appMode = 1
-	modeGrid  appMode = 2
+	modeList      appMode = 0
+	modeFocus     appMode = 1
+	modeGrid      appMode = 2
+	modeTagReview appMode = 3
+	modeDeadLinks appMode = 4
+	modeReader    appMode = 5
 )
 
 type listView int
@@ -36,14 +42,25 @@ const (
 
 type App struct {
 	db     *sql.DB
+	cfg    config.Config
+	opener *opener.Opener
+	keys   config.ListKeys
 	list   list.Model
 	width  int
 	height int
 
-	mode     appMode
-	focus    FocusModel
-	grid     GridModel
-	listView listView
+	mode      appMode
+	focus     FocusModel
+	grid      GridModel
+	tagReview TagReviewModel
+	deadLinks DeadLinksModel
+	reader    ReaderModel
+	listView  listView
+	// readerFrom is the mode the reader was opened from.
+	readerFrom appMode
+	// unreadOnly hides links that have been read, in the list and in the
+	// focus and grid views opened from it.
+	unreadOnly bool
 
 	spinner      spinner.Model
 	progress     progress.Model
@@ -52,9 +69,12 @@ type App struct {
 	dredgeDone   int
 	dredgeCancel context.CancelFunc
 	resultsCh    <-chan dredge.Result
+	// dredgeErr is why the dredge queue could not be read, while the
+	// workers wait to try again.
+	dredgeErr error
 }
 
-func NewApp(database *sql.DB) App {
+func NewApp(database *sql.DB, cfg config.Config) App {
 	delegate := list.NewDefaultDelegate()
 	l := list.New([]list.Item{}, delegate, 0, 0)
 	l.Title = "The Dredger — Pending"
@@ -68,6 +88,9 @@ func NewApp(database *sql.DB) App {
 
 	return App{
 		db:       database,
+		cfg:      cfg,
+		opener:   opener.New(cfg.Open),
+		keys:     cfg.Keys.List,
 		list:     l,
 		spinner:  s,
 		progress: p,
@@ -81,37 +104,95 @@ func (a App) Init() tea.Cmd {
 
 func (a App) loadLinks() tea.Msg {
 	links, err := db.GetLinksByStatus(a.db, model.Unprocessed)
-	return LinksLoadedMsg{Links: links, Err: err}
+	return LinksLoadedMsg{Links: a.visible(links), Err: err}
 }
 
 func (a App) loadSavedLinks() tea.Msg {
 	links, err := db.GetLinksByStatus(a.db, model.Saved)
-	return LinksLoadedMsg{Links: links, Err: err}
+	return LinksLoadedMsg{Links: a.visible(links), Err: err}
 }
 
+// visible drops read links when the list shows unread ones only.
+func (a App) visible(links []model.Link) []model.Link {
+	if !a.unreadOnly {
+		return links
+	}
+	return slices.DeleteFunc(links, func(l model.Link) bool { return !l.Unread() })
+}
+
+// reloadList reloads whichever list is showing.
+func (a App) reloadList() tea.Cmd {
+	if a.listView == viewSaved {
+		return a.loadSavedLinks
+	}
+	return a.loadLinks
+}
+
+// listTitle names the list showing.
+func (a App) listTitle() string {
+	title := "The Dredger — Pending"
+	if a.listView == viewSaved {
+		title = "The Dredger — Saved"
+	}
+	if a.unreadOnly {
+		title += " (unread)"
+	}
+	return title
+}
+
+// startDredge queues every link that has not been dredged yet.
 func (a App) startDredge() tea.Cmd {
 	return func() tea.Msg {
-		unprocessed, err := db.GetUnprocessedLinks(a.db)
+		queued, err := db.EnqueueUndredged(a.db)
 		if err != nil {
-			return DredgeDoneMsg{Err: fmt.Errorf("fetch unprocessed links: %w", err)}
+			return DredgeDoneMsg{Err: fmt.Errorf("queue undredged links: %w", err)}
 		}
-		if len(unprocessed) == 0 {
+		return dredgeQueuedInternal{count: queued}
+	}
+}
+
+// dredgeSingleLink queues one link for a manual dredge.
+func (a App) dredgeSingleLink(linkID int64) tea.Cmd {
+	return func() tea.Msg {
+		queued, err := db.EnqueueDredgeJobs(a.db, []int64{linkID})
+		if err != nil {
+			return DredgeLinkResultMsg{LinkID: linkID, State: model.DredgeCapsized, Error: err.Error()}
+		}
+		return dredgeQueuedInternal{count: queued}
+	}
+}
+
+// runDredgeQueue starts a service that drains the dredge job queue.
+func (a App) runDredgeQueue() tea.Cmd {
+	return func() tea.Msg {
+		total, err := db.CountDueDredgeJobs(a.db)
+		if err != nil {
+			return DredgeDoneMsg{Err: err}
+		}
+		if total == 0 {
 			return DredgeDoneMsg{}
 		}
 
+		svc, err := dredge.NewService(a.db, a.cfg)
+		if err != nil {
+			return DredgeDoneMsg{Err: err}
+		}
 		ctx, cancel := context.WithCancel(context.Background())
-
-		svc := dredge.NewService(a.db, 4)
-		go svc.Run(ctx, unprocessed)
+		go svc.Run(ctx)
 
 		return dredgeStartInternal{
-			total:   len(unprocessed),
+			total:   total,
 			cancel:  cancel,
 			results: svc.Results(),
 		}
 	}
 }
 
+// dredgeQueuedInternal reports links added to the job queue.
+type dredgeQueuedInternal struct {
+	count int
+}
+
 type dredgeStartInternal struct {
 	total   int
 	cancel  context.CancelFunc
@@ -128,36 +209,30 @@ func waitForResult(ch <-chan dredge.Result) tea.Cmd {
 	}
 }
 
-func (a App) dredgeSingleLink(linkID int64, url string) tea.Cmd {
-	return func() tea.Msg {
-		// Set state to crawling
-		_ = db.UpdateDredgeState(a.db, linkID, model.DredgeCrawling, "")
-
-		svc := dredge.NewService(a.db, 1)
-		link := model.Link{ID: linkID, URL: url}
-		ctx := context.Background()
-
-		go svc.Run(ctx, []model.Link{link})
-
-		result := <-svc.Results()
-
-		if result.Err != nil {
-			return DredgeLinkResultMsg{
-				LinkID: linkID,
-				State:  model.DredgeCapsized,
-				Error:  result.Err.Error(),
-			}
+// dredgeLinkResult converts a queue result for the focus card.
+func dredgeLinkResult(result dredge.Result) DredgeLinkResultMsg {
+	if result.Err != nil {
+		state := model.DredgeCapsized
+		if !result.RetryAt.IsZero() {
+			state = model.DredgeQueued
 		}
-
 		return DredgeLinkResultMsg{
-			LinkID:      linkID,
-			State:       model.DredgeComplete,
-			Title:       result.Title,
-			Description: result.Description,
-			Summary:     result.Summary,
-			Tags:        result.Tags,
+			LinkID:     result.LinkID,
+			State:      state,
+			Error:      result.Err.Error(),
+			ErrorClass: result.Class,
+			ArchiveURL: result.ArchiveURL,
 		}
 	}
+	return DredgeLinkResultMsg{
+		LinkID:      result.LinkID,
+		State:       model.DredgeComplete,
+		Title:       result.Title,
+		Description: result.Description,
+		Summary:     result.Summary,
+		Tags:        result.Tags,
+		Meta:        result.Meta,
+	}
 }
 
 func (a App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
@@ -175,14 +250,105 @@ func (a App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		a.grid.width = msg.Width
 		a.grid.height = msg.Height
 		a.grid.recalcLayout()
+		a.tagReview.setSize(msg.Width, msg.Height)
+		a.deadLinks.setSize(msg.Width, msg.Height)
+		a.reader.setSize(msg.Width, msg.Height)
 		return a, nil
 
+	case OpenReaderMsg:
+		a.readerFrom = a.mode
+		a.mode = modeReader
+		a.reader = NewReaderModel(a.db, a.cfg, msg.Link, a.width, a.height)
+		return a, a.reader.Init()
+
+	case ReaderExitMsg:
+		a.mode = a.readerFrom
+		if a.mode == modeGrid {
+			var cmd tea.Cmd
+			a.grid, cmd = a.grid.Update(msg)
+			return a, cmd
+		}
+		var cmd tea.Cmd
+		a.focus, cmd = a.focus.Update(msg)
+		return a, cmd
+
 	case FocusExitMsg:
 		a.mode = modeList
 		if a.listView == viewSaved {
 			return a, a.loadSavedLinks
 		}
 		return a, a.loadLinks
+
+	// Dredge progress is handled here so that it keeps flowing whichever
+	// mode is on screen.
+	case dredgeQueuedInternal:
+		if a.dredging {
+			a.dredgeTotal += msg.count
+			return a, nil
+		}
+		return a, a.runDredgeQueue()
+
+	case dredgeStartInternal:
+		a.dredging = true
+		a.dredgeTotal = msg.total
+		a.dredgeDone = 0
+		a.dredgeCancel = msg.cancel
+		a.resultsCh = msg.results
+		if a.height > 0 {
+			a.list.SetSize(a.width-4, a.height-5)
+		}
+		return a, tea.Batch(a.spinner.Tick, waitForResult(a.resultsCh))
+
+	case DredgeResultMsg:
+		if msg.Result.LinkID == 0 {
+			a.dredgeErr = msg.Result.Err
+			return a, waitForResult(a.resultsCh)
+		}
+		a.dredgeErr = nil
+		// A link queued for retry is still part of the run.
+		if msg.Result.RetryAt.IsZero() {
+			a.dredgeDone++
+		}
+		a.updateListItem(msg.Result)
+		var cmds []tea.Cmd
+		if a.dredgeTotal > 0 {
+			cmds = append(cmds, a.progress.SetPercent(float64(a.dredgeDone)/float64(a.dredgeTotal)))
+		}
+		cmds = append(cmds, waitForResult(a.resultsCh))
+		if a.mode == modeFocus {
+			var cmd tea.Cmd
+			a.focus, cmd = a.focus.Update(dredgeLinkResult(msg.Result))
+			cmds = append(cmds, cmd)
+		}
+		return a, tea.Batch(cmds...)
+
+	case DredgeDoneMsg:
+		wasRunning := a.dredging && a.dredgeDone > 0
+		a.dredging = false
+		a.dredgeErr = nil
+		a.dredgeCancel = nil
+		if a.height > 0 {
+			a.list.SetSize(a.width-4, a.height-4)
+		}
+		// Links queued while the workers were winding down are picked up
+		// by a fresh run.
+		if wasRunning && msg.Err == nil {
+			return a, a.runDredgeQueue()
+		}
+		return a, nil
+
+	case spinner.TickMsg:
+		if a.dredging {
+			var cmd tea.Cmd
+			a.spinner, cmd = a.spinner.Update(msg)
+			return a, cmd
+		}
+		return a, nil
+
+	case progress.FrameMsg:
+		var cmd tea.Cmd
+		a.progress, cmd = a.progress.Update(msg)
+		return a, cmd
 	}
 
 	// Delegate to focus mode
@@ -194,6 +360,18 @@ func (a App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		return a.updateGrid(msg)
 	}
 
+	if a.mode == modeTagReview {
+		return a.updateTagReview(msg)
+	}
+
+	if a.mode == modeDeadLinks {
+		return a.updateDeadLinks(msg)
+	}
+
+	if a.mode == modeReader {
+		return a.updateReader(msg)
+	}
+
 	return a.updateList(msg)
 }
 
@@ -201,7 +379,7 @@ func (a App) updateFocus(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyPressMsg:
 		switch msg.String() {
-		case "q", keyCtrlC:
+		case a.keys.Quit, keyCtrlC:
 			if a.dredgeCancel != nil {
 				a.dredgeCancel()
 			}
@@ -209,12 +387,13 @@ func (a App) updateFocus(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 
 	case TriggerDredgeLinkMsg:
-		cmd := a.dredgeSingleLink(msg.LinkID, msg.URL)
-		// Update current link state to crawling immediately
+		// Show the link as queued immediately
 		if a.focus.current != nil && a.focus.current.ID == msg.LinkID {
-			a.focus.current.DredgeState = model.DredgeCrawling
+			a.focus.current.DredgeState = model.DredgeQueued
+			a.focus.current.DredgeError = ""
+			a.focus.current.DredgeErrorClass = model.ErrorNone
 		}
-		return a, cmd
+		return a, a.dredgeSingleLink(msg.LinkID)
 	}
 
 	var cmd tea.Cmd
@@ -227,7 +406,7 @@ func (a App) updateGrid(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case tea.KeyPressMsg:
 		if !a.grid.searching && !a.grid.showSerendipity {
 			switch msg.String() {
-			case "q", keyCtrlC:
+			case a.keys.Quit, keyCtrlC:
 				if a.dredgeCancel != nil {
 					a.dredgeCancel()
 				}
@@ -245,6 +424,76 @@ func (a App) updateGrid(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return a, cmd
 }
 
+func (a App) updateTagReview(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyPressMsg:
+		switch msg.String() {
+		case a.keys.Quit, keyCtrlC:
+			if a.dredgeCancel != nil {
+				a.dredgeCancel()
+			}
+			return a, tea.Quit
+		}
+
+	case TagReviewExitMsg:
+		a.mode = modeList
+		if a.listView == viewSaved {
+			return a, a.loadSavedLinks
+		}
+		return a, a.loadLinks
+	}
+
+	var cmd tea.Cmd
+	a.tagReview, cmd = a.tagReview.Update(msg)
+	return a, cmd
+}
+
+func (a App) updateDeadLinks(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyPressMsg:
+		if !a.deadLinks.replacing {
+			switch msg.String() {
+			case a.keys.Quit, keyCtrlC:
+				if a.dredgeCancel != nil {
+					a.dredgeCancel()
+				}
+				return a, tea.Quit
+			}
+		}
+
+	case TriggerDredgeLinkMsg:
+		return a, a.dredgeSingleLink(msg.LinkID)
+
+	case DeadLinksExitMsg:
+		a.mode = modeList
+		if a.listView == viewSaved {
+			return a, a.loadSavedLinks
+		}
+		return a, a.loadLinks
+	}
+
+	var cmd tea.Cmd
+	a.deadLinks, cmd = a.deadLinks.Update(msg)
+	return a, cmd
+}
+
+func (a App) updateReader(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if msg, ok := msg.(tea.KeyPressMsg); ok {
+		switch msg.String() {
+		case a.keys.Quit, keyCtrlC:
+			a.reader.save()
+			if a.dredgeCancel != nil {
+				a.dredgeCancel()
+			}
+			return a, tea.Quit
+		}
+	}
+
+	var cmd tea.Cmd
+	a.reader, cmd = a.reader.Update(msg)
+	return a, cmd
+}
+
 func (a App) updateList(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyPressMsg:
@@ -252,12 +501,12 @@ func (a App) updateList(msg tea.Msg) (tea.Model, tea.Cmd) {
 			break // let list handle filter input
 		}
 		switch msg.String() {
-		case "q", keyCtrlC:
+		case a.keys.Quit, keyCtrlC:
 			if a.dredgeCancel != nil {
 				a.dredgeCancel()
 			}
 			return a, tea.Quit
-		case "f":
+		case a.keys.Focus:
 			a.mode = modeFocus
 			ctx := focusPending
 			if a.listView == viewSaved {
@@ -268,28 +517,39 @@ func (a App) updateList(msg tea.Msg) (tea.Model, tea.Cmd) {
 				link := sel.link
 				startLink = &link
 			}
-			a.focus = NewFocusModel(a.db, a.width, a.height, ctx, startLink)
+			a.focus = NewFocusModel(a.db, a.cfg.Keys.Focus, dredge.NewWayback(a.cfg), a.archiver(), a.opener, a.width, a.height, ctx, startLink, a.unreadOnly)
 			return a, a.focus.Init()
-		case "b":
+		case a.keys.SwitchView:
 			if a.listView == viewPending {
 				a.listView = viewSaved
-				a.list.Title = "The Dredger — Saved"
-				return a, a.loadSavedLinks
+			} else {
+				a.listView = viewPending
 			}
-			a.listView = viewPending
-			a.list.Title = "The Dredger — Pending"
-			return a, a.loadLinks
-		case "g":
+			a.list.Title = a.listTitle()
+			return a, a.reloadList()
+		case a.keys.Unread:
+			a.unreadOnly = !a.unreadOnly
+			a.list.Title = a.listTitle()
+			return a, a.reloadList()
+		case a.keys.Grid:
 			if a.listView == viewSaved {
 				a.mode = modeGrid
-				a.grid = NewGridModel(a.db, a.width, a.height)
+				a.grid = NewGridModel(a.db, a.cfg.Keys.Grid, a.opener, a.width, a.height, a.unreadOnly)
 				return a, a.grid.Init()
 			}
-		case "r":
+		case a.keys.Dredge:
 			if !a.dredging {
 				return a, a.startDredge()
 			}
-		case "/":
+		case a.keys.TagReview:
+			a.mode = modeTagReview
+			a.tagReview = NewTagReviewModel(a.db, a.cfg.Keys.TagReview, a.width, a.height)
+			return a, a.tagReview.Init()
+		case a.keys.DeadLinks:
+			a.mode = modeDeadLinks
+			a.deadLinks = NewDeadLinksModel(a.db, a.cfg, a.width, a.height)
+			return a, a.deadLinks.Init()
+		case a.keys.Filter:
 			a.list.SetFilteringEnabled(true)
 		}
 
@@ -306,47 +566,6 @@ func (a App) updateList(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return a, a.startDredge()
 		}
 		return a, nil
-
-	case dredgeStartInternal:
-		a.dredging = true
-		a.dredgeTotal = msg.total
-		a.dredgeDone = 0
-		a.dredgeCancel = msg.cancel
-		a.resultsCh = msg.results
-		if a.height > 0 {
-			a.list.SetSize(a.width-4, a.height-5)
-		}
-		return a, tea.Batch(a.spinner.Tick, waitForResult(a.resultsCh))
-
-	case DredgeResultMsg:
-		a.dredgeDone++
-		a.updateListItem(msg.Result)
-		var cmds []tea.Cmd
-		if a.dredgeTotal > 0 {
-			cmds = append(cmds, a.progress.SetPercent(float64(a.dredgeDone)/float64(a.dredgeTotal)))
-		}
-		cmds = append(cmds, waitForResult(a.resultsCh))
-		return a, tea.Batch(cmds...)
-
-	case DredgeDoneMsg:
-		a.dredging = false
-		if a.height > 0 {
-			a.list.SetSize(a.width-4, a.height-4)
-		}
-		return a, nil
-
-	case spinner.TickMsg:
-		if a.dredging {
-			var cmd tea.Cmd
-			a.spinner, cmd = a.spinner.Update(msg)
-			return a, cmd
-		}
-		return a, nil
-
-	case progress.FrameMsg:
-		var cmd tea.Cmd
-		a.progress, cmd = a.progress.Update(msg)
-		return a, cmd
 	}
 
 	var cmd tea.Cmd
@@ -382,6 +601,12 @@ func (a App) View() tea.View {
 		content = a.focus.View()
 	case modeGrid:
 		content = a.grid.View()
+	case modeTagReview:
+		content = a.tagReview.View()
+	case modeDeadLinks:
+		content = a.deadLinks.View()
+	case modeReader:
+		content = a.reader.View()
 	default:
 		var enrichmentBar string
 		if a.dredging {
@@ -389,6 +614,10 @@ func (a App) View() tea.View {
 			enrichmentBar = enrichmentBarStyle.Width(a.width).Render(
 				a.spinner.View()+fmt.Sprintf(" Dredging... %d/%d  ", a.dredgeDone, a.dredgeTotal)+bar,
 			) + "\n"
+			if a.dredgeErr != nil {
+				enrichmentBar += lipgloss.NewStyle().Foreground(pruneColor).Width(a.width).
+					Render("Retrying: "+a.dredgeErr.Error()) + "\n"
+			}
 		}
 
 		viewLabel := "pending"
@@ -398,16 +627,19 @@ func (a App) View() tea.View {
 
 		gridHint := ""
 		if a.listView == viewSaved {
-			gridHint = statusTextStyle.Render("g") + " grid  "
+			gridHint = statusTextStyle.Render(a.keys.Grid) + " grid  "
 		}
 
 		statusBar := statusBarStyle.Width(a.width).Render(
-			statusTextStyle.Render("q") + " quit  " +
-				statusTextStyle.Render("f") + " focus  " +
-				statusTextStyle.Render("b") + " " + viewLabel + "  " +
+			statusTextStyle.Render(a.keys.Quit) + " quit  " +
+				statusTextStyle.Render(a.keys.Focus) + " focus  " +
+				statusTextStyle.Render(a.keys.SwitchView) + " " + viewLabel + "  " +
 				gridHint +
-				statusTextStyle.Render("r") + " dredge  " +
-				statusTextStyle.Render("/") + " filter  " +
+				statusTextStyle.Render(a.keys.Dredge) + " dredge  " +
+				statusTextStyle.Render(a.keys.TagReview) + " tags  " +
+				statusTextStyle.Render(a.keys.DeadLinks) + " dead links  " +
+				statusTextStyle.Render(a.keys.Unread) + " unread  " +
+				statusTextStyle.Render(a.keys.Filter) + " filter  " +
 				statusTextStyle.Render("↑↓") + " navigate",
 		)
 
@@ -418,3 +650,16 @@ func (a App) View() tea.View {
 	v.AltScreen = true
 	return v
 }
+
+// archiver returns the offline archiver, or nil when archiving is disabled
+// or has nowhere to write.
+func (a App) archiver() *dredge.Archiver {
+	if !a.cfg.Archive.Enabled {
+		return nil
+	}
+	archiver, err := dredge.NewArchiver(a.db, a.cfg)
+	if err != nil {
+		return nil
+	}
+	return archiver
+}
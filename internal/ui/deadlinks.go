@@ -0,0 +1,286 @@
+package ui
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"charm.land/bubbles/v2/textinput"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/alexzajac/the-dredger/internal/config"
+	"github.com/alexzajac/the-dredger/internal/db"
+	"github.com/alexzajac/the-dredger/internal/dredge"
+	"github.com/alexzajac/the-dredger/internal/model"
+	"github.com/alexzajac/the-dredger/internal/opener"
+)
+
+// waybackLatest redirects to the most recent archived copy of the URL
+// appended to it, for dead links without a snapshot on record.
+const waybackLatest = "https://web.archive.org/web/2/"
+
+// DeadLinksModel lists links the checker flagged dead so they can be
+// pruned, pointed at a new URL, opened from the Wayback Machine or
+// checked again.
+type DeadLinksModel struct {
+	db      *sql.DB
+	checker *dredge.Checker
+	opener  *opener.Opener
+	keys    config.DeadLinksKeys
+	links   []model.Link
+	cursor  int
+	scroll  int
+	err     error
+	notice  string
+
+	replacing    bool
+	replaceInput textinput.Model
+
+	width, height int
+}
+
+func NewDeadLinksModel(database *sql.DB, cfg config.Config, width, height int) DeadLinksModel {
+	ti := textinput.New()
+	ti.Placeholder = "https://..."
+	ti.CharLimit = 2048
+
+	return DeadLinksModel{
+		db:           database,
+		checker:      dredge.NewChecker(database, cfg),
+		opener:       opener.New(cfg.Open),
+		keys:         cfg.Keys.DeadLinks,
+		replaceInput: ti,
+		width:        width,
+		height:       height,
+	}
+}
+
+func (m DeadLinksModel) Init() tea.Cmd {
+	return m.loadDeadLinks
+}
+
+func (m DeadLinksModel) loadDeadLinks() tea.Msg {
+	links, err := db.GetDeadLinks(m.db)
+	return DeadLinksLoadedMsg{Links: links, Err: err}
+}
+
+func (m DeadLinksModel) recheck(link model.Link) tea.Cmd {
+	return func() tea.Msg {
+		checked, err := m.checker.Check(context.Background(), link)
+		return DeadLinkCheckedMsg{Link: checked, Err: err}
+	}
+}
+
+func (m DeadLinksModel) Update(msg tea.Msg) (DeadLinksModel, tea.Cmd) {
+	m, cmd := m.update(msg)
+	m.clampScroll()
+	return m, cmd
+}
+
+func (m DeadLinksModel) update(msg tea.Msg) (DeadLinksModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case DeadLinksLoadedMsg:
+		m.links = msg.Links
+		m.err = msg.Err
+		m.cursor = min(m.cursor, max(len(m.links)-1, 0))
+		return m, nil
+
+	case DeadLinkCheckedMsg:
+		m.err = msg.Err
+		switch {
+		case msg.Err != nil:
+		case msg.Link.Dead:
+			m.notice = "Still dead: " + checkSummary(msg.Link)
+		default:
+			m.notice = "Back up: " + msg.Link.URL
+		}
+		return m, m.loadDeadLinks
+
+	case LinkOpenedMsg:
+		if errors.Is(msg.Err, opener.ErrNoBrowser) {
+			m.notice = "No browser here; copied " + msg.URL
+			return m, tea.SetClipboard(msg.URL)
+		}
+		m.err = msg.Err
+		return m, nil
+
+	case tea.KeyPressMsg:
+		if m.replacing {
+			return m.updateReplace(msg)
+		}
+		m.notice = ""
+		switch msg.String() {
+		case "j", "down":
+			if m.cursor < len(m.links)-1 {
+				m.cursor++
+			}
+		case "k", "up":
+			if m.cursor > 0 {
+				m.cursor--
+			}
+		case m.keys.Prune:
+			if l := m.selected(); l != nil {
+				link := *l
+				link.Status = model.Pruned
+				m.err = db.UpdateLink(m.db, link)
+				return m, m.loadDeadLinks
+			}
+		case m.keys.Replace:
+			if l := m.selected(); l != nil {
+				m.replacing = true
+				m.replaceInput.SetValue(l.URL)
+				m.replaceInput.CursorEnd()
+				return m, m.replaceInput.Focus()
+			}
+		case m.keys.Archive:
+			if l := m.selected(); l != nil {
+				archiveURL := l.LocalArchiveURL()
+				if archiveURL == "" {
+					archiveURL = l.ArchiveURL
+				}
+				if archiveURL == "" {
+					archiveURL = waybackLatest + l.URL
+				}
+				// Looking at a snapshot is not reading the link.
+				return m, openURL(m.opener, 0, archiveURL)
+			}
+		case m.keys.Recheck:
+			if l := m.selected(); l != nil {
+				m.notice = "Checking " + l.URL + "..."
+				return m, m.recheck(*l)
+			}
+		case keyEsc:
+			return m, func() tea.Msg { return DeadLinksExitMsg{} }
+		}
+	}
+	return m, nil
+}
+
+func (m DeadLinksModel) updateReplace(msg tea.KeyPressMsg) (DeadLinksModel, tea.Cmd) {
+	switch msg.String() {
+	case "enter":
+		newURL := strings.TrimSpace(m.replaceInput.Value())
+		m.replacing = false
+		m.replaceInput.Reset()
+		l := m.selected()
+		if l == nil || newURL == "" || newURL == l.URL {
+			return m, nil
+		}
+		if m.err = db.ReplaceLinkURL(m.db, l.ID, newURL); m.err != nil {
+			return m, nil
+		}
+		// The new page needs its own metadata and summary.
+		id := l.ID
+		return m, tea.Batch(m.loadDeadLinks, func() tea.Msg {
+			return TriggerDredgeLinkMsg{LinkID: id}
+		})
+	case keyEsc:
+		m.replacing = false
+		m.replaceInput.Reset()
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.replaceInput, cmd = m.replaceInput.Update(msg)
+	return m, cmd
+}
+
+func (m *DeadLinksModel) setSize(width, height int) {
+	m.width, m.height = width, height
+	m.clampScroll()
+}
+
+// visibleRows is how many links fit on screen.
+func (m DeadLinksModel) visibleRows() int {
+	// Header (1) + blank (1) + detail (3) + status bar (1) + margins (2)
+	return max(m.height-8, 1)
+}
+
+// clampScroll scrolls the list just enough to keep the cursor on screen.
+func (m *DeadLinksModel) clampScroll() {
+	m.scroll = max(min(m.scroll, m.cursor), m.cursor-m.visibleRows()+1, 0)
+}
+
+func (m *DeadLinksModel) selected() *model.Link {
+	if m.cursor < 0 || m.cursor >= len(m.links) {
+		return nil
+	}
+	return &m.links[m.cursor]
+}
+
+// checkSummary says why a link is considered dead.
+func checkSummary(link model.Link) string {
+	switch {
+	case link.CheckStatus != 0:
+		return fmt.Sprintf("%d %s", link.CheckStatus, strings.ToLower(http.StatusText(link.CheckStatus)))
+	case link.CheckError != "":
+		return link.CheckError
+	}
+	return "unreachable"
+}
+
+func (m DeadLinksModel) View() string {
+	header := titleStyle.Render(fmt.Sprintf("Dead Links — %d", len(m.links)))
+
+	var body string
+	if len(m.links) == 0 {
+		body = reviewDimStyle.Render("No dead links. Run `dredger check` to look for some.")
+	} else {
+		end := min(m.scroll+m.visibleRows(), len(m.links))
+
+		titleW := max(m.width-46, 20)
+		var lines []string
+		for i := m.scroll; i < end; i++ {
+			l := m.links[i]
+			title := l.Title
+			if title == "" {
+				title = l.URL
+			}
+			if r := []rune(title); len(r) > titleW {
+				title = string(r[:titleW-3]) + "..."
+			}
+			status := "—"
+			if l.CheckStatus != 0 {
+				status = fmt.Sprint(l.CheckStatus)
+			}
+			line := fmt.Sprintf(" %-4s %2d× %-24s %s", status, l.CheckFailures, extractDomain(l.URL), title)
+			if i == m.cursor {
+				line = reviewSelectedStyle.Render(line)
+			}
+			lines = append(lines, line)
+		}
+		body = strings.Join(lines, "\n")
+
+		if l := m.selected(); l != nil {
+			detail := fmt.Sprintf("%s\n%s, last checked %s", l.URL, checkSummary(*l), l.CheckedAt.Local().Format("2006-01-02 15:04"))
+			if label := archiveLabel(*l); label != "" {
+				detail += "\n" + label
+			}
+			body += "\n\n" + reviewDimStyle.Width(max(m.width-4, 20)).Render(detail)
+		}
+	}
+
+	if m.replacing {
+		body += "\n\n" + "New URL: " + m.replaceInput.View()
+	}
+	if m.notice != "" {
+		body += "\n\n" + reviewDimStyle.Render(m.notice)
+	}
+	if m.err != nil {
+		body += "\n\n" + lipgloss.NewStyle().Foreground(pruneColor).Render("Error: "+m.err.Error())
+	}
+
+	statusBar := statusBarStyle.Width(m.width).Render(
+		statusTextStyle.Render(m.keys.Prune) + " prune  " +
+			statusTextStyle.Render(m.keys.Replace) + " replace URL  " +
+			statusTextStyle.Render(m.keys.Archive) + " archived copy  " +
+			statusTextStyle.Render(m.keys.Recheck) + " recheck  " +
+			statusTextStyle.Render("↑↓") + " navigate  " +
+			statusTextStyle.Render("Esc") + " back",
+	)
+
+	return docStyle.Render(header+"\n\n"+body) + "\n" + statusBar
+}
@@ -1,17 +1,21 @@
 package ui
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"net/url"
-	"os/exec"
 	"strings"
+	"time"
 
 	"charm.land/bubbles/v2/textinput"
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
+	"github.com/alexzajac/the-dredger/internal/config"
 	"github.com/alexzajac/the-dredger/internal/db"
+	"github.com/alexzajac/the-dredger/internal/dredge"
 	"github.com/alexzajac/the-dredger/internal/model"
+	"github.com/alexzajac/the-dredger/internal/opener"
 )
 
 type FocusContext int
@@ -28,6 +32,7 @@ type UndoFrame struct {
 
 type FocusModel struct {
 	db      *sql.DB
+	keys    config.FocusKeys
 	current *model.Link
 	next    *model.Link
 	context FocusContext
@@ -43,35 +48,54 @@ type FocusModel struct {
 	tagging  bool
 	tagInput textinput.Model
 
+	// wayback, when set, takes snapshots of kept links.
+	wayback *dredge.Wayback
+	// archiver, when set, keeps offline copies of kept links.
+	archiver *dredge.Archiver
+	opener   *opener.Opener
+	// notice reports the last failed attempt to open a link.
+	notice string
+
 	width, height int
 
 	kept, pruned int
 
 	startLink *model.Link
+	// unreadOnly skips saved links that have been read.
+	unreadOnly bool
 }
 
-func NewFocusModel(database *sql.DB, width, height int, ctx FocusContext, startLink *model.Link) FocusModel {
+func NewFocusModel(database *sql.DB, keys config.FocusKeys, wayback *dredge.Wayback, archiver *dredge.Archiver, opener *opener.Opener, width, height int, ctx FocusContext, startLink *model.Link, unreadOnly bool) FocusModel {
 	ti := textinput.New()
 	ti.Placeholder = "add tag..."
 	ti.CharLimit = 40
 
 	return FocusModel{
 		db:        database,
+		keys:      keys,
+		wayback:   wayback,
+		archiver:  archiver,
+		opener:    opener,
 		anim:      newAnimState(),
 		tagInput:  ti,
 		width:     width,
 		height:    height,
 		context:   ctx,
 		startLink: startLink,
+
+		unreadOnly: unreadOnly,
 	}
 }
 
 func (f FocusModel) loadNextLink() tea.Msg {
 	var link *model.Link
 	var err error
-	if f.context == focusSaved {
+	switch {
+	case f.context == focusSaved && f.unreadOnly:
+		link, err = db.GetNextUnread(f.db)
+	case f.context == focusSaved:
 		link, err = db.GetNextSaved(f.db)
-	} else {
+	default:
 		link, err = db.GetNextUnprocessed(f.db)
 	}
 	return NextLinkLoadedMsg{Link: link, Err: err}
@@ -83,14 +107,42 @@ func (f FocusModel) prefetchNextLink() tea.Msg {
 		excludeID = f.current.ID
 	}
 	var link *model.Link
-	if f.context == focusSaved {
+	switch {
+	case f.context == focusSaved && f.unreadOnly:
+		link, _ = db.GetNextUnreadExcluding(f.db, excludeID)
+	case f.context == focusSaved:
 		link, _ = db.GetNextSavedExcluding(f.db, excludeID)
-	} else {
+	default:
 		link, _ = db.GetNextUnprocessedExcluding(f.db, excludeID)
 	}
 	return NextLinkPrefetchedMsg{Link: link}
 }
 
+// requestSnapshot asks the Wayback Machine to archive a kept link, when
+// that is configured. Failures are ignored; the page can be archived later.
+func (f FocusModel) requestSnapshot(rawURL string) tea.Cmd {
+	if f.wayback == nil || !f.wayback.SaveOnKeep {
+		return nil
+	}
+	return func() tea.Msg {
+		_ = f.wayback.Save(context.Background(), rawURL)
+		return nil
+	}
+}
+
+// archiveCopy saves an offline copy of a kept link in the background, when
+// archiving is enabled. As with snapshots, failures are left for the
+// archive command to retry.
+func (f FocusModel) archiveCopy(link model.Link) tea.Cmd {
+	if f.archiver == nil {
+		return nil
+	}
+	return func() tea.Msg {
+		_, _ = f.archiver.Archive(context.Background(), link)
+		return nil
+	}
+}
+
 func (f FocusModel) Init() tea.Cmd {
 	if f.startLink != nil {
 		link := f.startLink
@@ -138,6 +190,10 @@ func (f FocusModel) Update(msg tea.Msg) (FocusModel, tea.Cmd) {
 		if f.current != nil && f.current.ID == msg.LinkID {
 			f.current.DredgeState = msg.State
 			f.current.DredgeError = msg.Error
+			f.current.DredgeErrorClass = msg.ErrorClass
+			if msg.ArchiveURL != "" {
+				f.current.ArchiveURL = msg.ArchiveURL
+			}
 			if msg.Title != "" {
 				f.current.Title = msg.Title
 			}
@@ -150,6 +206,21 @@ func (f FocusModel) Update(msg tea.Msg) (FocusModel, tea.Cmd) {
 			if len(msg.Tags) > 0 {
 				f.current.Tags = msg.Tags
 			}
+			msg.Meta.Apply(f.current)
+		}
+		return f, nil
+
+	case LinkOpenedMsg:
+		var cmd tea.Cmd
+		f.notice, cmd = openedNotice(msg)
+		if msg.Err == nil && f.current != nil && f.current.ID == msg.LinkID {
+			_ = markRead(f.db, f.current)
+		}
+		return f, cmd
+
+	case ReaderExitMsg:
+		if f.current != nil && f.current.ID == msg.Link.ID {
+			copyReading(f.current, msg.Link)
 		}
 		return f, nil
 
@@ -189,9 +260,10 @@ func (f FocusModel) updateNormal(msg tea.KeyPressMsg) (FocusModel, tea.Cmd) {
 	if f.anim.active {
 		return f, nil
 	}
+	f.notice = ""
 
 	switch msg.String() {
-	case "down", "n":
+	case "down", f.keys.Next:
 		if f.current == nil || f.next == nil {
 			return f, nil
 		}
@@ -201,7 +273,7 @@ func (f FocusModel) updateNormal(msg tea.KeyPressMsg) (FocusModel, tea.Cmd) {
 		f.descScroll = 0
 		return f, f.prefetchNextLink
 
-	case "up", "p":
+	case "up", f.keys.Prev:
 		if len(f.browseHistory) == 0 {
 			return f, nil
 		}
@@ -214,7 +286,7 @@ func (f FocusModel) updateNormal(msg tea.KeyPressMsg) (FocusModel, tea.Cmd) {
 		f.descScroll = 0
 		return f, nil
 
-	case "h":
+	case f.keys.Prune:
 		if f.current == nil {
 			return f, nil
 		}
@@ -241,7 +313,7 @@ func (f FocusModel) updateNormal(msg tea.KeyPressMsg) (FocusModel, tea.Cmd) {
 		f.anim.start(-80, pruneColor)
 		return f, animTick()
 
-	case "l":
+	case f.keys.Keep:
 		if f.current == nil || f.context == focusSaved {
 			return f, nil
 		}
@@ -254,9 +326,9 @@ func (f FocusModel) updateNormal(msg tea.KeyPressMsg) (FocusModel, tea.Cmd) {
 		_ = db.UpdateLink(f.db, *f.current)
 		f.kept++
 		f.anim.start(80, keepColor)
-		return f, animTick()
+		return f, tea.Batch(animTick(), f.requestSnapshot(f.current.URL), f.archiveCopy(*f.current))
 
-	case "t":
+	case f.keys.Tag:
 		if f.current == nil {
 			return f, nil
 		}
@@ -264,37 +336,50 @@ func (f FocusModel) updateNormal(msg tea.KeyPressMsg) (FocusModel, tea.Cmd) {
 		cmd := f.tagInput.Focus()
 		return f, cmd
 
-	case "r":
+	case f.keys.Read:
 		if f.current == nil || f.context != focusSaved {
 			return f, nil
 		}
-		// Open URL in default browser
-		_ = exec.Command("open", f.current.URL).Start()
+		// Open URL in the browser, or its archived copy if it has gone
+		return f, openURL(f.opener, f.current.ID, f.current.OpenURL())
+
+	case f.keys.ToggleRead:
+		if f.current == nil {
+			return f, nil
+		}
+		_ = toggleRead(f.db, f.current)
 		return f, nil
 
-	case "d":
+	case f.keys.Reader:
+		if f.current == nil {
+			return f, nil
+		}
+		link := *f.current
+		return f, func() tea.Msg { return OpenReaderMsg{Link: link} }
+
+	case f.keys.Dredge:
 		if f.current == nil || f.context != focusSaved {
 			return f, nil
 		}
 		return f, func() tea.Msg {
-			return TriggerDredgeLinkMsg{LinkID: f.current.ID, URL: f.current.URL}
+			return TriggerDredgeLinkMsg{LinkID: f.current.ID}
 		}
 
-	case "j":
+	case f.keys.ScrollDown:
 		f.descScroll++
 		if f.descScroll > f.descMaxScroll {
 			f.descScroll = f.descMaxScroll
 		}
 		return f, nil
 
-	case "k":
+	case f.keys.ScrollUp:
 		f.descScroll--
 		if f.descScroll < 0 {
 			f.descScroll = 0
 		}
 		return f, nil
 
-	case "z":
+	case f.keys.Undo:
 		if len(f.undoStack) == 0 {
 			return f, nil
 		}
@@ -339,21 +424,24 @@ func (f FocusModel) View() string {
 	var help string
 	if f.context == focusSaved {
 		help = lipgloss.NewStyle().Foreground(lipgloss.Color("#9B9B9B")).Render(
-			statusTextStyle.Render("h") + " pending  " +
-				statusTextStyle.Render("t") + " tag  " +
-				statusTextStyle.Render("d") + " dredge  " +
-				statusTextStyle.Render("r") + " read  " +
+			statusTextStyle.Render(f.keys.Prune) + " pending  " +
+				statusTextStyle.Render(f.keys.Tag) + " tag  " +
+				statusTextStyle.Render(f.keys.Dredge) + " dredge  " +
+				statusTextStyle.Render(f.keys.Read) + " read  " +
+				statusTextStyle.Render(f.keys.Reader) + " reader  " +
+				statusTextStyle.Render(f.keys.ToggleRead) + " read/unread  " +
 				statusTextStyle.Render("↑↓") + " navigate  " +
-				statusTextStyle.Render("z") + " undo  " +
+				statusTextStyle.Render(f.keys.Undo) + " undo  " +
 				statusTextStyle.Render("Esc") + " back",
 		)
 	} else {
 		help = lipgloss.NewStyle().Foreground(lipgloss.Color("#9B9B9B")).Render(
-			statusTextStyle.Render("h") + " prune  " +
-				statusTextStyle.Render("l") + " keep  " +
-				statusTextStyle.Render("t") + " tag  " +
+			statusTextStyle.Render(f.keys.Prune) + " prune  " +
+				statusTextStyle.Render(f.keys.Keep) + " keep  " +
+				statusTextStyle.Render(f.keys.Tag) + " tag  " +
+				statusTextStyle.Render(f.keys.Reader) + " reader  " +
 				statusTextStyle.Render("↑↓") + " navigate  " +
-				statusTextStyle.Render("z") + " undo  " +
+				statusTextStyle.Render(f.keys.Undo) + " undo  " +
 				statusTextStyle.Render("Esc") + " back",
 		)
 	}
@@ -378,7 +466,7 @@ func (f FocusModel) View() string {
 			}
 		}
 		undo = "\n" + undoToastStyle.Render(
-			fmt.Sprintf("↩ Undo %s \"%s\" (z) [%d in stack]", last.Action, title, len(f.undoStack)),
+			fmt.Sprintf("↩ Undo %s \"%s\" (%s) [%d in stack]", last.Action, title, f.keys.Undo, len(f.undoStack)),
 		)
 	}
 
@@ -388,7 +476,12 @@ func (f FocusModel) View() string {
 		tagLine = "\n" + f.tagInput.View()
 	}
 
-	content := card + tagLine + "\n\n" + help + "\n\n" + stats + undo
+	var notice string
+	if f.notice != "" {
+		notice = "\n" + f.notice
+	}
+
+	content := card + tagLine + notice + "\n\n" + help + "\n\n" + stats + undo
 
 	return lipgloss.Place(f.width, f.height, lipgloss.Center, lipgloss.Center, content)
 }
@@ -403,8 +496,11 @@ func (f *FocusModel) renderCard() string {
 	innerWidth := cardWidth - 6 // account for border + padding
 
 	// Domain header
-	domain := extractDomain(link.URL)
+	domain := extractDomain(link.FinalURL())
 	header := domainHeaderStyle.Render(domain)
+	if badge := deadBadge(*link); badge != "" {
+		header += " " + badge
+	}
 
 	// Title
 	title := cardTitleStyle.Width(innerWidth).Render(link.Title)
@@ -414,10 +510,31 @@ func (f *FocusModel) renderCard() string {
 
 	// URL (truncated)
 	displayURL := link.URL
-	if len(displayURL) > innerWidth {
-		displayURL = displayURL[:innerWidth-3] + "..."
+	if link.ResolvedURL != "" {
+		displayURL += " → " + link.ResolvedURL
+	}
+	if r := []rune(displayURL); len(r) > innerWidth {
+		displayURL = string(r[:innerWidth-3]) + "..."
 	}
 	urlLine := cardURLStyle.Render(displayURL)
+	if label := archiveLabel(*link); label != "" {
+		urlLine += "\n" + cardArchiveStyle.Render(label)
+	}
+	if label := readLabel(*link); label != "" {
+		urlLine += "\n" + cardReadStyle.Render(label)
+	}
+	if label := lengthLabel(*link); label != "" {
+		urlLine += "\n" + cardDetailsStyle.Render(label)
+	}
+
+	// Author, site and dates from the page's metadata
+	var bylineBlock string
+	if byline := linkByline(*link); byline != "" {
+		bylineBlock = cardBylineStyle.Width(innerWidth).Render(byline)
+	}
+	if details := linkDetails(*link); details != "" {
+		bylineBlock = joinNonEmpty(bylineBlock, cardDetailsStyle.Width(innerWidth).Render(details))
+	}
 
 	// Description with scrolling
 	desc := link.Description
@@ -447,6 +564,8 @@ func (f *FocusModel) renderCard() string {
 	if link.DredgeState != model.DredgeNone {
 		badgeStyle := lipgloss.NewStyle().Padding(0, 1)
 		switch link.DredgeState {
+		case model.DredgeQueued:
+			badgeStyle = badgeStyle.Foreground(lipgloss.Color("#888888"))
 		case model.DredgeCrawling, model.DredgeCrunching:
 			badgeStyle = badgeStyle.Foreground(lipgloss.Color("#FFB347"))
 		case model.DredgeComplete:
@@ -455,9 +574,21 @@ func (f *FocusModel) renderCard() string {
 			badgeStyle = badgeStyle.Foreground(pruneColor)
 		}
 		label := link.DredgeState.String()
-		if link.DredgeState == model.DredgeCapsized && link.DredgeError != "" {
-			errLines := wrapText(link.DredgeError, innerWidth-2)
-			label = "Failed: " + strings.Join(errLines, "\n")
+		if link.DredgeError != "" {
+			prefix := "Failed"
+			switch {
+			case link.DredgeState == model.DredgeQueued:
+				prefix = "Retrying"
+			case link.DredgeState != model.DredgeCapsized:
+				prefix = ""
+			}
+			if prefix != "" {
+				if link.DredgeErrorClass != model.ErrorNone {
+					prefix += " (" + link.DredgeErrorClass.String() + ")"
+				}
+				errLines := wrapText(link.DredgeError, innerWidth-2)
+				label = prefix + ": " + strings.Join(errLines, "\n")
+			}
 		}
 		dredgeBadge = badgeStyle.Render(label)
 	}
@@ -492,7 +623,11 @@ func (f *FocusModel) renderCard() string {
 		Render(strings.ToUpper(link.Status.String()))
 
 	// Assemble card content
-	parts := []string{header, "", title, urlLine, "", descBlock + scrollHint}
+	parts := []string{header, "", title, urlLine}
+	if bylineBlock != "" {
+		parts = append(parts, bylineBlock)
+	}
+	parts = append(parts, "", descBlock+scrollHint)
 	if summaryBlock != "" {
 		parts = append(parts, "", summaryBlock)
 	}
@@ -529,6 +664,172 @@ func (f FocusModel) viewCompletion() string {
 	return lipgloss.Place(f.width, f.height, lipgloss.Center, lipgloss.Center, msg)
 }
 
+// linkByline summarises who published a link and when, e.g.
+// "Grace Hopper · The Blog · 1 May 2024 (updated 2 May 2024) · en".
+func linkByline(link model.Link) string {
+	var parts []string
+	if link.Author != "" {
+		parts = append(parts, link.Author)
+	}
+	if link.SiteName != "" {
+		parts = append(parts, link.SiteName)
+	}
+	if !link.PublishedAt.IsZero() {
+		date := link.PublishedAt.Format("2 Jan 2006")
+		if !link.ModifiedAt.IsZero() && link.ModifiedAt.Format("2 Jan 2006") != date {
+			date += " (updated " + link.ModifiedAt.Format("2 Jan 2006") + ")"
+		}
+		parts = append(parts, date)
+	}
+	if link.PageType != "" && link.PageType != "website" && link.PageType != "article" {
+		parts = append(parts, link.PageType)
+	}
+	if link.Language != "" {
+		parts = append(parts, strings.ToLower(link.Language))
+	}
+	return strings.Join(parts, " · ")
+}
+
+// linkDetails summarises kind-specific facts about a link, e.g.
+// "★ 1.2k · Go · MIT · pushed 6 May 2024 · archived" for a repository,
+// "2017 · NeurIPS · cs.CL, cs.LG" for a paper, whose authors are in the
+// byline, or "▶ 12:30" for a video.
+func linkDetails(link model.Link) string {
+	if link.MediaDuration > 0 {
+		return "▶ " + clockDuration(link.MediaDuration)
+	}
+	if paper := link.Details.Paper; paper != nil {
+		var parts []string
+		if paper.Year != 0 {
+			parts = append(parts, fmt.Sprint(paper.Year))
+		}
+		if paper.Venue != "" {
+			parts = append(parts, paper.Venue)
+		}
+		if len(paper.Categories) > 0 {
+			parts = append(parts, strings.Join(paper.Categories, ", "))
+		}
+		return strings.Join(parts, " · ")
+	}
+	repo := link.Details.Repo
+	if repo == nil {
+		return ""
+	}
+	parts := []string{"★ " + compactCount(repo.Stars)}
+	if repo.Language != "" {
+		parts = append(parts, repo.Language)
+	}
+	if repo.License != "" {
+		parts = append(parts, repo.License)
+	}
+	if !repo.PushedAt.IsZero() {
+		parts = append(parts, "pushed "+repo.PushedAt.Format("2 Jan 2006"))
+	}
+	if repo.Archived {
+		parts = append(parts, "archived")
+	}
+	return strings.Join(parts, " · ")
+}
+
+// deadBadge flags a link the checker found dead, with the status it
+// last answered if it answered at all, e.g. "DEAD 404".
+func deadBadge(link model.Link) string {
+	if !link.Dead {
+		return ""
+	}
+	label := "DEAD"
+	if link.CheckStatus != 0 {
+		label += fmt.Sprintf(" %d", link.CheckStatus)
+	}
+	return deadBadgeStyle.Render(label)
+}
+
+// archiveLabel describes a link's archived copies by the dates they were
+// taken, e.g. "⟲ archived 4 Mar 2019 · offline 2 Jun 2026", or returns ""
+// when it has none.
+func archiveLabel(link model.Link) string {
+	var parts []string
+	if link.ArchiveURL != "" {
+		// Snapshot URLs look like https://web.archive.org/web/20190304050607/<url>.
+		_, rest, _ := strings.Cut(link.ArchiveURL, "/web/")
+		ts, _, _ := strings.Cut(rest, "/")
+		if t, err := time.Parse("20060102150405", ts); err == nil {
+			parts = append(parts, "archived "+t.Format("2 Jan 2006"))
+		} else {
+			parts = append(parts, "archived copy")
+		}
+	}
+	if link.ArchivePath != "" {
+		parts = append(parts, "offline "+link.ArchivedAt.Local().Format("2 Jan 2006"))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return "⟲ " + strings.Join(parts, " · ")
+}
+
+// readLabel describes how much of a link has been read, e.g. "✓ read 4 Mar
+// 2026 (3×)" or "◔ 40% read", or returns "" for a link never opened.
+func readLabel(link model.Link) string {
+	switch {
+	case !link.Unread():
+		label := "✓ read " + link.ReadAt.Local().Format("2 Jan 2006")
+		if link.ReadCount > 1 {
+			label += fmt.Sprintf(" (%d×)", link.ReadCount)
+		}
+		return label
+	case link.Progress > 0:
+		return fmt.Sprintf("◔ %.f%% read", link.Progress*100)
+	}
+	return ""
+}
+
+// lengthLabel gives the length of a link's text, e.g. "◷ 6 min read ·
+// 1.4k words", or returns "" when the text has not been fetched.
+func lengthLabel(link model.Link) string {
+	if link.WordCount == 0 {
+		return ""
+	}
+	return fmt.Sprintf("◷ %d min read · %s words", readingMinutes(link.ReadingTime), compactCount(link.WordCount))
+}
+
+// readingMinutes rounds a reading time up to whole minutes, so that
+// nothing takes less than one.
+func readingMinutes(d time.Duration) int {
+	return max(int((d+time.Minute-1)/time.Minute), 1)
+}
+
+// clockDuration formats d like 4:05 or 1:02:03.
+func clockDuration(d time.Duration) string {
+	secs := int(d.Round(time.Second) / time.Second)
+	if secs >= 3600 {
+		return fmt.Sprintf("%d:%02d:%02d", secs/3600, secs/60%60, secs%60)
+	}
+	return fmt.Sprintf("%d:%02d", secs/60, secs%60)
+}
+
+// compactCount formats n like 950, 1.2k or 3.4M.
+func compactCount(n int) string {
+	switch {
+	case n >= 1_000_000:
+		return strings.TrimSuffix(fmt.Sprintf("%.1f", float64(n)/1e6), ".0") + "M"
+	case n >= 1000:
+		return strings.TrimSuffix(fmt.Sprintf("%.1f", float64(n)/1e3), ".0") + "k"
+	}
+	return fmt.Sprint(n)
+}
+
+// joinNonEmpty joins the non-empty blocks with newlines.
+func joinNonEmpty(blocks ...string) string {
+	var out []string
+	for _, b := range blocks {
+		if b != "" {
+			out = append(out, b)
+		}
+	}
+	return strings.Join(out, "\n")
+}
+
 func extractDomain(rawURL string) string {
 	u, err := url.Parse(rawURL)
 	if err != nil || u.Host == "" {
@@ -6,13 +6,15 @@ import (
 	"hash/fnv"
 	"image/color"
 	"net/url"
-	"os/exec"
+	"slices"
 	"strings"
 
 	tea "charm.land/bubbletea/v2"
 	"charm.land/lipgloss/v2"
+	"github.com/alexzajac/the-dredger/internal/config"
 	"github.com/alexzajac/the-dredger/internal/db"
 	"github.com/alexzajac/the-dredger/internal/model"
+	"github.com/alexzajac/the-dredger/internal/opener"
 	"github.com/atotto/clipboard"
 )
 
@@ -68,6 +70,8 @@ var (
 
 type GridModel struct {
 	db       *sql.DB
+	keys     config.GridKeys
+	opener   *opener.Opener
 	links    []model.Link
 	filtered []model.Link
 
@@ -80,6 +84,8 @@ type GridModel struct {
 
 	searching   bool
 	searchQuery string
+	// notice reports the last failed attempt to open a link.
+	notice string
 
 	serendipityLinks  []model.Link
 	showSerendipity   bool
@@ -89,12 +95,19 @@ type GridModel struct {
 	height int
 }
 
-func NewGridModel(database *sql.DB, width, height int) GridModel {
+// NewGridModel returns the grid of saved links, starting filtered to the
+// unread ones when unreadOnly is set.
+func NewGridModel(database *sql.DB, keys config.GridKeys, opener *opener.Opener, width, height int, unreadOnly bool) GridModel {
 	g := GridModel{
 		db:     database,
+		keys:   keys,
+		opener: opener,
 		width:  width,
 		height: height,
 	}
+	if unreadOnly {
+		g.searchQuery = "is:unread"
+	}
 	g.recalcLayout()
 	return g
 }
@@ -147,7 +160,7 @@ func (g *GridModel) recalcLayout() {
 }
 
 func (g *GridModel) activeLinks() []model.Link {
-	if g.searching && g.searchQuery != "" {
+	if g.searchQuery != "" {
 		return g.filtered
 	}
 	return g.links
@@ -205,20 +218,40 @@ func (g *GridModel) selectedLink() *model.Link {
 	return &links[idx]
 }
 
+// updateReading copies link's reading state to every copy of it in the
+// grid.
+func (g *GridModel) updateReading(link model.Link) {
+	for _, list := range [][]model.Link{g.links, g.filtered} {
+		for i := range list {
+			if list[i].ID == link.ID {
+				copyReading(&list[i], link)
+			}
+		}
+	}
+}
+
 func (g *GridModel) applySearch() {
+	g.filter()
+	if g.searchQuery != "" {
+		g.cursorX, g.cursorY, g.scrollY = 0, 0, 0
+	}
+}
+
+// filter recomputes the links matching the search query.
+func (g *GridModel) filter() {
+	g.filtered = nil
 	if g.searchQuery == "" {
-		g.filtered = nil
 		return
 	}
-	q := strings.ToLower(g.searchQuery)
-	g.filtered = nil
+	q := parseQuery(g.searchQuery)
 	for _, l := range g.links {
-		haystack := strings.ToLower(l.Title + " " + l.URL + " " + strings.Join(l.Tags, " "))
-		if strings.Contains(haystack, q) {
+		if q.matches(l) {
 			g.filtered = append(g.filtered, l)
 		}
 	}
-	g.cursorX, g.cursorY, g.scrollY = 0, 0, 0
+	if q.order != nil {
+		slices.SortStableFunc(g.filtered, q.order)
+	}
 }
 
 func (g GridModel) Update(msg tea.Msg) (GridModel, tea.Cmd) {
@@ -228,6 +261,7 @@ func (g GridModel) Update(msg tea.Msg) (GridModel, tea.Cmd) {
 			return g, nil
 		}
 		g.links = msg.Links
+		g.filter()
 		g.clampCursor()
 		return g, nil
 
@@ -238,6 +272,23 @@ func (g GridModel) Update(msg tea.Msg) (GridModel, tea.Cmd) {
 			g.serendipityScroll = 0
 		}
 		return g, nil
+
+	case ReaderExitMsg:
+		g.updateReading(msg.Link)
+		return g, nil
+
+	case LinkOpenedMsg:
+		var cmd tea.Cmd
+		g.notice, cmd = openedNotice(msg)
+		if msg.Err == nil {
+			for _, l := range g.links {
+				if l.ID == msg.LinkID && markRead(g.db, &l) == nil {
+					g.updateReading(l)
+					break
+				}
+			}
+		}
+		return g, cmd
 	}
 
 	if g.showSerendipity {
@@ -285,6 +336,10 @@ func (g GridModel) updateSearch(msg tea.Msg) (GridModel, tea.Cmd) {
 				g.applySearch()
 			}
 			return g, nil
+		case "space":
+			g.searchQuery += " "
+			g.applySearch()
+			return g, nil
 		default:
 			r := msg.String()
 			if len(r) == 1 {
@@ -299,8 +354,9 @@ func (g GridModel) updateSearch(msg tea.Msg) (GridModel, tea.Cmd) {
 
 func (g GridModel) updateNormal(msg tea.Msg) (GridModel, tea.Cmd) {
 	if msg, ok := msg.(tea.KeyPressMsg); ok {
+		g.notice = ""
 		switch msg.String() {
-		case "h", "left":
+		case g.keys.Left, "left":
 			g.cursorX--
 			if g.cursorX < 0 {
 				if g.cursorY > 0 {
@@ -312,7 +368,7 @@ func (g GridModel) updateNormal(msg tea.Msg) (GridModel, tea.Cmd) {
 				}
 			}
 			g.ensureVisible()
-		case "l", "right":
+		case g.keys.Right, "right":
 			g.cursorX++
 			links := g.activeLinks()
 			idx := g.cursorY*g.cols + g.cursorX
@@ -325,27 +381,38 @@ func (g GridModel) updateNormal(msg tea.Msg) (GridModel, tea.Cmd) {
 				}
 			}
 			g.ensureVisible()
-		case "k", "up":
+		case g.keys.Up, "up":
 			g.cursorY--
 			g.clampCursor()
 			g.ensureVisible()
-		case "j", "down":
+		case g.keys.Down, "down":
 			g.cursorY++
 			g.clampCursor()
 			g.ensureVisible()
-		case "/":
+		case g.keys.Search:
 			g.searching = true
 			g.searchQuery = ""
 			return g, nil
-		case keyEnter:
+		case g.keys.Open:
+			if link := g.selectedLink(); link != nil {
+				return g, openURL(g.opener, link.ID, link.OpenURL())
+			}
+		case g.keys.ToggleRead:
+			if link := g.selectedLink(); link != nil {
+				if toggleRead(g.db, link) == nil {
+					g.updateReading(*link)
+				}
+			}
+		case g.keys.Reader:
 			if link := g.selectedLink(); link != nil {
-				_ = exec.Command("open", link.URL).Start()
+				l := *link
+				return g, func() tea.Msg { return OpenReaderMsg{Link: l} }
 			}
-		case "y":
+		case g.keys.Copy:
 			if link := g.selectedLink(); link != nil {
 				_ = clipboard.WriteAll(link.URL)
 			}
-		case "r":
+		case g.keys.Serendipity:
 			return g, g.loadSerendipity
 		case keyEsc:
 			return g, func() tea.Msg { return GridExitMsg{} }
@@ -410,11 +477,13 @@ func (g GridModel) View() string {
 
 	// Status bar
 	statusBar := statusBarStyle.Width(g.width).Render(
-		statusTextStyle.Render("h/j/k/l") + " navigate  " +
-			statusTextStyle.Render("enter") + " open  " +
-			statusTextStyle.Render("y") + " copy  " +
-			statusTextStyle.Render("/") + " search  " +
-			statusTextStyle.Render("r") + " serendipity  " +
+		statusTextStyle.Render(g.keys.Left+"/"+g.keys.Down+"/"+g.keys.Up+"/"+g.keys.Right) + " navigate  " +
+			statusTextStyle.Render(g.keys.Open) + " open  " +
+			statusTextStyle.Render(g.keys.Reader) + " reader  " +
+			statusTextStyle.Render(g.keys.ToggleRead) + " read/unread  " +
+			statusTextStyle.Render(g.keys.Copy) + " copy  " +
+			statusTextStyle.Render(g.keys.Search) + " search  " +
+			statusTextStyle.Render(g.keys.Serendipity) + " serendipity  " +
 			statusTextStyle.Render("Esc") + " back",
 	)
 
@@ -423,6 +492,9 @@ func (g GridModel) View() string {
 	if searchBar != "" {
 		final += "\n" + searchBar
 	}
+	if g.notice != "" {
+		final += "\n" + g.notice
+	}
 	final += "\n" + statusBar
 
 	return final
@@ -451,9 +523,14 @@ func (g GridModel) renderCell(link model.Link, selected bool) string {
 			titleLines[1] = last[:len(last)-3] + "..."
 		}
 	}
+	// Read links fade back so that unread ones stand out.
+	titleColor := lipgloss.Color("#FFFDF5")
+	if !link.Unread() {
+		titleColor = lipgloss.Color("#9B9B9B")
+	}
 	titleStr := lipgloss.NewStyle().
 		Bold(true).
-		Foreground(lipgloss.Color("#FFFDF5")).
+		Foreground(titleColor).
 		Width(innerW).
 		Render(strings.Join(titleLines, "\n"))
 
@@ -461,6 +538,9 @@ func (g GridModel) renderCell(link model.Link, selected bool) string {
 	var tagStr string
 	if len(link.Tags) > 0 {
 		limit := 2
+		if link.Dead || link.ReadingTime > 0 {
+			limit = 1 // leave room for the badge or reading time
+		}
 		if len(link.Tags) < limit {
 			limit = len(link.Tags)
 		}
@@ -477,6 +557,13 @@ func (g GridModel) renderCell(link model.Link, selected bool) string {
 		tagStr = strings.Join(pills, " ")
 	}
 
+	if link.Dead {
+		tagStr = strings.TrimSpace(deadBadgeStyle.Render("DEAD") + " " + tagStr)
+	}
+	if link.ReadingTime > 0 {
+		tagStr = strings.TrimSpace(cardDetailsStyle.Render(fmt.Sprintf("%d min", readingMinutes(link.ReadingTime))) + " " + tagStr)
+	}
+
 	cellContent := block + "\n" + titleStr + "\n" + tagStr
 
 	style := gridNormalBorder
@@ -498,8 +585,11 @@ func (g GridModel) renderQuickLook() string {
 	}
 
 	innerW := quickLookW - 6
-	domain := extractDomain(link.URL)
+	domain := extractDomain(link.FinalURL())
 	header := domainHeaderStyle.Render(domain)
+	if badge := deadBadge(*link); badge != "" {
+		header += " " + badge
+	}
 
 	title := cardTitleStyle.Width(innerW).Render(link.Title)
 	if link.Title == "" {
@@ -507,10 +597,30 @@ func (g GridModel) renderQuickLook() string {
 	}
 
 	displayURL := link.URL
-	if len(displayURL) > innerW {
-		displayURL = displayURL[:innerW-3] + "..."
+	if link.ResolvedURL != "" {
+		displayURL += " → " + link.ResolvedURL
+	}
+	if r := []rune(displayURL); len(r) > innerW {
+		displayURL = string(r[:innerW-3]) + "..."
 	}
 	urlLine := cardURLStyle.Render(displayURL)
+	if label := archiveLabel(*link); label != "" {
+		urlLine += "\n" + cardArchiveStyle.Render(label)
+	}
+	if label := readLabel(*link); label != "" {
+		urlLine += "\n" + cardReadStyle.Render(label)
+	}
+	if label := lengthLabel(*link); label != "" {
+		urlLine += "\n" + cardDetailsStyle.Render(label)
+	}
+
+	var bylineBlock string
+	if byline := linkByline(*link); byline != "" {
+		bylineBlock = cardBylineStyle.Width(innerW).Render(strings.Join(wrapText(byline, innerW), "\n"))
+	}
+	if details := linkDetails(*link); details != "" {
+		bylineBlock = joinNonEmpty(bylineBlock, cardDetailsStyle.Width(innerW).Render(strings.Join(wrapText(details, innerW), "\n")))
+	}
 
 	desc := link.Description
 	if desc == "" {
@@ -548,7 +658,11 @@ func (g GridModel) renderQuickLook() string {
 
 	dateLine := fmt.Sprintf("Added: %s", link.DateAdded.Format("2006-01-02"))
 
-	parts := []string{header, "", title, urlLine, "", descBlock}
+	parts := []string{header, "", title, urlLine}
+	if bylineBlock != "" {
+		parts = append(parts, bylineBlock)
+	}
+	parts = append(parts, "", descBlock)
 	if summaryBlock != "" {
 		parts = append(parts, "", summaryBlock)
 	}
@@ -564,7 +678,7 @@ func (g GridModel) viewSerendipity() string {
 	var cards []string
 	for i, link := range g.serendipityLinks {
 		innerW := 50
-		domain := extractDomain(link.URL)
+		domain := extractDomain(link.FinalURL())
 		header := domainHeaderStyle.Render(domain)
 
 		title := cardTitleStyle.Width(innerW).Render(link.Title)
@@ -10,10 +10,14 @@ type linkItem struct {
 }
 
 func (i linkItem) Title() string {
-	if i.link.Title == "" {
-		return i.link.URL
+	title := i.link.Title
+	if title == "" {
+		title = i.link.URL
+	}
+	if !i.link.Unread() {
+		title = "✓ " + title
 	}
-	return i.link.Title
+	return title
 }
 
 func (i linkItem) Description() string {
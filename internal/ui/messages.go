@@ -41,7 +41,6 @@ type LinkActionedMsg struct {
 // TriggerDredgeLinkMsg requests a manual dredge of a specific link.
 type TriggerDredgeLinkMsg struct {
 	LinkID int64
-	URL    string
 }
 
 type GridLinksLoadedMsg struct {
@@ -64,5 +63,54 @@ type DredgeLinkResultMsg struct {
 	Description string
 	Summary     string
 	Tags        []string
+	Meta        dredge.PageMeta
 	Error       string
+	ErrorClass  model.ErrorClass
+	// ArchiveURL is the archived copy found when the page has gone.
+	ArchiveURL string
+}
+
+type SuggestedTagsLoadedMsg struct {
+	Suggestions []model.SuggestedTag
+	Err         error
+}
+
+type TagReviewExitMsg struct{}
+
+type DeadLinksLoadedMsg struct {
+	Links []model.Link
+	Err   error
+}
+
+// DeadLinkCheckedMsg returns the result of rechecking one dead link.
+type DeadLinkCheckedMsg struct {
+	Link model.Link
+	Err  error
+}
+
+type DeadLinksExitMsg struct{}
+
+// OpenReaderMsg asks for a link to be shown in the reader view.
+type OpenReaderMsg struct {
+	Link model.Link
+}
+
+type ReaderTextLoadedMsg struct {
+	LinkID int64
+	Text   string
+	Err    error
+}
+
+// ReaderExitMsg returns from the reader view with the link's reading
+// state updated.
+type ReaderExitMsg struct {
+	Link model.Link
+}
+
+// LinkOpenedMsg reports how handing a link to the browser went. LinkID is
+// zero for URLs that are not the link itself, such as archived copies.
+type LinkOpenedMsg struct {
+	LinkID int64
+	URL    string
+	Err    error
 }
@@ -0,0 +1,43 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/alexzajac/the-dredger/internal/opener"
+)
+
+// openURL opens rawURL with o, in the background or, for terminal
+// browsers, by handing over the terminal until the browser exits.
+func openURL(o *opener.Opener, linkID int64, rawURL string) tea.Cmd {
+	if o.InTerminal() {
+		cmd, err := o.Command(rawURL)
+		if err != nil {
+			return func() tea.Msg { return LinkOpenedMsg{LinkID: linkID, URL: rawURL, Err: err} }
+		}
+		return tea.ExecProcess(cmd, func(err error) tea.Msg {
+			if err != nil {
+				err = fmt.Errorf("%s: %w", cmd.Args[0], err)
+			}
+			return LinkOpenedMsg{LinkID: linkID, URL: rawURL, Err: err}
+		})
+	}
+	return func() tea.Msg {
+		return LinkOpenedMsg{LinkID: linkID, URL: rawURL, Err: o.Open(rawURL)}
+	}
+}
+
+// openedNotice describes a failed open for the status line. Without a
+// browser the URL is shown and copied to the clipboard instead, through
+// the terminal so that it works over SSH.
+func openedNotice(msg LinkOpenedMsg) (string, tea.Cmd) {
+	switch {
+	case msg.Err == nil:
+		return "", nil
+	case errors.Is(msg.Err, opener.ErrNoBrowser):
+		return reviewDimStyle.Render("No browser here; copied " + msg.URL), tea.SetClipboard(msg.URL)
+	}
+	return lipgloss.NewStyle().Foreground(pruneColor).Render("Could not open link: " + msg.Err.Error()), nil
+}
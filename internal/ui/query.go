@@ -0,0 +1,149 @@
+package ui
+
+import (
+	"cmp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/alexzajac/the-dredger/internal/model"
+)
+
+// linkQuery is a parsed search: words to find in a link's title, URL and
+// tags, plus field filters such as "dur<15m" and an order such as
+// "sort:short".
+type linkQuery struct {
+	words   []string
+	filters []func(model.Link) bool
+	// order compares links for sorting the results, or is nil to keep
+	// them as they are.
+	order func(a, b model.Link) int
+}
+
+// queryOps are the comparison operators of field filters, longest first.
+var queryOps = []string{"<=", ">=", "<", ">", "="}
+
+// parseQuery splits q into words, field filters such as "dur<15m" and
+// "is:unread", and an order such as "sort:long". Terms that look like a
+// filter but do not parse are searched for as words.
+func parseQuery(q string) linkQuery {
+	var lq linkQuery
+	for _, term := range strings.Fields(strings.ToLower(q)) {
+		if f, ok := parseFilter(term); ok {
+			lq.filters = append(lq.filters, f)
+			continue
+		}
+		if order, ok := parseOrder(term); ok {
+			lq.order = order
+			continue
+		}
+		lq.words = append(lq.words, term)
+	}
+	return lq
+}
+
+// parseOrder reads "sort:short" (quickest read first) and "sort:long".
+// Links without a reading time go last either way.
+func parseOrder(term string) (func(a, b model.Link) int, bool) {
+	var sign int
+	switch term {
+	case "sort:short":
+		sign = 1
+	case "sort:long":
+		sign = -1
+	default:
+		return nil, false
+	}
+	return func(a, b model.Link) int {
+		if (a.ReadingTime == 0) != (b.ReadingTime == 0) {
+			if a.ReadingTime == 0 {
+				return 1
+			}
+			return -1
+		}
+		return sign * cmp.Compare(a.ReadingTime, b.ReadingTime)
+	}, true
+}
+
+func parseFilter(term string) (func(model.Link) bool, bool) {
+	switch term {
+	case "is:unread":
+		return model.Link.Unread, true
+	case "is:read":
+		return func(l model.Link) bool { return !l.Unread() }, true
+	}
+	for _, op := range queryOps {
+		field, value, ok := strings.Cut(term, op)
+		if !ok || value == "" {
+			continue
+		}
+		switch field {
+		case "min":
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, false
+			}
+			return func(l model.Link) bool {
+				// Links whose text has not been fetched never match.
+				return l.ReadingTime > 0 && compare(int64(readingMinutes(l.ReadingTime)), op, int64(n))
+			}, true
+		case "words":
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, false
+			}
+			return func(l model.Link) bool {
+				return l.WordCount > 0 && compare(int64(l.WordCount), op, int64(n))
+			}, true
+		case "dur":
+			d, err := parseQueryDuration(value)
+			if err != nil {
+				return nil, false
+			}
+			return func(l model.Link) bool {
+				// Links without a known duration never match.
+				return l.MediaDuration > 0 && compare(int64(l.MediaDuration), op, int64(d))
+			}, true
+		}
+		return nil, false
+	}
+	return nil, false
+}
+
+// parseQueryDuration reads "15m", "1h30m" or a bare number of minutes.
+func parseQueryDuration(s string) (time.Duration, error) {
+	if n, err := strconv.Atoi(s); err == nil {
+		return time.Duration(n) * time.Minute, nil
+	}
+	return time.ParseDuration(s)
+}
+
+func compare(a int64, op string, b int64) bool {
+	switch op {
+	case "<":
+		return a < b
+	case "<=":
+		return a <= b
+	case ">":
+		return a > b
+	case ">=":
+		return a >= b
+	}
+	return a == b
+}
+
+// matches reports whether l has every word and passes every filter.
+func (q linkQuery) matches(l model.Link) bool {
+	haystack := strings.ToLower(l.Title + " " + l.URL + " " + l.ResolvedURL + " " + strings.Join(l.Tags, " "))
+	for _, w := range q.words {
+		if !strings.Contains(haystack, w) {
+			return false
+		}
+	}
+	for _, f := range q.filters {
+		if !f(l) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,139 @@
+package ui
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/alexzajac/the-dredger/internal/model"
+)
+
+func TestParseFilter(t *testing.T) {
+	short := model.Link{MediaDuration: 10 * time.Minute}
+	long := model.Link{MediaDuration: 90 * time.Minute}
+	unknown := model.Link{}
+	read := model.Link{ReadAt: time.Now()}
+	quick := model.Link{WordCount: 400, ReadingTime: 100 * time.Second}
+	essay := model.Link{WordCount: 5000, ReadingTime: 21 * time.Minute}
+
+	cases := []struct {
+		term  string
+		ok    bool
+		match map[string]bool // by link name, for terms that parse
+	}{
+		{"dur<15m", true, map[string]bool{"short": true, "long": false, "unknown": false}},
+		{"dur>1h", true, map[string]bool{"short": false, "long": true, "unknown": false}},
+		{"dur>=90", true, map[string]bool{"short": false, "long": true}},
+		{"dur<=10m", true, map[string]bool{"short": true, "long": false}},
+		{"dur=1h30m", true, map[string]bool{"short": false, "long": true}},
+		{"dur<1.5h", true, map[string]bool{"short": true, "long": false}},
+		{"is:unread", true, map[string]bool{"unknown": true, "read": false}},
+		{"is:read", true, map[string]bool{"unknown": false, "read": true}},
+		{"min<5", true, map[string]bool{"quick": true, "essay": false, "unknown": false}},
+		{"min=2", true, map[string]bool{"quick": true, "essay": false}},
+		{"min>=21", true, map[string]bool{"quick": false, "essay": true}},
+		{"words>1000", true, map[string]bool{"quick": false, "essay": true, "unknown": false}},
+		{"words<=400", true, map[string]bool{"quick": true, "essay": false}},
+		{"min<5m", false, nil},
+		{"words>1k", false, nil},
+		{"dur<", false, nil},
+		{"dur<soon", false, nil},
+		{"dur~15m", false, nil},
+		{"length<15m", false, nil},
+		{"is:starred", false, nil},
+		{"golang", false, nil},
+	}
+	links := map[string]model.Link{
+		"short": short, "long": long, "unknown": unknown, "read": read, "quick": quick, "essay": essay,
+	}
+	for _, c := range cases {
+		f, ok := parseFilter(c.term)
+		if ok != c.ok {
+			t.Errorf("parseFilter(%q) ok = %v, want %v", c.term, ok, c.ok)
+			continue
+		}
+		for name, want := range c.match {
+			if got := f(links[name]); got != want {
+				t.Errorf("parseFilter(%q) on %s link = %v, want %v", c.term, name, got, want)
+			}
+		}
+	}
+}
+
+func TestParseQuery(t *testing.T) {
+	cases := []struct {
+		q       string
+		words   []string
+		filters int
+	}{
+		{"", nil, 0},
+		{"Go TUI", []string{"go", "tui"}, 0},
+		{"talk dur<15m", []string{"talk"}, 1},
+		{"DUR>1H is:Unread rust", []string{"rust"}, 2},
+		{"dur<soon podcast", []string{"dur<soon", "podcast"}, 0},
+		{"a=b", []string{"a=b"}, 0},
+		{"min<10 words>500 is:read", nil, 3},
+		{"sort:short essay", []string{"essay"}, 0},
+		{"sort:wide", []string{"sort:wide"}, 0},
+	}
+	for _, c := range cases {
+		q := parseQuery(c.q)
+		if !slices.Equal(q.words, c.words) || len(q.filters) != c.filters {
+			t.Errorf("parseQuery(%q) = words %q, %d filters; want %q, %d", c.q, q.words, len(q.filters), c.words, c.filters)
+		}
+	}
+}
+
+func TestQueryMatches(t *testing.T) {
+	talk := model.Link{Title: "A Go talk", URL: "https://example.com/talk", Tags: []string{"golang"}, MediaDuration: 12 * time.Minute}
+	cases := map[string]bool{
+		"go talk":          true,
+		"golang dur<15m":   true,
+		"talk dur>15m":     false,
+		"rust dur<15m":     false,
+		"example is:read":  false,
+		"talk is:unread":   true,
+		"dur<soon":         false,
+		"EXAMPLE.COM/TALK": true,
+	}
+	for q, want := range cases {
+		if got := parseQuery(q).matches(talk); got != want {
+			t.Errorf("parseQuery(%q).matches = %v, want %v", q, got, want)
+		}
+	}
+}
+
+func TestParseOrder(t *testing.T) {
+	links := []model.Link{
+		{ID: 1},
+		{ID: 2, ReadingTime: 20 * time.Minute},
+		{ID: 3, ReadingTime: 2 * time.Minute},
+		{ID: 4, ReadingTime: 8 * time.Minute},
+	}
+	cases := map[string][]int64{
+		"sort:short": {3, 4, 2, 1},
+		"sort:long":  {2, 4, 3, 1},
+	}
+	for term, want := range cases {
+		order, ok := parseOrder(term)
+		if !ok {
+			t.Errorf("parseOrder(%q) did not parse", term)
+			continue
+		}
+		sorted := slices.Clone(links)
+		slices.SortStableFunc(sorted, order)
+		var got []int64
+		for _, l := range sorted {
+			got = append(got, l.ID)
+		}
+		if !slices.Equal(got, want) {
+			t.Errorf("%s ordered %v, want %v", term, got, want)
+		}
+	}
+	if _, ok := parseOrder("sort:random"); ok {
+		t.Error("expected an unknown order to be rejected")
+	}
+	if q := parseQuery("sort:long"); q.order == nil {
+		t.Error("expected parseQuery to pick up the order")
+	}
+}
@@ -0,0 +1,329 @@
+package ui
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"charm.land/bubbles/v2/viewport"
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/alexzajac/the-dredger/internal/config"
+	"github.com/alexzajac/the-dredger/internal/db"
+	"github.com/alexzajac/the-dredger/internal/dredge"
+	"github.com/alexzajac/the-dredger/internal/model"
+)
+
+// readerMaxWidth keeps lines short enough to read comfortably on wide
+// terminals.
+const readerMaxWidth = 80
+
+var (
+	readerHeadingStyle = lipgloss.NewStyle().
+				Bold(true).
+				Foreground(activeColor)
+
+	readerTextStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#E0E0E0"))
+
+	readerQuoteStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("#A8A8C8")).
+				Italic(true).
+				Border(lipgloss.ThickBorder(), false, false, false, true).
+				BorderForeground(lipgloss.Color("#555555")).
+				PaddingLeft(1)
+
+	readerBulletStyle = lipgloss.NewStyle().
+				Foreground(accentColor)
+)
+
+// ReaderModel shows a link's article text in a scrollable viewport, so
+// links can be read without a browser, e.g. over SSH. The text comes from
+// the database, or from the offline copy or a fresh crawl when none is
+// stored. The scroll position and how far through the text the reader
+// got are remembered per link, and the link is marked read when the end is
+// reached.
+type ReaderModel struct {
+	db   *sql.DB
+	cfg  config.Config
+	keys config.ReaderKeys
+	link model.Link
+
+	text     string
+	loading  bool
+	err      error
+	viewport viewport.Model
+	// progress is the furthest through the text (0 to 1) the bottom of
+	// the viewport has been, and finished whether it reached the end.
+	progress float64
+	finished bool
+
+	width, height int
+}
+
+func NewReaderModel(database *sql.DB, cfg config.Config, link model.Link, width, height int) ReaderModel {
+	m := ReaderModel{
+		db:       database,
+		cfg:      cfg,
+		keys:     cfg.Keys.Reader,
+		link:     link,
+		loading:  true,
+		viewport: viewport.New(),
+	}
+	m.setSize(width, height)
+	return m
+}
+
+func (m ReaderModel) Init() tea.Cmd {
+	return m.loadText
+}
+
+func (m ReaderModel) loadText() tea.Msg {
+	content, err := db.GetLinkContent(m.db, m.link.ID)
+	if err != nil {
+		return ReaderTextLoadedMsg{LinkID: m.link.ID, Err: err}
+	}
+	if content != nil && content.Text != "" {
+		return ReaderTextLoadedMsg{LinkID: m.link.ID, Text: content.Text}
+	}
+	svc, err := dredge.NewService(m.db, m.cfg)
+	if err != nil {
+		return ReaderTextLoadedMsg{LinkID: m.link.ID, Err: err}
+	}
+	text, err := svc.FetchText(context.Background(), m.link)
+	return ReaderTextLoadedMsg{LinkID: m.link.ID, Text: text, Err: err}
+}
+
+// setSize fits the viewport to the window, keeping the reader at the same
+// point in the text.
+func (m *ReaderModel) setSize(width, height int) {
+	m.width, m.height = width, height
+	position := m.position()
+	// Header (2) + blank (1) + status bar (1) + margins (2)
+	m.viewport.SetWidth(max(width-4, 20))
+	m.viewport.SetHeight(max(height-6, 3))
+	if m.text != "" {
+		m.viewport.SetContent(renderArticle(m.text, m.textWidth()))
+		m.scrollTo(position)
+	}
+}
+
+func (m ReaderModel) textWidth() int {
+	return min(m.viewport.Width(), readerMaxWidth)
+}
+
+// position is how far through the text (0 to 1) the top of the viewport
+// is.
+func (m ReaderModel) position() float64 {
+	if m.text == "" {
+		return m.link.ReaderPosition
+	}
+	if m.viewport.TotalLineCount() <= m.viewport.Height() {
+		return 0
+	}
+	return m.viewport.ScrollPercent()
+}
+
+func (m *ReaderModel) scrollTo(position float64) {
+	scrollable := m.viewport.TotalLineCount() - m.viewport.Height()
+	m.viewport.SetYOffset(int(position*float64(max(scrollable, 0)) + 0.5))
+}
+
+// seen is how far through the text (0 to 1) the bottom of the viewport
+// is.
+func (m ReaderModel) seen() float64 {
+	total := m.viewport.TotalLineCount()
+	if total == 0 {
+		return 0
+	}
+	return min(float64(m.viewport.YOffset()+m.viewport.Height())/float64(total), 1)
+}
+
+// track notes how far the reader has got, and marks the link read the
+// first time the end of the text is on screen.
+func (m *ReaderModel) track() {
+	if m.text == "" {
+		return
+	}
+	m.progress = max(m.progress, m.seen())
+	if m.finished || !m.viewport.AtBottom() {
+		return
+	}
+	m.finished = true
+	m.err = markRead(m.db, &m.link)
+}
+
+// exit saves the reading position and hands the updated link back. A text
+// read to the end opens at the top next time.
+func (m ReaderModel) exit() tea.Cmd {
+	link := m.save()
+	return func() tea.Msg { return ReaderExitMsg{Link: link} }
+}
+
+// save remembers where the reader is in the link, and how far it has got,
+// returning the link as saved. A link read to the end starts from the top
+// next time.
+func (m ReaderModel) save() model.Link {
+	link := m.link
+	if m.text != "" {
+		link.ReaderPosition = m.position()
+		if m.viewport.AtBottom() {
+			link.ReaderPosition = 0
+		}
+		link.Progress = max(link.Progress, m.progress)
+		_ = db.UpdateReaderPosition(m.db, link.ID, link.ReaderPosition, link.Progress)
+	}
+	return link
+}
+
+func (m ReaderModel) Update(msg tea.Msg) (ReaderModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case ReaderTextLoadedMsg:
+		if msg.LinkID != m.link.ID {
+			return m, nil
+		}
+		m.loading = false
+		m.err = msg.Err
+		m.text = msg.Text
+		if m.text != "" {
+			m.viewport.SetContent(renderArticle(m.text, m.textWidth()))
+			m.scrollTo(m.link.ReaderPosition)
+			m.track()
+		}
+		return m, nil
+
+	case tea.KeyPressMsg:
+		switch msg.String() {
+		case m.keys.ScrollDown, "down":
+			m.viewport.ScrollDown(1)
+		case m.keys.ScrollUp, "up":
+			m.viewport.ScrollUp(1)
+		case m.keys.PageDown, "pgdown":
+			m.viewport.PageDown()
+		case m.keys.PageUp, "pgup":
+			m.viewport.PageUp()
+		case m.keys.Top, "home":
+			m.viewport.GotoTop()
+		case m.keys.Bottom, "end":
+			m.viewport.GotoBottom()
+		case keyEsc:
+			return m, m.exit()
+		}
+		m.track()
+		return m, nil
+	}
+	return m, nil
+}
+
+func (m ReaderModel) View() string {
+	title := m.link.Title
+	if title == "" {
+		title = m.link.URL
+	}
+	if r := []rune(title); len(r) > m.width-6 && m.width > 9 {
+		title = string(r[:m.width-9]) + "..."
+	}
+	sub := extractDomain(m.link.FinalURL())
+	if !m.link.ReadAt.IsZero() {
+		sub += " · read " + m.link.ReadAt.Local().Format("2 Jan 2006")
+	}
+	header := titleStyle.Render(title) + "\n" + cardURLStyle.Render(" "+sub)
+
+	var body string
+	switch {
+	case m.loading:
+		body = reviewDimStyle.Render("Loading " + m.link.URL + "...")
+	case m.err != nil && m.text == "":
+		body = lipgloss.NewStyle().Foreground(pruneColor).Render("Error: " + m.err.Error())
+	case m.text == "":
+		body = reviewDimStyle.Render("No readable text found on this page.")
+	default:
+		body = m.viewport.View()
+	}
+
+	progress := ""
+	if m.text != "" {
+		progress = fmt.Sprintf("%3.f%%  ", m.position()*100)
+		if m.viewport.TotalLineCount() <= m.viewport.Height() || m.viewport.AtBottom() {
+			progress = "end  "
+		}
+	}
+	statusBar := statusBarStyle.Width(m.width).Render(
+		progress +
+			statusTextStyle.Render(m.keys.ScrollDown+"/"+m.keys.ScrollUp) + " scroll  " +
+			statusTextStyle.Render(m.keys.PageDown+"/"+m.keys.PageUp) + " page  " +
+			statusTextStyle.Render(m.keys.Top+"/"+m.keys.Bottom) + " top/end  " +
+			statusTextStyle.Render("Esc") + " back",
+	)
+
+	return docStyle.Render(header+"\n\n"+body) + "\n" + statusBar
+}
+
+// markRead records a read of link now, updating it in place.
+func markRead(database *sql.DB, link *model.Link) error {
+	now := time.Now()
+	if err := db.MarkRead(database, link.ID, now); err != nil {
+		return err
+	}
+	link.ReadAt = now
+	link.ReadCount++
+	link.Progress = 1
+	return nil
+}
+
+// toggleRead marks link read, or unread if it already is, updating it in
+// place.
+func toggleRead(database *sql.DB, link *model.Link) error {
+	if link.Unread() {
+		return markRead(database, link)
+	}
+	if err := db.MarkUnread(database, link.ID); err != nil {
+		return err
+	}
+	link.ReadAt, link.Progress, link.ReaderPosition = time.Time{}, 0, 0
+	return nil
+}
+
+// copyReading copies the reading state of src onto dst.
+func copyReading(dst *model.Link, src model.Link) {
+	dst.ReadAt = src.ReadAt
+	dst.ReadCount = src.ReadCount
+	dst.Progress = src.Progress
+	dst.ReaderPosition = src.ReaderPosition
+}
+
+// renderArticle styles text in the form the content extractor writes it —
+// "#" headings, "-" list items, "> " quotes and paragraphs separated by
+// blank lines — wrapped to width.
+func renderArticle(text string, width int) string {
+	var out []string
+	for _, line := range strings.Split(text, "\n") {
+		trimmed := strings.TrimLeft(line, " ")
+		indent := len(line) - len(trimmed)
+		switch {
+		case trimmed == "":
+			out = append(out, "")
+		case strings.HasPrefix(trimmed, "#"):
+			heading := strings.TrimSpace(strings.TrimLeft(trimmed, "#"))
+			out = append(out, readerHeadingStyle.Width(width).Render(heading))
+		case strings.HasPrefix(trimmed, "- "):
+			bullet := strings.Repeat(" ", indent) + readerBulletStyle.Render("•") + " "
+			hang := strings.Repeat(" ", indent+2)
+			item := readerTextStyle.Width(max(width-indent-2, 10)).Render(trimmed[2:])
+			for i, l := range strings.Split(item, "\n") {
+				if i == 0 {
+					out = append(out, bullet+l)
+				} else {
+					out = append(out, hang+l)
+				}
+			}
+		case strings.HasPrefix(trimmed, "> "):
+			out = append(out, readerQuoteStyle.Width(width).Render(trimmed[2:]))
+		default:
+			out = append(out, readerTextStyle.Width(width).Render(line))
+		}
+	}
+	return strings.Join(out, "\n")
+}
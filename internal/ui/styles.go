@@ -52,6 +52,24 @@ var (
 	cardDescStyle = lipgloss.NewStyle().
 			Foreground(lipgloss.Color("#C0C0C0"))
 
+	cardBylineStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#A8A8C8"))
+
+	cardDetailsStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("#D4B86A"))
+
+	cardArchiveStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("#7FB3D5"))
+
+	cardReadStyle = lipgloss.NewStyle().
+			Foreground(accentColor)
+
+	deadBadgeStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#FFFDF5")).
+			Background(pruneColor).
+			Bold(true).
+			Padding(0, 1)
+
 	tagPillStyle = lipgloss.NewStyle().
 			Foreground(lipgloss.Color("#FFFDF5")).
 			Background(activeColor).
@@ -0,0 +1,163 @@
+package ui
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	tea "charm.land/bubbletea/v2"
+	"charm.land/lipgloss/v2"
+	"github.com/alexzajac/the-dredger/internal/config"
+	"github.com/alexzajac/the-dredger/internal/db"
+	"github.com/alexzajac/the-dredger/internal/model"
+)
+
+var (
+	reviewSelectedStyle = lipgloss.NewStyle().
+				Foreground(lipgloss.Color("#FFFDF5")).
+				Background(lipgloss.Color("#4A3D6B"))
+
+	reviewDimStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#9B9B9B"))
+)
+
+// TagReviewModel lists LLM-suggested tags that fell outside the existing
+// vocabulary so they can be approved onto their link or rejected.
+type TagReviewModel struct {
+	db          *sql.DB
+	keys        config.TagReviewKeys
+	suggestions []model.SuggestedTag
+	cursor      int
+	scroll      int
+	err         error
+
+	width, height int
+}
+
+func NewTagReviewModel(database *sql.DB, keys config.TagReviewKeys, width, height int) TagReviewModel {
+	return TagReviewModel{
+		db:     database,
+		keys:   keys,
+		width:  width,
+		height: height,
+	}
+}
+
+func (m TagReviewModel) Init() tea.Cmd {
+	return m.loadSuggestions
+}
+
+func (m TagReviewModel) loadSuggestions() tea.Msg {
+	suggestions, err := db.GetSuggestedTags(m.db)
+	return SuggestedTagsLoadedMsg{Suggestions: suggestions, Err: err}
+}
+
+func (m TagReviewModel) Update(msg tea.Msg) (TagReviewModel, tea.Cmd) {
+	m, cmd := m.update(msg)
+	m.clampScroll()
+	return m, cmd
+}
+
+func (m TagReviewModel) update(msg tea.Msg) (TagReviewModel, tea.Cmd) {
+	switch msg := msg.(type) {
+	case SuggestedTagsLoadedMsg:
+		m.suggestions = msg.Suggestions
+		m.err = msg.Err
+		m.cursor = min(m.cursor, max(len(m.suggestions)-1, 0))
+		return m, nil
+
+	case tea.KeyPressMsg:
+		switch msg.String() {
+		case "j", "down":
+			if m.cursor < len(m.suggestions)-1 {
+				m.cursor++
+			}
+		case "k", "up":
+			if m.cursor > 0 {
+				m.cursor--
+			}
+		case m.keys.Approve:
+			if s := m.selected(); s != nil {
+				m.err = db.ApproveSuggestedTag(m.db, s.ID)
+				return m, m.loadSuggestions
+			}
+		case m.keys.Reject:
+			if s := m.selected(); s != nil {
+				m.err = db.RejectSuggestedTag(m.db, s.ID)
+				return m, m.loadSuggestions
+			}
+		case keyEsc:
+			return m, func() tea.Msg { return TagReviewExitMsg{} }
+		}
+	}
+	return m, nil
+}
+
+func (m *TagReviewModel) setSize(width, height int) {
+	m.width, m.height = width, height
+	m.clampScroll()
+}
+
+// visibleRows is how many suggestions fit on screen.
+func (m TagReviewModel) visibleRows() int {
+	// Header (1) + blank (1) + status bar (1) + margins (2)
+	return max(m.height-5, 1)
+}
+
+// clampScroll scrolls the list just enough to keep the cursor on screen.
+func (m *TagReviewModel) clampScroll() {
+	m.scroll = max(min(m.scroll, m.cursor), m.cursor-m.visibleRows()+1, 0)
+}
+
+func (m *TagReviewModel) selected() *model.SuggestedTag {
+	if m.cursor < 0 || m.cursor >= len(m.suggestions) {
+		return nil
+	}
+	return &m.suggestions[m.cursor]
+}
+
+func (m TagReviewModel) View() string {
+	header := titleStyle.Render(fmt.Sprintf("Tag Review — %d suggested", len(m.suggestions)))
+
+	var body string
+	if len(m.suggestions) == 0 {
+		body = reviewDimStyle.Render("No suggested tags waiting for review.")
+	} else {
+		end := min(m.scroll+m.visibleRows(), len(m.suggestions))
+
+		titleW := max(m.width-40, 20)
+		var lines []string
+		for i := m.scroll; i < end; i++ {
+			s := m.suggestions[i]
+			title := s.LinkTitle
+			if title == "" {
+				title = s.LinkURL
+			}
+			if r := []rune(title); len(r) > titleW {
+				title = string(r[:titleW-3]) + "..."
+			}
+			// Pad by display width: the pill's escape codes take no room.
+			pill := tagPillStyle.Render(s.Tag)
+			pill += strings.Repeat(" ", max(20-lipgloss.Width(pill), 0))
+			line := fmt.Sprintf(" %s %3.0f%%  %s", pill, s.Confidence*100, title)
+			if i == m.cursor {
+				line = reviewSelectedStyle.Render(line)
+			}
+			lines = append(lines, line)
+		}
+		body = strings.Join(lines, "\n")
+	}
+
+	if m.err != nil {
+		body += "\n\n" + lipgloss.NewStyle().Foreground(pruneColor).Render("Error: "+m.err.Error())
+	}
+
+	statusBar := statusBarStyle.Width(m.width).Render(
+		statusTextStyle.Render(m.keys.Approve) + " approve  " +
+			statusTextStyle.Render(m.keys.Reject) + " reject  " +
+			statusTextStyle.Render("↑↓") + " navigate  " +
+			statusTextStyle.Render("Esc") + " back",
+	)
+
+	return docStyle.Render(header+"\n\n"+body) + "\n" + statusBar
+}